@@ -0,0 +1,262 @@
+// Package cache implements cache-aside on top of Redis
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotFound is returned by loaders for missing values. Not found results
+// are cached for Config.NegativeTTL and returned as ErrNotFound.
+var ErrNotFound = errors.New("cache: not found")
+
+// Config holds cache configuration
+type Config struct {
+	// Prefix is prepended to all keys
+	Prefix string `yaml:"prefix" env:"CACHE_PREFIX"`
+	// NegativeTTL - how long ErrNotFound results are cached, 0 disables negative caching
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"CACHE_NEGATIVE_TTL" env-default:"1m"`
+	// StaleTTL - how long expired values are still served while being
+	// refreshed in background, 0 disables stale-while-revalidate
+	StaleTTL time.Duration `yaml:"stale_ttl" env:"CACHE_STALE_TTL" env-default:"0s"`
+	// WriteTimeout bounds cache writes, including background refreshes
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"CACHE_WRITE_TIMEOUT" env-default:"3s"`
+
+	// Local - optional in-process L1 cache invalidated via Redis pub/sub
+	Local LocalConfig `yaml:"local"`
+}
+
+// Cache is a Redis backed cache with request coalescing and optional
+// in-process L1
+type Cache struct {
+	client *redis.Client
+	cfg    Config
+	group  singleflight.Group
+
+	local        *localCache
+	instanceID   string
+	subscription *redis.Subscription
+}
+
+// entry is the stored representation of a cached value
+type entry struct {
+	Value     json.RawMessage `json:"v,omitempty"`
+	ExpiresAt int64           `json:"e"` // unix milliseconds
+	NotFound  bool            `json:"n,omitempty"`
+}
+
+// fresh reports whether entry is not expired
+func (e *entry) fresh(now time.Time) bool {
+	return now.UnixMilli() < e.ExpiresAt
+}
+
+// New creates a new cache
+func New(client *redis.Client, cfg Config) *Cache {
+	// Apply defaults if not set
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 3 * time.Second
+	}
+
+	if cfg.Local.TTL <= 0 {
+		cfg.Local.TTL = 30 * time.Second
+	}
+	if cfg.Local.Channel == "" {
+		cfg.Local.Channel = "cache:invalidate"
+	}
+
+	c := &Cache{client: client, cfg: cfg}
+	if cfg.Local.Size > 0 {
+		c.local = newLocalCache(cfg.Local.Size, cfg.Local.TTL)
+		// Without invalidation L1 would serve stale values, so disable it
+		if err := c.startInvalidation(); err != nil {
+			logger.Error("cache invalidation subscription failed, L1 disabled", zap.Error(err))
+			c.local = nil
+		}
+	}
+	return c
+}
+
+// Close stops L1 invalidation subscription
+func (c *Cache) Close() error {
+	if c.subscription != nil {
+		return c.subscription.Close()
+	}
+	return nil
+}
+
+// GetOrSet returns cached value for key or loads it with loader and caches
+// it for ttl. Concurrent misses for the same key share one loader call.
+// When StaleTTL is set, expired values are returned immediately and
+// refreshed in background. Loader errors other than ErrNotFound are not cached.
+func GetOrSet[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, loader func() (T, error)) (T, error) {
+	var zero T
+
+	e, ok := c.get(ctx, key)
+	if ok {
+		if e.fresh(time.Now()) {
+			return decode[T](e)
+		}
+		// Stale entry - serve it and refresh once in background
+		go func() {
+			_, _, _ = c.group.Do(key, func() (any, error) {
+				return c.load(context.WithoutCancel(ctx), key, ttl, wrapLoader(loader))
+			})
+		}()
+		return decode[T](e)
+	}
+
+	v, err, _ := c.group.Do(key, func() (any, error) {
+		return c.load(ctx, key, ttl, wrapLoader(loader))
+	})
+	if err != nil {
+		return zero, err
+	}
+	value, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("cache key %s loaded as %T", key, v)
+	}
+	return value, nil
+}
+
+// Set stores value for key
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	return c.set(ctx, key, &entry{Value: data}, ttl)
+}
+
+// Delete removes keys
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	full := make([]string, len(keys))
+	for i, key := range keys {
+		full[i] = c.key(key)
+	}
+	if err := c.client.Del(ctx, full...).Err(); err != nil {
+		return err
+	}
+
+	if c.local != nil {
+		for _, key := range full {
+			c.local.delete(key)
+			c.invalidate(ctx, key)
+		}
+	}
+	return nil
+}
+
+// load calls loader and stores the result
+func (c *Cache) load(ctx context.Context, key string, ttl time.Duration, loader func() (any, error)) (any, error) {
+	v, err := loader()
+	if errors.Is(err, ErrNotFound) {
+		if c.cfg.NegativeTTL > 0 {
+			c.store(ctx, key, &entry{NotFound: true}, c.cfg.NegativeTTL)
+		}
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal value: %w", err)
+	}
+	c.store(ctx, key, &entry{Value: data}, ttl)
+	return v, nil
+}
+
+// get reads entry from L1 or Redis; Redis errors are logged and treated as a miss
+func (c *Cache) get(ctx context.Context, key string) (*entry, bool) {
+	key = c.key(key)
+	if c.local != nil {
+		if e, ok := c.local.get(key); ok {
+			return e, true
+		}
+	}
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !redis.IsNil(err) {
+			logger.WithContext(ctx).Warn("cache get failed", zap.String("key", key), zap.Error(err))
+		}
+		return nil, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		logger.WithContext(ctx).Warn("cache entry corrupted", zap.String("key", key), zap.Error(err))
+		return nil, false
+	}
+
+	if c.local != nil {
+		c.local.set(key, &e)
+	}
+	return &e, true
+}
+
+// store writes entry; errors are logged since the value is already loaded
+func (c *Cache) store(ctx context.Context, key string, e *entry, ttl time.Duration) {
+	if err := c.set(ctx, key, e, ttl); err != nil {
+		logger.WithContext(ctx).Warn("cache set failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// set writes entry keeping it in Redis for StaleTTL after expiry
+func (c *Cache) set(ctx context.Context, key string, e *entry, ttl time.Duration) error {
+	e.ExpiresAt = time.Now().Add(ttl).UnixMilli()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal entry: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cfg.WriteTimeout)
+	defer cancel()
+
+	key = c.key(key)
+	if err := c.client.Set(ctx, key, data, ttl+c.cfg.StaleTTL).Err(); err != nil {
+		return err
+	}
+
+	if c.local != nil {
+		c.local.set(key, e)
+		c.invalidate(ctx, key)
+	}
+	return nil
+}
+
+// key returns prefixed key
+func (c *Cache) key(key string) string {
+	return c.cfg.Prefix + key
+}
+
+// wrapLoader adapts typed loader for singleflight
+func wrapLoader[T any](loader func() (T, error)) func() (any, error) {
+	return func() (any, error) {
+		return loader()
+	}
+}
+
+// decode returns value stored in entry
+func decode[T any](e *entry) (T, error) {
+	var v T
+	if e.NotFound {
+		return v, ErrNotFound
+	}
+	if err := json.Unmarshal(e.Value, &v); err != nil {
+		return v, fmt.Errorf("unmarshal cached value: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+)
+
+func newTestCache(t *testing.T, cfg Config) *Cache {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := &redis.Client{Client: goredis.NewClient(&goredis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	return New(client, cfg)
+}
+
+func TestGetOrSetCoalescesLoads(t *testing.T) {
+	c := newTestCache(t, Config{})
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	loader := func() (string, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := GetOrSet(ctx, c, "k", time.Minute, loader); err != nil || v != "value" {
+				t.Errorf("GetOrSet = %q, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, err := GetOrSet(ctx, c, "k", time.Minute, loader); err != nil || v != "value" {
+		t.Errorf("cached GetOrSet = %q, %v", v, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times, want 1", n)
+	}
+}
+
+func TestGetOrSetNegativeCaching(t *testing.T) {
+	c := newTestCache(t, Config{NegativeTTL: time.Minute})
+	ctx := context.Background()
+
+	var calls int
+	loader := func() (int, error) {
+		calls++
+		return 0, ErrNotFound
+	}
+
+	for range 2 {
+		if _, err := GetOrSet(ctx, c, "missing", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+}
+
+func TestGetOrSetStaleWhileRevalidate(t *testing.T) {
+	c := newTestCache(t, Config{StaleTTL: time.Minute})
+	ctx := context.Background()
+
+	refreshed := make(chan struct{})
+	version := 0
+	loader := func() (int, error) {
+		version++
+		if version == 2 {
+			defer close(refreshed)
+		}
+		return version, nil
+	}
+
+	if v, _ := GetOrSet(ctx, c, "k", 20*time.Millisecond, loader); v != 1 {
+		t.Fatalf("v = %d, want 1", v)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// Expired value is served while refresh runs in background
+	if v, _ := GetOrSet(ctx, c, "k", time.Minute, loader); v != 1 {
+		t.Fatalf("stale v = %d, want 1", v)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("value not refreshed")
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if v, _ := GetOrSet(ctx, c, "k", time.Minute, loader); v != 2 {
+		t.Errorf("refreshed v = %d, want 2", v)
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+	"go.uber.org/zap"
+)
+
+// invalidation is a message removing key from L1 of other instances
+type invalidation struct {
+	Sender string `json:"sender"`
+	Key    string `json:"key"`
+}
+
+// startInvalidation subscribes to invalidation messages of other instances.
+// L1 is purged after reconnects since messages may have been missed.
+func (c *Cache) startInvalidation() error {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	c.instanceID = hex.EncodeToString(id[:])
+
+	sub, err := redis.Subscribe(context.Background(), c.client, c.cfg.Local.Channel,
+		func(_ context.Context, msg invalidation) error {
+			if msg.Sender != c.instanceID {
+				c.local.delete(msg.Key)
+			}
+			return nil
+		},
+		redis.WithResubscribeHook(c.local.purge),
+	)
+	if err != nil {
+		return err
+	}
+	c.subscription = sub
+	return nil
+}
+
+// invalidate removes key from L1 of other instances
+func (c *Cache) invalidate(ctx context.Context, key string) {
+	if c.local == nil {
+		return
+	}
+	err := c.client.PublishJSON(ctx, c.cfg.Local.Channel, invalidation{Sender: c.instanceID, Key: key})
+	if err != nil {
+		logger.WithContext(ctx).Warn("cache invalidation publish failed", zap.String("key", key), zap.Error(err))
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LocalConfig configures in-process L1 cache in front of Redis
+type LocalConfig struct {
+	// Size - max number of entries, 0 disables L1
+	Size int `yaml:"size" env:"CACHE_LOCAL_SIZE" env-default:"0"`
+	// TTL bounds how long an entry lives in L1, so missed invalidations
+	// heal by themselves
+	TTL time.Duration `yaml:"ttl" env:"CACHE_LOCAL_TTL" env-default:"30s"`
+	// Channel - Redis pub/sub channel for invalidation messages
+	Channel string `yaml:"channel" env:"CACHE_LOCAL_CHANNEL" env-default:"cache:invalidate"`
+}
+
+// localCache is a bounded LRU with per-entry expiration
+type localCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List // front is most recently used
+}
+
+type localItem struct {
+	key       string
+	entry     *entry
+	expiresAt time.Time
+}
+
+func newLocalCache(size int, ttl time.Duration) *localCache {
+	return &localCache{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// get returns entry if present and not expired
+func (l *localCache) get(key string) (*entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*localItem)
+	if time.Now().After(item.expiresAt) {
+		l.remove(elem)
+		return nil, false
+	}
+	l.order.MoveToFront(elem)
+	return item.entry, true
+}
+
+// set stores entry evicting the least recently used one if full
+func (l *localCache) set(key string, e *entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	item := &localItem{key: key, entry: e, expiresAt: time.Now().Add(l.ttl)}
+	if elem, ok := l.items[key]; ok {
+		elem.Value = item
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(item)
+	if l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+// delete removes key
+func (l *localCache) delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.items[key]; ok {
+		l.remove(elem)
+	}
+}
+
+// purge removes all entries
+func (l *localCache) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = make(map[string]*list.Element, l.size)
+	l.order.Init()
+}
+
+func (l *localCache) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.items, elem.Value.(*localItem).key)
+}
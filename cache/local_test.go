@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+)
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLocalCache(2, time.Minute)
+	l.set("a", &entry{})
+	l.set("b", &entry{})
+	l.get("a")
+	l.set("c", &entry{})
+
+	if _, ok := l.get("b"); ok {
+		t.Error("b should be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := l.get(key); !ok {
+			t.Errorf("%s should be cached", key)
+		}
+	}
+}
+
+func TestLocalCacheInvalidatedByOtherInstance(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newCache := func() *Cache {
+		client := &redis.Client{Client: goredis.NewClient(&goredis.Options{Addr: mr.Addr()})}
+		c := New(client, Config{Local: LocalConfig{Size: 10}})
+		t.Cleanup(func() {
+			_ = c.Close()
+			_ = client.Close()
+		})
+		return c
+	}
+	c1, c2 := newCache(), newCache()
+	ctx := context.Background()
+	time.Sleep(50 * time.Millisecond) // let subscriptions start
+
+	load := func(v string) func() (string, error) {
+		return func() (string, error) { return v, nil }
+	}
+	if v, _ := GetOrSet(ctx, c1, "user:42", time.Minute, load("old")); v != "old" {
+		t.Fatalf("v = %q", v)
+	}
+	if err := c2.Set(ctx, "user:42", "new", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, _ := GetOrSet(ctx, c1, "user:42", time.Minute, load("loaded"))
+		if v == "new" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("L1 not invalidated, v = %q", v)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
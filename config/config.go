@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -11,73 +12,220 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// Load loads configuration from yaml file and environment variables
-func Load[T any](path string) (*T, error) {
+// AppEnvVar selects the environment overlay for LoadForAppEnv
+const AppEnvVar = "APP_ENV"
+
+// Load loads configuration from yaml files and environment variables, then
+// validates it (env-required and validate tags, Validator interface).
+// Files are applied in order, missing ones are skipped: later files
+// override mappings key by key (nested ones included), lists and scalars
+// are replaced as a whole. Values may reference environment variables as
+// ${VAR} or ${VAR:-default}.
+func Load[T any](paths ...string) (*T, error) {
+	return LoadWithOptions[T](WithFiles(paths...))
+}
+
+// MustLoad loads configuration or panics
+func MustLoad[T any](paths ...string) *T {
+	cfg, err := Load[T](paths...)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// Option configures LoadWithOptions
+type Option func(*options)
+
+type options struct {
+	paths     []string
+	envPrefix string
+}
+
+// WithFiles adds yaml files applied in order, see Load
+func WithFiles(paths ...string) Option {
+	return func(o *options) {
+		o.paths = append(o.paths, paths...)
+	}
+}
+
+// WithEnvPrefix prefixes all environment variable names, e.g. "ORDERS_"
+// reads ORDERS_POSTGRES_HOST for env:"POSTGRES_HOST"
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// LoadWithOptions loads configuration like Load with options
+func LoadWithOptions[T any](opts ...Option) (*T, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var cfg T
 
-	// Load from YAML file if exists
-	if path != "" {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			if !os.IsNotExist(err) {
-				return nil, fmt.Errorf("read config file: %w", err)
-			}
-		} else {
-			if err := yaml.Unmarshal(data, &cfg); err != nil {
-				return nil, fmt.Errorf("parse config file: %w", err)
-			}
+	// Load from YAML files if exist
+	for _, path := range o.paths {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
 		}
 	}
 
 	// Override with environment variables
-	if err := loadFromEnv(&cfg); err != nil {
+	if err := loadFromEnv(&cfg, o.envPrefix); err != nil {
 		return nil, fmt.Errorf("load env vars: %w", err)
 	}
 
+	if err := validate(&cfg, o.envPrefix); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+
 	return &cfg, nil
 }
 
-// MustLoad loads configuration or panics
-func MustLoad[T any](path string) *T {
-	cfg, err := Load[T](path)
+// LoadForAppEnv loads path overlaid with the file for APP_ENV, e.g.
+// config.yaml and config.production.yaml for APP_ENV=production
+func LoadForAppEnv[T any](path string) (*T, error) {
+	return Load[T](appEnvPaths(path, os.Getenv(AppEnvVar))...)
+}
+
+// MustLoadForAppEnv loads configuration for APP_ENV or panics
+func MustLoadForAppEnv[T any](path string) *T {
+	cfg, err := LoadForAppEnv[T](path)
 	if err != nil {
 		panic(err)
 	}
 	return cfg
 }
 
-func loadFromEnv(cfg any) error {
-	return processStruct(reflect.ValueOf(cfg).Elem())
+// appEnvPaths returns path and its overlay for env
+func appEnvPaths(path, env string) []string {
+	if path == "" || env == "" {
+		return []string{path}
+	}
+	ext := filepath.Ext(path)
+	return []string{path, strings.TrimSuffix(path, ext) + "." + env + ext}
+}
+
+// loadFile decodes yaml file into cfg over already loaded values, expanding
+// ${VAR} references in values first
+func loadFile(path string, cfg any) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	// Empty file
+	if node.Kind == 0 {
+		return nil
+	}
+	if err := expandNode(&node); err != nil {
+		return fmt.Errorf("expand config file %s: %w", path, err)
+	}
+	if err := node.Decode(cfg); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func loadFromEnv(cfg any, envPrefix string) error {
+	_, err := processStruct(reflect.ValueOf(cfg).Elem(), prefixed(os.Getenv, envPrefix))
+	return err
 }
 
-func processStruct(v reflect.Value) error {
+// prefixed returns getenv looking up prefixed names
+func prefixed(getenv func(string) string, prefix string) func(string) string {
+	if prefix == "" {
+		return getenv
+	}
+	return func(key string) string { return getenv(prefix + key) }
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// processStruct sets fields from environment variables read with getenv,
+// zero fields get default (or env-default) tag values whether or not the
+// field has an env tag. Reports whether any value came from the environment.
+func processStruct(v reflect.Value, getenv func(string) string) (bool, error) {
 	t := v.Type()
+	fromEnv := false
 
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
 		fieldType := t.Field(i)
-
-		// Handle nested structs
-		if field.Kind() == reflect.Struct {
-			if err := processStruct(field); err != nil {
-				return err
-			}
+		if !fieldType.IsExported() {
 			continue
 		}
 
 		// Get env tag
 		envTag := fieldType.Tag.Get("env")
-		if envTag == "" {
+		// env-prefix applies to fields of nested structs, e.g. USERS_ for
+		// one of several ClientConfigs
+		nestedGetenv := prefixed(getenv, fieldType.Tag.Get("env-prefix"))
+
+		switch {
+		// Handle nested structs
+		case field.Kind() == reflect.Struct && field.Type() != timeType:
+			set, err := processStruct(field, nestedGetenv)
+			if err != nil {
+				return false, err
+			}
+			fromEnv = fromEnv || set
+			continue
+
+		// Handle nested struct pointers, yaml:"-" ones are runtime dependencies (e.g. Metrics)
+		case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct && field.Type().Elem() != timeType:
+			if fieldType.Tag.Get("yaml") == "-" {
+				continue
+			}
+			set, err := processStructPointer(field, nestedGetenv)
+			if err != nil {
+				return false, fmt.Errorf("set field %s: %w", fieldType.Name, err)
+			}
+			fromEnv = fromEnv || set
 			continue
-		}
 
-		// Get default value
-		defaultVal := fieldType.Tag.Get("env-default")
+		// Handle slices of structs from YAML: elements read ENV_0_FIELD,
+		// ENV_1_FIELD, ... or only defaults without env tag
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct && field.Type().Elem() != timeType:
+			for j := 0; j < field.Len(); j++ {
+				elemGetenv := func(string) string { return "" }
+				if envTag != "" {
+					elemGetenv = prefixed(nestedGetenv, envTag+"_"+strconv.Itoa(j)+"_")
+				}
+				set, err := processStruct(field.Index(j), elemGetenv)
+				if err != nil {
+					return false, fmt.Errorf("set field %s[%d]: %w", fieldType.Name, j, err)
+				}
+				fromEnv = fromEnv || set
+			}
+			continue
+		}
 
 		// Get value from environment
-		value := os.Getenv(envTag)
-		if value == "" {
-			value = defaultVal
+		var value string
+		if envTag != "" {
+			value = getenv(envTag)
+		}
+		if value != "" {
+			fromEnv = true
+		} else if field.IsZero() {
+			// Defaults fill only fields not set in YAML, so explicit zero
+			// values (false, 0) can't be told apart and get the default too
+			value = defaultValue(fieldType)
 		}
 
 		if value == "" {
@@ -86,11 +234,37 @@ func processStruct(v reflect.Value) error {
 
 		// Set field value
 		if err := setField(field, value); err != nil {
-			return fmt.Errorf("set field %s: %w", fieldType.Name, err)
+			return false, fmt.Errorf("set field %s: %w", fieldType.Name, err)
 		}
 	}
 
-	return nil
+	return fromEnv, nil
+}
+
+// defaultValue returns default tag or env-default tag value
+func defaultValue(field reflect.StructField) string {
+	if value, ok := field.Tag.Lookup("default"); ok {
+		return value
+	}
+	return field.Tag.Get("env-default")
+}
+
+// processStructPointer processes pointed struct, nil pointer is allocated
+// only if the environment sets some of its fields
+func processStructPointer(field reflect.Value, getenv func(string) string) (bool, error) {
+	if !field.IsNil() {
+		return processStruct(field.Elem(), getenv)
+	}
+
+	value := reflect.New(field.Type().Elem())
+	set, err := processStruct(value.Elem(), getenv)
+	if err != nil || !set {
+		return false, err
+	}
+	if field.CanSet() {
+		field.Set(value)
+	}
+	return true, nil
 }
 
 func setField(field reflect.Value, value string) error {
@@ -98,6 +272,15 @@ func setField(field reflect.Value, value string) error {
 		return nil
 	}
 
+	if field.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -138,14 +321,43 @@ func setField(field reflect.Value, value string) error {
 		}
 		field.SetBool(b)
 
+	case reflect.Pointer:
+		// Pointers to scalars, e.g. *int or *time.Duration
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+
 	case reflect.Slice:
-		if field.Type().Elem().Kind() == reflect.String {
-			parts := strings.Split(value, ",")
-			for i := range parts {
-				parts[i] = strings.TrimSpace(parts[i])
+		// Comma-separated values: "a,b,c"
+		parts := strings.Split(value, ",")
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		field.Set(slice)
+
+	case reflect.Map:
+		// Comma-separated pairs: "key1=value1,key2=value2"
+		if field.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", field.Type().Key())
+		}
+		m := reflect.MakeMap(field.Type())
+		for pair := range strings.SplitSeq(value, ",") {
+			key, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid map entry %q, key=value expected", pair)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, strings.TrimSpace(val)); err != nil {
+				return fmt.Errorf("map entry %q: %w", key, err)
 			}
-			field.Set(reflect.ValueOf(parts))
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(field.Type().Key()), elem)
 		}
+		field.Set(m)
 	}
 
 	return nil
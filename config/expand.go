@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envRefPattern matches $${ escapes and ${VAR}, ${VAR:-default} and ${VAR:?message} references
+var envRefPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::([-?])([^}]*))?\}`)
+
+// expandNode expands environment references in scalar values of node,
+// mapping keys are left as is
+func expandNode(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return expandScalar(node)
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := expandNode(node.Content[i]); err != nil {
+				return fmt.Errorf("%s: %w", node.Content[i-1].Value, err)
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := expandNode(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandScalar replaces references in scalar value:
+//
+//	${VAR}            - value of VAR, empty if unset
+//	${VAR:-default}   - default if VAR is unset or empty
+//	${VAR:?message}   - error with message if VAR is unset or empty
+//	$${               - literal ${
+func expandScalar(node *yaml.Node) error {
+	if !envRefPattern.MatchString(node.Value) {
+		return nil
+	}
+
+	var errs []error
+	node.Value = envRefPattern.ReplaceAllStringFunc(node.Value, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		match := envRefPattern.FindStringSubmatch(ref)
+		name, op, arg := match[1], match[2], match[3]
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		switch op {
+		case "-":
+			return arg
+		case "?":
+			if arg == "" {
+				arg = "not set"
+			}
+			errs = append(errs, fmt.Errorf("%s: %s", name, arg))
+		}
+		return ""
+	})
+
+	// Let plain scalars resolve again, so ${PORT:-5432} decodes into int
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		node.Tag = ""
+	}
+	return errors.Join(errs...)
+}
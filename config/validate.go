@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator is implemented by config structs with checks tags can't
+// express. Validate is called after load for the root and every nested
+// struct.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError describes invalid config field
+type FieldError struct {
+	// Field - dotted path, e.g. Postgres.Host
+	Field string
+	// Env - environment variable of the field, if any
+	Env string
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Env != "" {
+		return fmt.Sprintf("%s (%s): %v", e.Field, e.Env, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ErrRequired is returned for missing env-required fields
+var ErrRequired = errors.New("required")
+
+// validate checks env-required and validate tags and calls Validator,
+// returning all failures joined
+func validate(cfg any, envPrefix string) error {
+	return errors.Join(validateStruct(reflect.ValueOf(cfg).Elem(), "", envPrefix, true)...)
+}
+
+// validateStruct validates fields of v, prefix is the field path and
+// envPrefix prefixes env names in errors unless hasEnv is false
+func validateStruct(v reflect.Value, prefix, envPrefix string, hasEnv bool) []error {
+	var errs []error
+	t := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		name := prefix + fieldType.Name
+		envTag := fieldType.Tag.Get("env")
+		nestedPrefix := envPrefix + fieldType.Tag.Get("env-prefix")
+
+		switch {
+		case field.Kind() == reflect.Struct && field.Type() != timeType:
+			errs = append(errs, validateStruct(field, name+".", nestedPrefix, hasEnv)...)
+			continue
+		case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct && field.Type().Elem() != timeType:
+			if !field.IsNil() && fieldType.Tag.Get("yaml") != "-" {
+				errs = append(errs, validateStruct(field.Elem(), name+".", nestedPrefix, hasEnv)...)
+			}
+			if fieldType.Tag.Get("env-required") != "true" {
+				continue
+			}
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct && field.Type().Elem() != timeType:
+			for j := 0; j < field.Len(); j++ {
+				elemPrefix := fmt.Sprintf("%s%s_%d_", nestedPrefix, envTag, j)
+				errs = append(errs, validateStruct(field.Index(j), fmt.Sprintf("%s[%d].", name, j), elemPrefix, hasEnv && envTag != "")...)
+			}
+		}
+
+		fieldErr := func(err error) error {
+			fe := &FieldError{Field: name, Err: err}
+			if hasEnv && envTag != "" {
+				fe.Env = envPrefix + envTag
+			}
+			return fe
+		}
+
+		if fieldType.Tag.Get("env-required") == "true" && field.IsZero() {
+			errs = append(errs, fieldErr(ErrRequired))
+			continue
+		}
+		if rules := fieldType.Tag.Get("validate"); rules != "" {
+			for rule := range strings.SplitSeq(rules, ",") {
+				if err := checkRule(field, strings.TrimSpace(rule)); err != nil {
+					errs = append(errs, fieldErr(err))
+				}
+			}
+		}
+	}
+
+	if v.CanAddr() {
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				if prefix != "" {
+					err = fmt.Errorf("%s: %w", strings.TrimSuffix(prefix, "."), err)
+				}
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// checkRule checks single rule: required, min=N, max=N, url or oneof=a b c.
+// min and max compare numbers (durations as "1s") or length of strings,
+// slices and maps. url and oneof accept empty values, combine with
+// required if needed.
+func checkRule(field reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if field.IsZero() {
+			return ErrRequired
+		}
+	case "min", "max":
+		value, limit, err := compareValues(field, arg)
+		if err != nil {
+			return fmt.Errorf("invalid rule %q: %w", rule, err)
+		}
+		if name == "min" && value < limit {
+			return fmt.Errorf("must be at least %s", arg)
+		}
+		if name == "max" && value > limit {
+			return fmt.Errorf("must be at most %s", arg)
+		}
+	case "url":
+		if field.Kind() != reflect.String {
+			return fmt.Errorf("invalid rule %q: string field expected", rule)
+		}
+		if s := field.String(); s != "" {
+			u, err := url.Parse(s)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return errors.New("must be an absolute URL")
+			}
+		}
+	case "oneof":
+		if field.Kind() != reflect.String {
+			return fmt.Errorf("invalid rule %q: string field expected", rule)
+		}
+		allowed := strings.Fields(arg)
+		if s := field.String(); s != "" && !slices.Contains(allowed, s) {
+			return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+		}
+	default:
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+	return nil
+}
+
+// compareValues returns field value (or length) and parsed limit as float64
+func compareValues(field reflect.Value, arg string) (float64, float64, error) {
+	switch field.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		limit, err := strconv.Atoi(arg)
+		return float64(field.Len()), float64(limit), err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			limit, err := time.ParseDuration(arg)
+			return float64(field.Int()), float64(limit), err
+		}
+		limit, err := strconv.ParseFloat(arg, 64)
+		return float64(field.Int()), limit, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		limit, err := strconv.ParseFloat(arg, 64)
+		return float64(field.Uint()), limit, err
+	case reflect.Float32, reflect.Float64:
+		limit, err := strconv.ParseFloat(arg, 64)
+		return field.Float(), limit, err
+	default:
+		return 0, 0, fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+}
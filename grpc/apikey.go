@@ -0,0 +1,171 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyHeader is the default metadata key carrying the API key
+const APIKeyHeader = "x-api-key"
+
+// ErrInvalidAPIKey is returned by KeyValidator for unknown keys
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyInfo holds info about the caller authenticated with an API key
+type APIKeyInfo struct {
+	// Name - caller name, e.g. service or cron job name
+	Name string
+}
+
+// KeyValidator validates API keys
+type KeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key string) (*APIKeyInfo, error)
+}
+
+// KeyValidatorFunc adapts a function to KeyValidator
+type KeyValidatorFunc func(ctx context.Context, key string) (*APIKeyInfo, error)
+
+// ValidateAPIKey calls f(ctx, key)
+func (f KeyValidatorFunc) ValidateAPIKey(ctx context.Context, key string) (*APIKeyInfo, error) {
+	return f(ctx, key)
+}
+
+// StaticKeyValidator validates keys from config (name -> key)
+type StaticKeyValidator struct {
+	keys map[string]string
+}
+
+// NewStaticKeyValidator creates validator for keys from config, keyed by caller name
+func NewStaticKeyValidator(keys map[string]string) *StaticKeyValidator {
+	return &StaticKeyValidator{keys: keys}
+}
+
+// ValidateAPIKey compares key with configured keys in constant time
+func (v *StaticKeyValidator) ValidateAPIKey(_ context.Context, key string) (*APIKeyInfo, error) {
+	for name, expected := range v.keys {
+		if expected != "" && subtle.ConstantTimeCompare([]byte(key), []byte(expected)) == 1 {
+			return &APIKeyInfo{Name: name}, nil
+		}
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+// RedisKeyValidator looks up keys in Redis. Keys are stored as SHA-256 hex
+// hashes: prefix + sha256(key) -> caller name.
+type RedisKeyValidator struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisKeyValidator creates Redis-backed key validator
+func NewRedisKeyValidator(client *redis.Client, prefix string) *RedisKeyValidator {
+	if prefix == "" {
+		prefix = "apikey:"
+	}
+	return &RedisKeyValidator{client: client, prefix: prefix}
+}
+
+// ValidateAPIKey looks up caller name by key hash
+func (v *RedisKeyValidator) ValidateAPIKey(ctx context.Context, key string) (*APIKeyInfo, error) {
+	name, err := v.client.Get(ctx, v.prefix+HashAPIKey(key)).Result()
+	if redis.IsNil(err) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeyInfo{Name: name}, nil
+}
+
+// HashAPIKey returns SHA-256 hex hash of the key, as stored by RedisKeyValidator
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyInterceptorConfig holds API key interceptor configuration
+type APIKeyInterceptorConfig struct {
+	// Header - metadata key carrying the key (default x-api-key)
+	Header string
+	// SkipMethods - list of methods to skip auth
+	SkipMethods []string
+	// Optional - pass requests without API key to the next interceptor
+	// (e.g. AuthInterceptor for user traffic with JWT)
+	Optional bool
+}
+
+type apiKeyContextKey struct{}
+
+// GetAPIKeyInfo extracts API key caller info from context
+func GetAPIKeyInfo(ctx context.Context) (*APIKeyInfo, bool) {
+	info, ok := ctx.Value(apiKeyContextKey{}).(*APIKeyInfo)
+	return info, ok
+}
+
+// APIKeyInterceptor creates API key authentication interceptor.
+// Chain it before AuthInterceptor with Optional set to accept both API keys
+// and JWT: requests authenticated with a key skip JWT validation.
+func APIKeyInterceptor(validator KeyValidator, cfg APIKeyInterceptorConfig) grpc.UnaryServerInterceptor {
+	header := cfg.Header
+	if header == "" {
+		header = APIKeyHeader
+	}
+	skipMap := make(map[string]bool)
+	for _, method := range cfg.SkipMethods {
+		skipMap[method] = true
+	}
+
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if skipMap[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		key := GetMetadata(ctx, header)
+		if key == "" {
+			if cfg.Optional {
+				return handler(ctx, req)
+			}
+			logger.WithContext(ctx).Warn("api key missing",
+				zap.String("method", info.FullMethod),
+			)
+			return nil, status.Error(codes.Unauthenticated, "missing api key")
+		}
+
+		keyInfo, err := validator.ValidateAPIKey(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrInvalidAPIKey) {
+				logger.WithContext(ctx).Warn("invalid api key",
+					zap.String("method", info.FullMethod),
+				)
+				return nil, status.Error(codes.Unauthenticated, "invalid api key")
+			}
+			logger.WithContext(ctx).Error("api key validation failed",
+				zap.String("method", info.FullMethod),
+				zap.Error(err),
+			)
+			return nil, status.Error(codes.Unavailable, "api key validation failed")
+		}
+
+		logger.WithContext(ctx).Debug("api key validated",
+			zap.String("method", info.FullMethod),
+			zap.String("caller", keyInfo.Name),
+		)
+
+		return handler(context.WithValue(ctx, apiKeyContextKey{}, keyInfo), req)
+	}
+}
@@ -0,0 +1,129 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// countingJWTValidator accepts token "valid" and counts calls
+type countingJWTValidator struct {
+	calls atomic.Int32
+}
+
+func (v *countingJWTValidator) ValidateAccessToken(token string) (*JWTClaims, error) {
+	v.calls.Add(1)
+	if token != "valid" {
+		return nil, errors.New("invalid token")
+	}
+	return &JWTClaims{UserID: 42}, nil
+}
+
+func TestStaticKeyValidator(t *testing.T) {
+	v := NewStaticKeyValidator(map[string]string{"billing": "key-1", "cron": "key-2", "disabled": ""})
+
+	info, err := v.ValidateAPIKey(context.Background(), "key-2")
+	if err != nil || info.Name != "cron" {
+		t.Fatalf("ValidateAPIKey(key-2) = %v, %v; want cron", info, err)
+	}
+	for _, key := range []string{"key-3", "key-", ""} {
+		if _, err := v.ValidateAPIKey(context.Background(), key); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("ValidateAPIKey(%q) error = %v, want ErrInvalidAPIKey", key, err)
+		}
+	}
+}
+
+func TestRedisKeyValidator(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := &redis.Client{Client: goredis.NewClient(&goredis.Options{Addr: srv.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	v := NewRedisKeyValidator(client, "")
+
+	if err := srv.Set("apikey:"+HashAPIKey("key-1"), "billing"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := v.ValidateAPIKey(context.Background(), "key-1")
+	if err != nil || info.Name != "billing" {
+		t.Fatalf("ValidateAPIKey(key-1) = %v, %v; want billing", info, err)
+	}
+	if _, err := v.ValidateAPIKey(context.Background(), "key-2"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("unknown key error = %v, want ErrInvalidAPIKey", err)
+	}
+
+	// Redis failures are not reported as invalid keys
+	srv.Close()
+	_, err = APIKeyInterceptor(v, APIKeyInterceptorConfig{})(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs(APIKeyHeader, "key-1")),
+		nil, &grpc.UnaryServerInfo{FullMethod: healthCheckMethod},
+		func(ctx context.Context, req any) (any, error) { return nil, nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("error with Redis down = %v, want Unavailable", err)
+	}
+}
+
+func TestAPIKeyInterceptor_WithAuthInterceptor(t *testing.T) {
+	jwtValidator := &countingJWTValidator{}
+	s, err := NewServerWithOptions(ServerConfig{}, WithUnaryInterceptors(
+		APIKeyInterceptor(NewStaticKeyValidator(map[string]string{"billing": "key-1"}), APIKeyInterceptorConfig{Optional: true}),
+		AuthInterceptor(jwtValidator, AuthInterceptorConfig{}),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerHealthCheck(s, func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+		if info, ok := GetAPIKeyInfo(ctx); ok {
+			return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, grpc.SetHeader(ctx, metadata.Pairs("x-caller", info.Name))
+		}
+		if _, ok := GetAuthInfo(ctx); ok {
+			return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, grpc.SetHeader(ctx, metadata.Pairs("x-caller", "user"))
+		}
+		return nil, status.Error(codes.Internal, "handler called without auth")
+	})
+	client := healthpb.NewHealthClient(dialServer(t, s))
+
+	tests := []struct {
+		name       string
+		md         metadata.MD
+		wantCode   codes.Code
+		wantCaller string
+		wantJWT    int32
+	}{
+		{"api key skips jwt", metadata.Pairs(APIKeyHeader, "key-1"), codes.OK, "billing", 0},
+		{"api key with jwt", metadata.Pairs(APIKeyHeader, "key-1", "authorization", "Bearer valid"), codes.OK, "billing", 0},
+		{"jwt without api key", metadata.Pairs("authorization", "Bearer valid"), codes.OK, "user", 1},
+		{"invalid api key", metadata.Pairs(APIKeyHeader, "key-2"), codes.Unauthenticated, "", 0},
+		// An invalid key is rejected, not passed on to JWT auth
+		{"invalid api key with jwt", metadata.Pairs(APIKeyHeader, "key-2", "authorization", "Bearer valid"), codes.Unauthenticated, "", 0},
+		{"invalid jwt", metadata.Pairs("authorization", "Bearer invalid"), codes.Unauthenticated, "", 1},
+		{"no credentials", metadata.MD{}, codes.Unauthenticated, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtValidator.calls.Store(0)
+			var header metadata.MD
+			_, err := client.Check(metadata.NewOutgoingContext(context.Background(), tt.md),
+				&healthpb.HealthCheckRequest{}, grpc.Header(&header))
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("code = %s, want %s: %v", status.Code(err), tt.wantCode, err)
+			}
+			if tt.wantCaller != "" && firstValue(header, "x-caller") != tt.wantCaller {
+				t.Errorf("caller = %v, want %s", header.Get("x-caller"), tt.wantCaller)
+			}
+			if got := jwtValidator.calls.Load(); got != tt.wantJWT {
+				t.Errorf("JWT validations = %d, want %d", got, tt.wantJWT)
+			}
+		})
+	}
+}
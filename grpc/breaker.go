@@ -0,0 +1,264 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CircuitBreakerConfig holds client circuit breaker configuration
+type CircuitBreakerConfig struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// FailureRate - share of failed calls (0..1] within Window that opens the breaker
+	FailureRate float64 `yaml:"failure_rate" env-default:"0.5"`
+	// MinRequests - calls required within Window before FailureRate is evaluated
+	MinRequests int `yaml:"min_requests" env-default:"20"`
+	// Window - period over which calls are counted
+	Window time.Duration `yaml:"window" env-default:"10s"`
+	// OpenTimeout - how long the breaker fails fast before letting probe calls through
+	OpenTimeout time.Duration `yaml:"open_timeout" env-default:"30s"`
+	// HalfOpenRequests - concurrent probe calls allowed after OpenTimeout
+	HalfOpenRequests int `yaml:"half_open_requests" env-default:"1"`
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// callOutcome is the result of a call as seen by the breaker
+type callOutcome int
+
+const (
+	outcomeSuccess callOutcome = iota
+	outcomeFailure
+	// outcomeNeutral says nothing about the dependency's health
+	outcomeNeutral
+)
+
+// circuitBreaker tracks failures of a single target+method
+type circuitBreaker struct {
+	cfg           CircuitBreakerConfig
+	now           func() time.Time
+	onStateChange func(state breakerState)
+
+	mu    sync.Mutex
+	state breakerState
+	// generation changes with every state change, results of calls allowed
+	// in an earlier generation are ignored
+	generation  uint64
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig, onStateChange func(state breakerState)) *circuitBreaker {
+	// Apply defaults if not set
+	if cfg.FailureRate <= 0 {
+		cfg.FailureRate = 0.5
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &circuitBreaker{cfg: cfg, now: time.Now, onStateChange: onStateChange}
+}
+
+// allow reports whether a call may proceed and returns the generation
+// to pass to record
+func (b *circuitBreaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return 0, false
+		}
+		b.setState(breakerHalfOpen)
+		b.probes = 1
+		return b.generation, true
+	case breakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return 0, false
+		}
+		b.probes++
+		return b.generation, true
+	default:
+		if now.Sub(b.windowStart) > b.cfg.Window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+		return b.generation, true
+	}
+}
+
+// record registers outcome of a call allowed in generation. Calls that
+// started before the last state change are ignored, so a slow call allowed
+// while closed can't close or reopen the breaker in place of a probe.
+// Neutral outcomes only free the probe slot.
+func (b *circuitBreaker) record(generation uint64, outcome callOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case breakerHalfOpen:
+		b.probes--
+		switch outcome {
+		case outcomeFailure:
+			b.open()
+		case outcomeSuccess:
+			b.reset()
+		}
+	case breakerClosed:
+		if outcome == outcomeNeutral {
+			return
+		}
+		b.requests++
+		if outcome == outcomeFailure {
+			b.failures++
+		}
+		if b.requests >= b.cfg.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.cfg.FailureRate {
+			b.open()
+		}
+	}
+}
+
+func (b *circuitBreaker) open() {
+	b.openedAt = b.now()
+	b.probes = 0
+	b.setState(breakerOpen)
+}
+
+func (b *circuitBreaker) reset() {
+	b.windowStart = b.now()
+	b.requests, b.failures, b.probes = 0, 0, 0
+	b.setState(breakerClosed)
+}
+
+func (b *circuitBreaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	b.generation++
+	if b.onStateChange != nil {
+		b.onStateChange(state)
+	}
+}
+
+// breakerOutcome classifies call error. Errors indicating an unhealthy
+// dependency are failures, business errors (NotFound, InvalidArgument, ...)
+// are successes. Canceled calls are neutral: the caller gave up, the
+// dependency may still be down.
+func breakerOutcome(err error) callOutcome {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return outcomeFailure
+	case codes.Canceled:
+		return outcomeNeutral
+	default:
+		return outcomeSuccess
+	}
+}
+
+// breakerOpenError is returned while the breaker is open. It has status
+// Unavailable but is never retried, retrying can't get through the breaker.
+type breakerOpenError struct {
+	method string
+}
+
+func (e *breakerOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for %s", e.method)
+}
+
+// GRPCStatus makes status.Code and status.FromError see Unavailable
+func (e *breakerOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// circuitBreakerInterceptor fails fast with Unavailable while the breaker
+// for the call's target and method is open. It runs inside the retry
+// interceptor, so every attempt is checked and recorded.
+func circuitBreakerInterceptor(cfg CircuitBreakerConfig, m *metrics.Metrics) grpc.UnaryClientInterceptor {
+	var (
+		mu       sync.Mutex
+		breakers = make(map[string]*circuitBreaker)
+	)
+	get := func(target, method string) *circuitBreaker {
+		mu.Lock()
+		defer mu.Unlock()
+		key := target + method
+		b, ok := breakers[key]
+		if !ok {
+			b = newCircuitBreaker(cfg, func(state breakerState) {
+				logger.Warn("gRPC client circuit breaker state changed",
+					zap.String("target", target),
+					zap.String("method", method),
+					zap.String("state", state.String()),
+				)
+				if m != nil {
+					m.RecordCircuitBreakerState(target, method, state.String())
+				}
+			})
+			breakers[key] = b
+		}
+		return b
+	}
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		b := get(cc.Target(), method)
+		generation, ok := b.allow()
+		if !ok {
+			return &breakerOpenError{method: method}
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(generation, breakerOutcome(err))
+		return err
+	}
+}
@@ -0,0 +1,198 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	var states []breakerState
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureRate:      0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		OpenTimeout:      10 * time.Second,
+		HalfOpenRequests: 1,
+	}, func(state breakerState) { states = append(states, state) })
+	b.now = func() time.Time { return now }
+
+	for _, outcome := range []callOutcome{outcomeSuccess, outcomeFailure, outcomeNeutral, outcomeSuccess, outcomeFailure} {
+		generation, ok := b.allow()
+		if !ok {
+			t.Fatal("closed breaker must allow calls")
+		}
+		b.record(generation, outcome)
+	}
+	if b.state != breakerOpen {
+		t.Fatalf("state = %s, want open", b.state)
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("open breaker must reject calls")
+	}
+
+	now = now.Add(10 * time.Second)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatal("breaker must allow probe after open timeout")
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("breaker must allow only one concurrent probe")
+	}
+	b.record(probe, outcomeFailure)
+	if b.state != breakerOpen {
+		t.Fatalf("state = %s, want open after failed probe", b.state)
+	}
+
+	now = now.Add(10 * time.Second)
+	probe, ok = b.allow()
+	if !ok {
+		t.Fatal("breaker must allow probe after open timeout")
+	}
+	b.record(probe, outcomeSuccess)
+	if b.state != breakerClosed {
+		t.Fatalf("state = %s, want closed after successful probe", b.state)
+	}
+
+	want := []breakerState{breakerOpen, breakerHalfOpen, breakerOpen, breakerHalfOpen, breakerClosed}
+	if len(states) != len(want) {
+		t.Fatalf("transitions = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", states, want)
+		}
+	}
+}
+
+func TestCircuitBreaker_CanceledProbe(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureRate: 0.5,
+		MinRequests: 1,
+		Window:      time.Minute,
+		OpenTimeout: 10 * time.Second,
+	}, nil)
+	b.now = func() time.Time { return now }
+
+	generation, _ := b.allow()
+	b.record(generation, breakerOutcome(status.Error(codes.Unavailable, "down")))
+	if b.state != breakerOpen {
+		t.Fatalf("state = %s, want open", b.state)
+	}
+
+	now = now.Add(10 * time.Second)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatal("breaker must allow probe after open timeout")
+	}
+	// The caller hung up, the backend may still be down
+	b.record(probe, breakerOutcome(status.Error(codes.Canceled, "context canceled")))
+	if b.state != breakerHalfOpen {
+		t.Fatalf("state = %s, want half_open after canceled probe", b.state)
+	}
+
+	probe, ok = b.allow()
+	if !ok {
+		t.Fatal("canceled probe must free the probe slot")
+	}
+	b.record(probe, breakerOutcome(status.Error(codes.Unavailable, "down")))
+	if b.state != breakerOpen {
+		t.Fatalf("state = %s, want open after failed probe", b.state)
+	}
+}
+
+func TestCircuitBreaker_StaleResults(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureRate: 0.5,
+		MinRequests: 2,
+		Window:      time.Minute,
+		OpenTimeout: 10 * time.Second,
+	}, nil)
+	b.now = func() time.Time { return now }
+
+	slow, _ := b.allow()
+	for range 2 {
+		generation, _ := b.allow()
+		b.record(generation, outcomeFailure)
+	}
+	if b.state != breakerOpen {
+		t.Fatalf("state = %s, want open", b.state)
+	}
+
+	now = now.Add(10 * time.Second)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatal("breaker must allow probe after open timeout")
+	}
+
+	// A call allowed while closed finishing now must not decide for the probe
+	b.record(slow, outcomeSuccess)
+	if b.state != breakerHalfOpen {
+		t.Fatalf("state = %s, want half_open after stale result", b.state)
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("stale result must not free the probe slot")
+	}
+
+	b.record(probe, outcomeSuccess)
+	if b.state != breakerClosed {
+		t.Fatalf("state = %s, want closed after successful probe", b.state)
+	}
+}
+
+func TestCircuitBreaker_InsideRetry(t *testing.T) {
+	cc, err := grpc.NewClient("passthrough:///breaker", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	retry, err := newRetrier(ClientConfig{MaxRetries: 5, RetryWaitTime: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	breaker := circuitBreakerInterceptor(CircuitBreakerConfig{
+		FailureRate: 0.5,
+		MinRequests: 3,
+		Window:      time.Minute,
+		OpenTimeout: time.Minute,
+	}, nil)
+
+	attempts := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		return status.Error(codes.Unavailable, "down")
+	}
+	// Same order as NewClient: retry wraps the breaker
+	call := func() error {
+		return retry.unaryInterceptor()(context.Background(), "/svc/Method", nil, nil, cc,
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return breaker(ctx, method, req, reply, cc, invoker, opts...)
+			})
+	}
+
+	err = call()
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("call = %v, want Unavailable", err)
+	}
+	// Every attempt is recorded, the breaker opens after the third one
+	// and retries stop instead of spinning on the open breaker
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+	if _, ok := err.(*breakerOpenError); !ok {
+		t.Errorf("error = %T, want *breakerOpenError", err)
+	}
+
+	if err := call(); status.Code(err) != codes.Unavailable || attempts != 3 {
+		t.Errorf("call with open breaker = %v after %d attempts, want fail fast", err, attempts)
+	}
+}
@@ -2,31 +2,75 @@ package grpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // ClientConfig holds gRPC client configuration
 type ClientConfig struct {
-	Host              string        `yaml:"host"`
-	Port              int           `yaml:"port"`
-	Timeout           time.Duration `yaml:"timeout" env-default:"5s"`
-	MaxRetries        int           `yaml:"max_retries" env-default:"3"`
-	RetryWaitTime     time.Duration `yaml:"retry_wait_time" env-default:"100ms"`
-	MaxRecvMsgSize    int           `yaml:"max_recv_msg_size" env-default:"4194304"`
-	MaxSendMsgSize    int           `yaml:"max_send_msg_size" env-default:"4194304"`
-	KeepAliveTime     time.Duration `yaml:"keep_alive_time" env-default:"30s"`
-	KeepAliveTimeout  time.Duration `yaml:"keep_alive_timeout" env-default:"10s"`
-	InitialWindowSize int32         `yaml:"initial_window_size" env-default:"65536"`
-	InitialConnWindow int32         `yaml:"initial_conn_window" env-default:"65536"`
+	Host           string        `yaml:"host"`
+	Port           int           `yaml:"port"`
+	Timeout        time.Duration `yaml:"timeout" env-default:"5s"`
+	MaxRetries     int           `yaml:"max_retries" env-default:"3"`
+	RetryWaitTime  time.Duration `yaml:"retry_wait_time" env-default:"100ms"`
+	MaxRecvMsgSize int           `yaml:"max_recv_msg_size" env-default:"4194304"`
+	MaxSendMsgSize int           `yaml:"max_send_msg_size" env-default:"4194304"`
+	// KeepAliveTime must not be lower than the server's KeepAliveMinTime,
+	// otherwise the server closes connections with "too_many_pings"
+	KeepAliveTime    time.Duration `yaml:"keep_alive_time" env-default:"30s"`
+	KeepAliveTimeout time.Duration `yaml:"keep_alive_timeout" env-default:"10s"`
+	// Window sizes in bytes. Setting them disables grpc-go dynamic (BDP based)
+	// window estimation, so 0 (default) is usually the best choice
+	InitialWindowSize int32 `yaml:"initial_window_size" env-default:"0"`
+	InitialConnWindow int32 `yaml:"initial_conn_window" env-default:"0"`
+	// PropagateMetadata - forward request ID, user ID and authorization from the incoming request
+	PropagateMetadata bool `yaml:"propagate_metadata" env-default:"false"`
+
+	TLS ClientTLSConfig `yaml:"tls"`
+
+	// Scheme - resolver scheme, e.g. "dns" for headless Kubernetes services
+	// (resolves all replicas) or "passthrough"; empty uses grpc-go default (dns)
+	Scheme string `yaml:"scheme"`
+	// LoadBalancingPolicy - "round_robin" or "pick_first"; empty uses pick_first
+	LoadBalancingPolicy string `yaml:"load_balancing_policy"`
+	// ServiceConfig - default service config JSON, overrides LoadBalancingPolicy
+	ServiceConfig string `yaml:"service_config"`
+
+	// WaitForReady - calls wait for the connection to become ready instead of
+	// failing fast with Unavailable (bounded by the call deadline)
+	WaitForReady bool `yaml:"wait_for_ready" env-default:"false"`
+	// EagerConnect - NewClient connects immediately and blocks until the server
+	// is ready (bounded by ctx) instead of connecting on the first call
+	EagerConnect bool `yaml:"eager_connect" env-default:"false"`
+	// HealthService - service name checked by WaitUntilReady, empty checks the whole server
+	HealthService string `yaml:"health_service"`
+
+	// EnableTracing - create OpenTelemetry spans per call and propagate trace context in metadata
+	EnableTracing bool `yaml:"enable_tracing" env-default:"false"`
+	// Compression - "gzip" compresses requests, "none" (default) disables
+	Compression string `yaml:"compression" env-default:"none"`
+
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	Retry          RetryConfig          `yaml:"retry"`
+	Hedging        HedgingConfig        `yaml:"hedging"`
+	// Logging - payload redaction, truncation and debug sampling, same as the server's
+	Logging LoggingConfig `yaml:"logging"`
+
+	// Metrics - optional, records call counters and latency, circuit breaker state and deadlines
+	Metrics *metrics.Metrics `yaml:"-"`
 }
 
 // Addr returns client target address
@@ -34,14 +78,73 @@ func (c *ClientConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// Target returns dial target including resolver scheme (e.g. dns:///host:port)
+func (c *ClientConfig) Target() string {
+	if c.Scheme == "" {
+		return c.Addr()
+	}
+	return fmt.Sprintf("%s:///%s", c.Scheme, c.Addr())
+}
+
+// serviceConfig returns default service config JSON
+func (c *ClientConfig) serviceConfig() string {
+	if c.ServiceConfig != "" {
+		return c.ServiceConfig
+	}
+	if c.LoadBalancingPolicy != "" {
+		return fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, c.LoadBalancingPolicy)
+	}
+	return ""
+}
+
+// Minimum values accepted by grpc-go, lower values are silently ignored or raised
+const (
+	minKeepAliveTime = 10 * time.Second
+	minWindowSize    = 65535
+)
+
+// Validate checks keepalive and window settings
+func (c *ClientConfig) Validate() error {
+	if c.KeepAliveTime != 0 && c.KeepAliveTime < minKeepAliveTime {
+		return fmt.Errorf("keep_alive_time must be at least %s, got %s", minKeepAliveTime, c.KeepAliveTime)
+	}
+	if c.KeepAliveTimeout < 0 {
+		return fmt.Errorf("keep_alive_timeout must not be negative, got %s", c.KeepAliveTimeout)
+	}
+	if c.InitialWindowSize != 0 && c.InitialWindowSize < minWindowSize {
+		return fmt.Errorf("initial_window_size must be at least %d, got %d", minWindowSize, c.InitialWindowSize)
+	}
+	if c.InitialConnWindow != 0 && c.InitialConnWindow < minWindowSize {
+		return fmt.Errorf("initial_conn_window must be at least %d, got %d", minWindowSize, c.InitialConnWindow)
+	}
+	if err := validateCompression(c.Compression); err != nil {
+		return err
+	}
+	switch c.LoadBalancingPolicy {
+	case "", "round_robin", "pick_first":
+	default:
+		return fmt.Errorf("unsupported load_balancing_policy %q", c.LoadBalancingPolicy)
+	}
+	if c.ServiceConfig != "" && !json.Valid([]byte(c.ServiceConfig)) {
+		return fmt.Errorf("service_config is not valid JSON")
+	}
+	return nil
+}
+
 // Client wraps gRPC client connection
 type Client struct {
 	conn   *grpc.ClientConn
 	config ClientConfig
 }
 
-// NewClient creates a new gRPC client connection
+// NewClient creates a new gRPC client connection.
+// The connection is established lazily on the first call unless cfg.EagerConnect is set,
+// in which case NewClient waits until ready using ctx.
 func NewClient(ctx context.Context, cfg ClientConfig, opts ...grpc.DialOption) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid grpc client config: %w", err)
+	}
+
 	// Apply defaults if not set
 	maxRecvMsgSize := cfg.MaxRecvMsgSize
 	if maxRecvMsgSize == 0 {
@@ -60,42 +163,146 @@ func NewClient(ctx context.Context, cfg ClientConfig, opts ...grpc.DialOption) (
 		zap.Int("max_retries", cfg.MaxRetries),
 		zap.Duration("retry_wait_time", cfg.RetryWaitTime),
 		zap.Duration("timeout", cfg.Timeout),
-		zap.String("addr", cfg.Addr()),
+		zap.Duration("keep_alive_time", cfg.KeepAliveTime),
+		zap.Duration("keep_alive_timeout", cfg.KeepAliveTimeout),
+		zap.Int32("initial_window_size", cfg.InitialWindowSize),
+		zap.Int32("initial_conn_window", cfg.InitialConnWindow),
+		zap.Bool("tls", cfg.TLS.Enabled),
+		zap.String("compression", cfg.Compression),
+		zap.Bool("tracing", cfg.EnableTracing),
+		zap.String("load_balancing_policy", cfg.LoadBalancingPolicy),
+		zap.String("target", cfg.Target()),
 	)
 
+	creds, err := cfg.TLS.transportCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("tls credentials: %w", err)
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{clientLoggingInterceptor(cfg.Logging)}
+	if cfg.Metrics != nil {
+		interceptors = append(interceptors, cfg.Metrics.GRPCClientMetricsInterceptor())
+	}
+	interceptors = append(interceptors, deadlineInterceptor(cfg.Timeout, cfg.Metrics))
+	retry, err := newRetrier(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("retry config: %w", err)
+	}
+	interceptors = append(interceptors, retry.unaryInterceptor())
+	// Breaker runs inside retry so every attempt is checked and recorded
+	if cfg.CircuitBreaker.Enabled {
+		interceptors = append(interceptors, circuitBreakerInterceptor(cfg.CircuitBreaker, cfg.Metrics))
+	}
+	if len(cfg.Hedging.Methods) > 0 {
+		interceptors = append(interceptors, hedgingInterceptor(cfg.Hedging))
+	}
+	if cfg.PropagateMetadata {
+		interceptors = append([]grpc.UnaryClientInterceptor{MetadataPropagationInterceptor()}, interceptors...)
+	}
+
+	callOpts := []grpc.CallOption{
+		grpc.MaxCallRecvMsgSize(maxRecvMsgSize),
+		grpc.MaxCallSendMsgSize(maxSendMsgSize),
+	}
+	if cfg.Compression == CompressionGzip {
+		callOpts = append(callOpts, grpc.UseCompressor(CompressionGzip))
+	}
+	if cfg.WaitForReady {
+		callOpts = append(callOpts, grpc.WaitForReady(true))
+	}
+
 	defaultOpts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultCallOptions(
-			grpc.MaxCallRecvMsgSize(maxRecvMsgSize),
-			grpc.MaxCallSendMsgSize(maxSendMsgSize),
-		),
-		grpc.WithChainUnaryInterceptor(
-			clientLoggingInterceptor(),
-			retryInterceptor(cfg.MaxRetries, cfg.RetryWaitTime),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(callOpts...),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+		grpc.WithChainStreamInterceptor(
+			clientStreamLoggingInterceptor(),
+			retry.streamInterceptor(),
 		),
 	}
+	if cfg.EnableTracing {
+		defaultOpts = append(defaultOpts, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	}
+	if cfg.KeepAliveTime > 0 {
+		defaultOpts = append(defaultOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepAliveTime,
+			Timeout: cfg.KeepAliveTimeout,
+		}))
+	}
+	if sc := cfg.serviceConfig(); sc != "" {
+		defaultOpts = append(defaultOpts, grpc.WithDefaultServiceConfig(sc))
+	}
+	if cfg.InitialWindowSize > 0 {
+		defaultOpts = append(defaultOpts, grpc.WithInitialWindowSize(cfg.InitialWindowSize))
+	}
+	if cfg.InitialConnWindow > 0 {
+		defaultOpts = append(defaultOpts, grpc.WithInitialConnWindowSize(cfg.InitialConnWindow))
+	}
 
 	allOpts := append(defaultOpts, opts...)
 
-	conn, err := grpc.DialContext(ctx, cfg.Addr(), allOpts...)
+	conn, err := grpc.NewClient(cfg.Target(), allOpts...)
 	if err != nil {
-		logger.Error("failed to dial gRPC server",
+		logger.Error("failed to create gRPC client",
 			zap.String("addr", cfg.Addr()),
 			zap.Error(err),
 		)
-		return nil, fmt.Errorf("dial grpc: %w", err)
+		return nil, fmt.Errorf("create grpc client: %w", err)
 	}
 
-	logger.Info("gRPC client connected",
+	client := &Client{
+		conn:   conn,
+		config: cfg,
+	}
+
+	if cfg.EagerConnect {
+		if err := client.WaitUntilReady(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	logger.Info("gRPC client created",
 		zap.String("addr", cfg.Addr()),
+		zap.Bool("connected", cfg.EagerConnect),
 		zap.Int("applied_max_recv_msg_size", maxRecvMsgSize),
 		zap.Int("applied_max_send_msg_size", maxSendMsgSize),
 	)
 
-	return &Client{
-		conn:   conn,
-		config: cfg,
-	}, nil
+	return client, nil
+}
+
+// WaitUntilReady connects and blocks until the connection is ready and the server
+// reports SERVING via the standard health service, or ctx is done.
+// Servers without the health service are considered ready once connected.
+func (c *Client) WaitUntilReady(ctx context.Context) error {
+	c.conn.Connect()
+	for {
+		state := c.conn.GetState()
+		if state == connectivity.Ready {
+			break
+		}
+		if state == connectivity.Shutdown {
+			return fmt.Errorf("grpc client connection is closed")
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("wait for connection to %s: %w", c.config.Target(), ctx.Err())
+		}
+	}
+
+	resp, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: c.config.HealthService,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("health check %s: %w", c.config.Target(), err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health check %s: status %s", c.config.Target(), resp.GetStatus())
+	}
+	return nil
 }
 
 // Conn returns the underlying connection
@@ -116,7 +323,9 @@ func (c *Client) Close() error {
 
 // Client interceptors
 
-func clientLoggingInterceptor() grpc.UnaryClientInterceptor {
+func clientLoggingInterceptor(cfg LoggingConfig) grpc.UnaryClientInterceptor {
+	payload := newPayloadLogger(cfg)
+
 	return func(
 		ctx context.Context,
 		method string,
@@ -126,21 +335,25 @@ func clientLoggingInterceptor() grpc.UnaryClientInterceptor {
 		opts ...grpc.CallOption,
 	) error {
 		start := time.Now()
+		log := logger.WithContext(ctx)
+		debug := payload.debugEnabled(log)
 
-		// Log outgoing request details
-		logger.Debug("gRPC client call started",
-			zap.String("method", method),
-			zap.String("target", cc.Target()),
-			zap.Any("request", req),
-		)
-
-		// Extract metadata if present
-		md, ok := metadata.FromOutgoingContext(ctx)
-		if ok {
-			logger.Debug("gRPC client call metadata",
+		if debug {
+			// Log outgoing request details
+			log.Debug("gRPC client call started",
 				zap.String("method", method),
-				zap.Any("metadata", md),
+				zap.String("target", cc.Target()),
+				payload.field("request", req),
 			)
+
+			// Extract metadata if present
+			md, ok := metadata.FromOutgoingContext(ctx)
+			if ok {
+				log.Debug("gRPC client call metadata",
+					zap.String("method", method),
+					payload.metadataField(md),
+				)
+			}
 		}
 
 		err := invoker(ctx, method, req, reply, cc, opts...)
@@ -152,18 +365,20 @@ func clientLoggingInterceptor() grpc.UnaryClientInterceptor {
 		}
 
 		if code == codes.OK {
-			logger.Debug("gRPC client call completed",
-				zap.String("method", method),
-				zap.Duration("duration", duration),
-				zap.Any("response", reply),
-			)
+			if debug {
+				log.Debug("gRPC client call completed",
+					zap.String("method", method),
+					zap.Duration("duration", duration),
+					payload.field("response", reply),
+				)
+			}
 		} else {
-			logger.Warn("gRPC client call failed",
+			log.Warn("gRPC client call failed",
 				zap.String("method", method),
 				zap.Duration("duration", duration),
 				zap.String("code", code.String()),
 				zap.String("target", cc.Target()),
-				zap.Any("request", req),
+				payload.field("request", req),
 				zap.Error(err),
 			)
 		}
@@ -171,95 +386,3 @@ func clientLoggingInterceptor() grpc.UnaryClientInterceptor {
 		return err
 	}
 }
-
-func retryInterceptor(maxRetries int, waitTime time.Duration) grpc.UnaryClientInterceptor {
-	return func(
-		ctx context.Context,
-		method string,
-		req, reply any,
-		cc *grpc.ClientConn,
-		invoker grpc.UnaryInvoker,
-		opts ...grpc.CallOption,
-	) error {
-		var lastErr error
-
-		for i := 0; i <= maxRetries; i++ {
-			attempt := i + 1
-			logger.Debug("gRPC client call attempt",
-				zap.String("method", method),
-				zap.Int("attempt", attempt),
-				zap.Int("max_retries", maxRetries),
-			)
-
-			err := invoker(ctx, method, req, reply, cc, opts...)
-			if err == nil {
-				if attempt > 1 {
-					logger.Info("gRPC client call succeeded after retry",
-						zap.String("method", method),
-						zap.Int("attempt", attempt),
-					)
-				}
-				return nil
-			}
-
-			lastErr = err
-			code := status.Code(err)
-
-			logger.Debug("gRPC client call attempt failed",
-				zap.String("method", method),
-				zap.Int("attempt", attempt),
-				zap.String("code", code.String()),
-				zap.Bool("retryable", isRetryable(code)),
-				zap.Error(err),
-			)
-
-			// Only retry on specific codes
-			if !isRetryable(code) {
-				logger.Debug("error is not retryable, stopping retries",
-					zap.String("method", method),
-					zap.String("code", code.String()),
-				)
-				return err
-			}
-
-			if i < maxRetries {
-				waitDuration := waitTime * time.Duration(i+1)
-				logger.Debug("waiting before retry",
-					zap.String("method", method),
-					zap.Int("next_attempt", attempt+1),
-					zap.Duration("wait_time", waitDuration),
-				)
-				select {
-				case <-ctx.Done():
-					logger.Warn("context cancelled during retry wait",
-						zap.String("method", method),
-						zap.Error(ctx.Err()),
-					)
-					return ctx.Err()
-				case <-time.After(waitDuration):
-					logger.Debug("retrying gRPC call",
-						zap.String("method", method),
-						zap.Int("attempt", attempt+1),
-					)
-				}
-			}
-		}
-
-		logger.Warn("gRPC client call failed after all retries",
-			zap.String("method", method),
-			zap.Int("total_attempts", maxRetries+1),
-			zap.Error(lastErr),
-		)
-
-		return lastErr
-	}
-}
-
-func isRetryable(code codes.Code) bool {
-	switch code {
-	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.Internal:
-		return true
-	default:
-		return false
-	}
-}
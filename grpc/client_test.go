@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientLoggingInterceptor(t *testing.T) {
+	cc, err := grpc.NewClient("passthrough:///logging", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	interceptor := clientLoggingInterceptor(LoggingConfig{RedactFields: []string{"service"}, MaxPayloadSize: 1000, DebugSampleRate: 1})
+	req := &healthpb.HealthCheckRequest{Service: "secret-service"}
+	call := func(level zapcore.Level, callErr error) []observer.LoggedEntry {
+		core, logs := observer.New(level)
+		ctx := logger.ToContext(context.Background(), zap.New(core))
+		_ = interceptor(ctx, healthCheckMethod, req, &healthpb.HealthCheckResponse{}, cc,
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return callErr
+			})
+		return logs.All()
+	}
+
+	if entries := call(zapcore.InfoLevel, nil); len(entries) != 0 {
+		t.Errorf("successful call without debug logged %d entries", len(entries))
+	}
+
+	entries := call(zapcore.DebugLevel, nil)
+	if len(entries) != 2 {
+		t.Fatalf("debug entries = %d, want started and completed", len(entries))
+	}
+	for _, entry := range append(entries, call(zapcore.InfoLevel, status.Error(codes.Unavailable, "down"))...) {
+		for _, field := range entry.Context {
+			if field.Key == "request" && strings.Contains(logFieldString(field), "secret-service") {
+				t.Errorf("%q logged unredacted request: %v", entry.Message, field)
+			}
+		}
+	}
+}
+
+// logFieldString returns field value for assertions
+func logFieldString(field zap.Field) string {
+	return fmt.Sprintf("%s %s", field.String, field.Interface)
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Supported values of ServerConfig.Compression and ClientConfig.Compression
+const (
+	CompressionNone = "none"
+	CompressionGzip = gzip.Name
+)
+
+func validateCompression(compression string) error {
+	switch compression {
+	case "", CompressionNone, CompressionGzip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression %q", compression)
+	}
+}
+
+// setSendCompressor compresses responses with gzip if the client supports it
+func setSendCompressor(ctx context.Context) {
+	supported, err := grpc.ClientSupportedCompressors(ctx)
+	if err == nil && slices.Contains(supported, CompressionGzip) {
+		_ = grpc.SetSendCompressor(ctx, CompressionGzip)
+	}
+}
+
+func compressionUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		setSendCompressor(ctx)
+		return handler(ctx, req)
+	}
+}
+
+func compressionStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		setSendCompressor(ss.Context())
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// typeURLPrefix is the prefix of Any type URLs used by gRPC status details
+const typeURLPrefix = "type.googleapis.com/"
+
+// ConvertGRPCToConnectError converts gRPC status error to *connect.Error.
+// Status details (e.g. errdetails.BadRequest) are preserved as Connect error details.
+// Connect errors are returned as is, nil stays nil.
+func ConvertGRPCToConnectError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectErr
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.FromContextError(err)
+	}
+	return connectErrorFromStatus(st.Proto())
+}
+
+// ConvertConnectToGRPCError converts *connect.Error to gRPC status error.
+// Connect error details are preserved as status details.
+// gRPC status errors are returned as is, nil stays nil.
+func ConvertConnectToGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		return status.Error(codes.Unknown, err.Error())
+	}
+
+	st := &spb.Status{
+		Code:    int32(connectErr.Code()),
+		Message: connectErr.Message(),
+	}
+	for _, detail := range connectErr.Details() {
+		st.Details = append(st.Details, &anypb.Any{
+			TypeUrl: typeURLPrefix + detail.Type(),
+			Value:   detail.Bytes(),
+		})
+	}
+	return status.FromProto(st).Err()
+}
+
+// connectErrorFromStatus builds Connect error from gRPC status, preserving details
+func connectErrorFromStatus(st *spb.Status) *connect.Error {
+	connectErr := connect.NewWireError(connect.Code(st.GetCode()), errors.New(st.GetMessage()))
+	for _, d := range st.GetDetails() {
+		if detail, err := connect.NewErrorDetail(d); err == nil {
+			connectErr.AddDetail(detail)
+		}
+	}
+	return connectErr
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConnectErrorConversion(t *testing.T) {
+	badRequest := &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "phone", Description: "invalid phone"},
+		},
+	}
+	st, err := status.New(codes.InvalidArgument, "validation failed").WithDetails(badRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var connectErr *connect.Error
+	if !errors.As(ConvertGRPCToConnectError(st.Err()), &connectErr) {
+		t.Fatal("expected *connect.Error")
+	}
+	if connectErr.Code() != connect.CodeInvalidArgument || connectErr.Message() != "validation failed" {
+		t.Fatalf("unexpected connect error: %v", connectErr)
+	}
+	if len(connectErr.Details()) != 1 {
+		t.Fatalf("details = %d, want 1", len(connectErr.Details()))
+	}
+
+	back, ok := status.FromError(ConvertConnectToGRPCError(connectErr))
+	if !ok {
+		t.Fatal("expected gRPC status error")
+	}
+	if back.Code() != codes.InvalidArgument || back.Message() != "validation failed" {
+		t.Fatalf("unexpected status: %v", back)
+	}
+	details := back.Details()
+	if len(details) != 1 {
+		t.Fatalf("details = %d, want 1", len(details))
+	}
+	got, ok := details[0].(*errdetails.BadRequest)
+	if !ok || !proto.Equal(got, badRequest) {
+		t.Fatalf("details = %v, want %v", details[0], badRequest)
+	}
+}
+
+func TestConvertNil(t *testing.T) {
+	if ConvertGRPCToConnectError(nil) != nil || ConvertConnectToGRPCError(nil) != nil {
+		t.Fatal("nil must stay nil")
+	}
+}
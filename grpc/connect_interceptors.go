@@ -0,0 +1,190 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// connectInterceptor implements connect.Interceptor for handlers (server side).
+// Client calls pass through unchanged.
+type connectInterceptor struct {
+	unary  func(ctx context.Context, procedure string, call func(context.Context) error) error
+	stream func(ctx context.Context, procedure string, call func(context.Context) error) error
+}
+
+func (i *connectInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		ctx = withIncomingHeaders(ctx, req.Header())
+
+		var resp connect.AnyResponse
+		err := i.unary(ctx, req.Spec().Procedure, func(ctx context.Context) error {
+			var err error
+			resp, err = next(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+func (i *connectInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *connectInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx = withIncomingHeaders(ctx, conn.RequestHeader())
+		call := func(ctx context.Context) error {
+			return next(ctx, conn)
+		}
+		if i.stream != nil {
+			return i.stream(ctx, conn.Spec().Procedure, call)
+		}
+		return i.unary(ctx, conn.Spec().Procedure, call)
+	}
+}
+
+// withIncomingHeaders exposes Connect request headers as incoming gRPC metadata,
+// so GetMetadata, GetRequestID and GetUserID work in Connect handlers too
+func withIncomingHeaders(ctx context.Context, header http.Header) context.Context {
+	if _, ok := metadata.FromIncomingContext(ctx); ok {
+		return ctx
+	}
+	md := make(metadata.MD, len(header))
+	for k, v := range header {
+		md.Append(k, v...)
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// connectStatus returns gRPC code of Connect handler error
+func connectStatus(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	return codes.Code(connect.CodeOf(err))
+}
+
+// ConnectLoggingInterceptor logs Connect requests like the gRPC server logging interceptor.
+// Payloads are logged for unary calls only.
+func ConnectLoggingInterceptor(cfg LoggingConfig) connect.Interceptor {
+	payload := newPayloadLogger(cfg)
+
+	return connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if req.Spec().IsClient {
+				return next(ctx, req)
+			}
+			ctx = withIncomingHeaders(ctx, req.Header())
+
+			start := time.Now()
+			log := logger.WithContext(ctx)
+			debug := payload.debugEnabled(log)
+			procedure := req.Spec().Procedure
+
+			if debug {
+				log.Debug("Connect request received",
+					zap.String("method", procedure),
+					payload.field("request", req.Any()),
+				)
+				md, _ := metadata.FromIncomingContext(ctx)
+				log.Debug("Connect request metadata",
+					zap.String("method", procedure),
+					payload.metadataField(md),
+				)
+			}
+
+			resp, err := next(ctx, req)
+
+			duration := time.Since(start)
+			if err == nil {
+				if debug {
+					log.Debug("Connect request completed",
+						zap.String("method", procedure),
+						zap.Duration("duration", duration),
+						payload.field("response", resp.Any()),
+					)
+				}
+			} else {
+				log.Warn("Connect request failed",
+					zap.String("method", procedure),
+					zap.Duration("duration", duration),
+					zap.String("code", connectStatus(err).String()),
+					payload.field("request", req.Any()),
+					zap.Error(err),
+				)
+			}
+
+			return resp, err
+		}
+	})
+}
+
+// ConnectRecoveryInterceptor recovers handler panics like the gRPC recovery interceptor
+func ConnectRecoveryInterceptor(cfg RecoveryConfig, onPanic PanicHandler) connect.Interceptor {
+	return &connectInterceptor{
+		unary: func(ctx context.Context, procedure string, call func(context.Context) error) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = ConvertGRPCToConnectError(handlePanic(ctx, cfg, onPanic, procedure, r))
+				}
+			}()
+			return call(ctx)
+		},
+	}
+}
+
+// ConnectAuthInterceptor authenticates Connect requests with JWTValidator like AuthInterceptor
+func ConnectAuthInterceptor(validator JWTValidator, cfg AuthInterceptorConfig) connect.Interceptor {
+	auth := newAuthenticator(validator, cfg)
+
+	return &connectInterceptor{
+		unary: func(ctx context.Context, procedure string, call func(context.Context) error) error {
+			ctx, err := auth.authenticate(ctx, procedure)
+			if err != nil {
+				return ConvertGRPCToConnectError(err)
+			}
+			return call(ctx)
+		},
+	}
+}
+
+// ConnectTimeoutInterceptor limits handler execution time like the gRPC timeout interceptor.
+// Streams are not limited.
+func ConnectTimeoutInterceptor(timeout time.Duration) connect.Interceptor {
+	return &connectInterceptor{
+		unary: func(ctx context.Context, _ string, call func(context.Context) error) error {
+			if timeout <= 0 {
+				return call(ctx)
+			}
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return call(ctx)
+		},
+		stream: func(ctx context.Context, _ string, call func(context.Context) error) error {
+			return call(ctx)
+		},
+	}
+}
+
+// ConnectMetricsInterceptor records the same request metrics as metrics.GRPCMetricsInterceptor
+func ConnectMetricsInterceptor(m *metrics.Metrics) connect.Interceptor {
+	return &connectInterceptor{
+		unary: func(ctx context.Context, procedure string, call func(context.Context) error) error {
+			start := time.Now()
+			err := call(ctx)
+			m.RecordGRPCRequest(procedure, connectStatus(err).String(), time.Since(start))
+			return err
+		},
+	}
+}
@@ -0,0 +1,189 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// connectHealthCheck serves handler as Connect Health/Check with interceptors
+// and returns a client for it
+func connectHealthCheck(
+	t *testing.T,
+	handler func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error),
+	interceptors ...connect.Interceptor,
+) *connect.Client[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse] {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle(healthCheckMethod, connect.NewUnaryHandler(healthCheckMethod,
+		func(ctx context.Context, req *connect.Request[healthpb.HealthCheckRequest]) (*connect.Response[healthpb.HealthCheckResponse], error) {
+			resp, err := handler(ctx, req.Msg)
+			if err != nil {
+				return nil, err
+			}
+			return connect.NewResponse(resp), nil
+		},
+		connect.WithInterceptors(interceptors...),
+	))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return connect.NewClient[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse](server.Client(), server.URL+healthCheckMethod)
+}
+
+func TestConnectRecoveryInterceptor(t *testing.T) {
+	panics := make(chan PanicInfo, 1)
+	client := connectHealthCheck(t,
+		func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+			panic("boom")
+		},
+		ConnectRecoveryInterceptor(RecoveryConfig{IncludeErrorID: true}, func(ctx context.Context, info PanicInfo) {
+			panics <- info
+		}),
+	)
+
+	req := connect.NewRequest(&healthpb.HealthCheckRequest{})
+	req.Header().Set(RequestIDHeader, "req-1")
+	_, err := client.CallUnary(context.Background(), req)
+	if connect.CodeOf(err) != connect.CodeInternal || !strings.Contains(err.Error(), "req-1") {
+		t.Fatalf("error = %v, want internal with error ID", err)
+	}
+	if info := <-panics; info.Method != healthCheckMethod || info.Value != "boom" || info.ErrorID != "req-1" {
+		t.Errorf("PanicInfo = %+v", info)
+	}
+}
+
+func TestConnectAuthInterceptor(t *testing.T) {
+	jwtValidator := &countingJWTValidator{}
+	client := connectHealthCheck(t,
+		func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+			info, ok := GetAuthInfo(ctx)
+			if !ok || info.UserID != 42 {
+				return nil, errors.New("handler called without auth info")
+			}
+			return &healthpb.HealthCheckResponse{}, nil
+		},
+		ConnectAuthInterceptor(jwtValidator, AuthInterceptorConfig{}),
+	)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          connect.Code
+	}{
+		{"valid token", "Bearer valid", 0},
+		{"invalid token", "Bearer invalid", connect.CodeUnauthenticated},
+		{"missing token", "", connect.CodeUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := connect.NewRequest(&healthpb.HealthCheckRequest{})
+			if tt.authorization != "" {
+				req.Header().Set("Authorization", tt.authorization)
+			}
+			_, err := client.CallUnary(context.Background(), req)
+			if tt.want == 0 && err != nil || tt.want != 0 && connect.CodeOf(err) != tt.want {
+				t.Errorf("error = %v, want code %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectTimeoutInterceptor(t *testing.T) {
+	client := connectHealthCheck(t,
+		func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok || time.Until(deadline) > time.Minute {
+				return nil, errors.New("handler has no deadline")
+			}
+			return &healthpb.HealthCheckResponse{}, nil
+		},
+		ConnectTimeoutInterceptor(time.Minute),
+	)
+
+	if _, err := client.CallUnary(context.Background(), connect.NewRequest(&healthpb.HealthCheckRequest{})); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConnectMetricsInterceptor(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := metrics.NewWithConfig("orders", metrics.Config{Registry: registry, SkipRuntimeCollectors: true})
+	client := connectHealthCheck(t,
+		func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+			if req.Service == "missing" {
+				return nil, connect.NewError(connect.CodeNotFound, errors.New("not found"))
+			}
+			return &healthpb.HealthCheckResponse{}, nil
+		},
+		ConnectMetricsInterceptor(m),
+	)
+
+	for _, service := range []string{"", "missing"} {
+		_, _ = client.CallUnary(context.Background(), connect.NewRequest(&healthpb.HealthCheckRequest{Service: service}))
+	}
+
+	expected := `
+# HELP grpc_requests_total Total number of gRPC requests
+# TYPE grpc_requests_total counter
+grpc_requests_total{method="/grpc.health.v1.Health/Check",service="orders",status="NotFound"} 1
+grpc_requests_total{method="/grpc.health.v1.Health/Check",service="orders",status="OK"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "grpc_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnectLoggingInterceptor(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	client := connectHealthCheck(t,
+		func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+			// Request headers are visible as incoming metadata
+			if GetRequestID(ctx) != "req-1" {
+				return nil, errors.New("request ID not found in metadata")
+			}
+			if req.Service == "missing" {
+				return nil, connect.NewError(connect.CodeNotFound, errors.New("not found"))
+			}
+			return &healthpb.HealthCheckResponse{}, nil
+		},
+		connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+			return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+				return next(logger.ToContext(ctx, zap.New(core)), req)
+			}
+		}),
+		ConnectLoggingInterceptor(LoggingConfig{RedactFields: []string{"service"}}),
+	)
+
+	for _, service := range []string{"", "missing"} {
+		req := connect.NewRequest(&healthpb.HealthCheckRequest{Service: service})
+		req.Header().Set(RequestIDHeader, "req-1")
+		_, _ = client.CallUnary(context.Background(), req)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].Message != "Connect request failed" {
+		t.Fatalf("entries = %v, want only the failed request", entries)
+	}
+	fields := entries[0].ContextMap()
+	if fields["code"] != "NotFound" || fields["method"] != healthCheckMethod {
+		t.Errorf("fields = %v", fields)
+	}
+	if request := fmt.Sprint(fields["request"]); fields["request"] == nil || strings.Contains(request, "missing") {
+		t.Errorf("request = %s, want redacted request", request)
+	}
+}
@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// deadlineInterceptor applies default timeout to calls without a deadline
+// and records calls that exceeded their deadline
+func deadlineInterceptor(timeout time.Duration, m *metrics.Metrics) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) == codes.DeadlineExceeded || ctx.Err() == context.DeadlineExceeded {
+			logger.WithContext(ctx).Warn("gRPC client call deadline exceeded",
+				zap.String("method", method),
+				zap.String("target", cc.Target()),
+			)
+			if m != nil {
+				m.RecordDeadlineExceeded(cc.Target(), method)
+			}
+		}
+		return err
+	}
+}
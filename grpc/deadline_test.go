@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeadlineInterceptor(t *testing.T) {
+	cc, err := grpc.NewClient("passthrough:///deadline", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	registry := prometheus.NewRegistry()
+	m := metrics.NewWithConfig("orders", metrics.Config{Registry: registry, SkipRuntimeCollectors: true})
+	interceptor := deadlineInterceptor(time.Minute, m)
+
+	tests := []struct {
+		name          string
+		callerTimeout time.Duration // 0 - no caller deadline
+		wantTimeout   time.Duration
+		invokeErr     error
+	}{
+		{"default timeout applied", 0, time.Minute, nil},
+		{"shorter caller deadline kept", time.Second, time.Second, nil},
+		{"longer caller deadline kept", time.Hour, time.Hour, nil},
+		{"deadline exceeded", time.Second, time.Second, status.Error(codes.DeadlineExceeded, "deadline exceeded")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.callerTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.callerTimeout)
+				defer cancel()
+			}
+
+			err := interceptor(ctx, healthCheckMethod, nil, nil, cc,
+				func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+					deadline, ok := ctx.Deadline()
+					if remaining := time.Until(deadline); !ok || remaining > tt.wantTimeout || remaining < tt.wantTimeout-time.Second {
+						t.Errorf("deadline in %v, want %v", remaining, tt.wantTimeout)
+					}
+					return tt.invokeErr
+				})
+			if err != tt.invokeErr {
+				t.Errorf("error = %v, want %v", err, tt.invokeErr)
+			}
+		})
+	}
+
+	expected := `
+# HELP grpc_client_deadline_exceeded_total Total number of gRPC client calls that exceeded their deadline
+# TYPE grpc_client_deadline_exceeded_total counter
+grpc_client_deadline_exceeded_total{method="/grpc.health.v1.Health/Check",service="orders",target="passthrough:///deadline"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "grpc_client_deadline_exceeded_total"); err != nil {
+		t.Error(err)
+	}
+}
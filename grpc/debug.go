@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/stats"
+)
+
+// DebugConfig holds debug mode configuration.
+// When enabled, the channelz service is registered on the gRPC server and
+// an admin HTTP endpoint is served on Addr.
+type DebugConfig struct {
+	Enabled bool   `yaml:"enabled" env:"GRPC_DEBUG_ENABLED" env-default:"false"`
+	Addr    string `yaml:"addr" env:"GRPC_DEBUG_ADDR" env-default:"127.0.0.1:6061"`
+}
+
+// DebugInfo is the snapshot returned by the admin endpoint
+type DebugInfo struct {
+	Connections int64            `json:"connections"`
+	InFlight    map[string]int64 `json:"in_flight"`
+	Config      ServerConfig     `json:"config"`
+}
+
+// debugStats tracks open connections and in-flight requests per method
+type debugStats struct {
+	connections atomic.Int64
+
+	mu       sync.Mutex
+	inFlight map[string]int64
+}
+
+type debugMethodKey struct{}
+
+func newDebugStats() *debugStats {
+	return &debugStats{inFlight: make(map[string]int64)}
+}
+
+// TagRPC stores method name for HandleRPC
+func (d *debugStats) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, debugMethodKey{}, info.FullMethodName)
+}
+
+// HandleRPC counts in-flight requests
+func (d *debugStats) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	method, _ := ctx.Value(debugMethodKey{}).(string)
+	switch s.(type) {
+	case *stats.Begin:
+		d.mu.Lock()
+		d.inFlight[method]++
+		d.mu.Unlock()
+	case *stats.End:
+		d.mu.Lock()
+		if d.inFlight[method]--; d.inFlight[method] <= 0 {
+			delete(d.inFlight, method)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// TagConn implements stats.Handler
+func (d *debugStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn counts open connections
+func (d *debugStats) HandleConn(_ context.Context, s stats.ConnStats) {
+	switch s.(type) {
+	case *stats.ConnBegin:
+		d.connections.Add(1)
+	case *stats.ConnEnd:
+		d.connections.Add(-1)
+	}
+}
+
+func (d *debugStats) snapshot(cfg ServerConfig) DebugInfo {
+	d.mu.Lock()
+	inFlight := make(map[string]int64, len(d.inFlight))
+	for method, n := range d.inFlight {
+		inFlight[method] = n
+	}
+	d.mu.Unlock()
+
+	return DebugInfo{
+		Connections: d.connections.Load(),
+		InFlight:    inFlight,
+		Config:      cfg,
+	}
+}
+
+// DebugInfo returns current connection counts, in-flight requests and applied config.
+// Counters are only collected when debug mode is enabled.
+func (s *Server) DebugInfo() DebugInfo {
+	if s.debugStats == nil {
+		return DebugInfo{Config: s.config}
+	}
+	return s.debugStats.snapshot(s.config)
+}
+
+// DebugHandler returns admin HTTP handler serving DebugInfo as JSON on /debug/grpc
+func (s *Server) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/grpc", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.DebugInfo()); err != nil {
+			logger.Warn("failed to write debug info", zap.Error(err))
+		}
+	})
+	return mux
+}
+
+// startDebugServer serves admin endpoint in background, failures do not stop the gRPC server
+func (s *Server) startDebugServer() {
+	s.debugServer = &http.Server{
+		Addr:              s.config.Debug.Addr,
+		Handler:           s.DebugHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logger.Info("gRPC debug server starting", zap.String("addr", s.config.Debug.Addr))
+	go func() {
+		if err := s.debugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("gRPC debug server failed", zap.Error(err))
+		}
+	}()
+}
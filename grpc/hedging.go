@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// HedgingConfig holds client hedging configuration.
+// Hedging is enabled for the listed methods only; they must be idempotent.
+type HedgingConfig struct {
+	// Methods - idempotent methods to hedge (e.g., "/user.UserService/GetUser")
+	Methods []string `yaml:"methods"`
+	// Delay - time to wait for a response before sending the next attempt
+	Delay time.Duration `yaml:"delay" env-default:"50ms"`
+	// MaxAttempts - total attempts including the original one
+	MaxAttempts int `yaml:"max_attempts" env-default:"2"`
+}
+
+// hedgeAttempt is the result of a single hedged attempt
+type hedgeAttempt struct {
+	attempt int
+	reply   proto.Message
+	err     error
+	// copyBack writes attempt headers, trailers and peer to the caller's options
+	copyBack func()
+}
+
+// hedgeCallOptions replaces Header, Trailer and Peer options with copies
+// private to one attempt, so concurrent attempts don't write the caller's
+// values. The returned func copies them to the caller's destinations.
+func hedgeCallOptions(opts []grpc.CallOption) ([]grpc.CallOption, func()) {
+	out := make([]grpc.CallOption, 0, len(opts))
+	var copies []func()
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			md := new(metadata.MD)
+			out = append(out, grpc.Header(md))
+			copies = append(copies, func() { *o.HeaderAddr = *md })
+		case grpc.TrailerCallOption:
+			md := new(metadata.MD)
+			out = append(out, grpc.Trailer(md))
+			copies = append(copies, func() { *o.TrailerAddr = *md })
+		case grpc.PeerCallOption:
+			p := new(peer.Peer)
+			out = append(out, grpc.Peer(p))
+			copies = append(copies, func() { *o.PeerAddr = *p })
+		default:
+			out = append(out, opt)
+		}
+	}
+	return out, func() {
+		for _, copyBack := range copies {
+			copyBack()
+		}
+	}
+}
+
+// hedgingInterceptor sends additional attempts of slow calls after Delay and
+// returns the first successful response. Failed attempts with retryable codes
+// trigger the next attempt immediately, other errors are returned as is.
+// Header, Trailer and Peer options receive values of the returned attempt.
+func hedgingInterceptor(cfg HedgingConfig) grpc.UnaryClientInterceptor {
+	// Apply defaults if not set
+	delay := cfg.Delay
+	if delay == 0 {
+		delay = 50 * time.Millisecond
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts < 2 {
+		maxAttempts = 2
+	}
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, method := range cfg.Methods {
+		methods[method] = true
+	}
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		replyMsg, ok := reply.(proto.Message)
+		if !methods[method] || !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		// Cancels attempts still in flight once a result is taken
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan hedgeAttempt, maxAttempts)
+		send := func(attempt int) {
+			out := replyMsg.ProtoReflect().New().Interface()
+			attemptOpts, copyBack := hedgeCallOptions(opts)
+			go func() {
+				err := invoker(ctx, method, req, out, cc, attemptOpts...)
+				results <- hedgeAttempt{attempt: attempt, reply: out, err: err, copyBack: copyBack}
+			}()
+		}
+
+		sent, pending := 1, 1
+		send(sent)
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		var last hedgeAttempt
+		for pending > 0 {
+			select {
+			case <-timer.C:
+				if sent < maxAttempts {
+					sent++
+					pending++
+					logger.Debug("gRPC client sending hedged attempt",
+						zap.String("method", method),
+						zap.Int("attempt", sent),
+					)
+					send(sent)
+					timer.Reset(delay)
+				}
+			case res := <-results:
+				pending--
+				if res.err == nil {
+					if res.attempt > 1 {
+						logger.Debug("gRPC client hedged attempt won",
+							zap.String("method", method),
+							zap.Int("attempt", res.attempt),
+						)
+					}
+					proto.Reset(replyMsg)
+					proto.Merge(replyMsg, res.reply)
+					res.copyBack()
+					return nil
+				}
+				last = res
+				if !isHedgeable(res.err) {
+					res.copyBack()
+					return res.err
+				}
+				if sent < maxAttempts {
+					sent++
+					pending++
+					send(sent)
+					timer.Reset(delay)
+				}
+			}
+		}
+
+		last.copyBack()
+		return last.err
+	}
+}
+
+// isHedgeable reports whether a failed attempt allows trying another replica
+func isHedgeable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
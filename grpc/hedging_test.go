@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// Run with -race: attempts write headers and trailers concurrently
+func TestHedgingInterceptor_CallOptions(t *testing.T) {
+	hedging := hedgingInterceptor(HedgingConfig{
+		Methods:     []string{healthCheckMethod},
+		Delay:       5 * time.Millisecond,
+		MaxAttempts: 2,
+	})
+
+	var attempts atomic.Int32
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempt := strconv.Itoa(int(attempts.Add(1)))
+		for _, opt := range opts {
+			switch o := opt.(type) {
+			case grpc.HeaderCallOption:
+				*o.HeaderAddr = metadata.Pairs("attempt", attempt)
+			case grpc.TrailerCallOption:
+				*o.TrailerAddr = metadata.Pairs("attempt", attempt)
+			}
+		}
+		if attempt == "1" {
+			// The slow attempt writes again after the hedged one won
+			<-ctx.Done()
+			for _, opt := range opts {
+				if o, ok := opt.(grpc.TrailerCallOption); ok {
+					*o.TrailerAddr = metadata.Pairs("attempt", attempt)
+				}
+			}
+			return ctx.Err()
+		}
+		reply.(*healthpb.HealthCheckResponse).Status = healthpb.HealthCheckResponse_SERVING
+		return nil
+	}
+
+	var header, trailer metadata.MD
+	reply := &healthpb.HealthCheckResponse{}
+	err := hedging(context.Background(), healthCheckMethod, &healthpb.HealthCheckRequest{}, reply, nil, invoker,
+		grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("reply = %v, want the hedged attempt response", reply)
+	}
+	for name, md := range map[string]metadata.MD{"header": header, "trailer": trailer} {
+		if got := md.Get("attempt"); len(got) != 1 || got[0] != "2" {
+			t.Errorf("%s = %v, want values of the winning attempt", name, md)
+		}
+	}
+}
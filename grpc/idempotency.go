@@ -0,0 +1,286 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	// IdempotencyKeyHeader is the metadata key carrying the idempotency key
+	IdempotencyKeyHeader = "x-idempotency-key"
+	// IdempotentReplayHeader is set on responses served from the idempotency store
+	IdempotentReplayHeader = "x-idempotent-replay"
+)
+
+// IdempotencyStore persists responses of requests carrying an idempotency key
+type IdempotencyStore interface {
+	// Get returns stored response; found is false if key is unknown
+	Get(ctx context.Context, key string) (data []byte, found bool, err error)
+	// Reserve marks key as in progress; returns false if it is already reserved
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Save stores response and clears the reservation
+	Save(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	// Release clears the reservation without storing a response
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyConfig holds idempotency interceptor configuration
+type IdempotencyConfig struct {
+	// TTL - how long responses are replayed for retries (default 24h)
+	TTL time.Duration
+	// LockTTL - how long a request in progress blocks duplicates (default 30s)
+	LockTTL time.Duration
+	// Methods - methods to protect (e.g., "/payments.PaymentService/Charge"); empty means all
+	Methods []string
+}
+
+// IdempotencyInterceptor replays stored responses for requests carrying
+// x-idempotency-key. Only successful responses are stored, so failed requests
+// can be retried. A request hash is stored with the response, reusing a key
+// with a different request fails with InvalidArgument.
+// Store failures are logged and the request is processed normally.
+//
+// Keys are scoped by method and authenticated user, which is read from
+// AuthInfo, so AuthInterceptor must run before this interceptor. Otherwise
+// all callers share one key space and may replay each other's responses.
+func IdempotencyInterceptor(store IdempotencyStore, cfg IdempotencyConfig) grpc.UnaryServerInterceptor {
+	ttl := cfg.TTL
+	if ttl == 0 {
+		ttl = 24 * time.Hour
+	}
+	lockTTL := cfg.LockTTL
+	if lockTTL == 0 {
+		lockTTL = 30 * time.Second
+	}
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, method := range cfg.Methods {
+		methods[method] = true
+	}
+
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if len(methods) > 0 && !methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		idempotencyKey := GetMetadata(ctx, IdempotencyKeyHeader)
+		reqMsg, ok := req.(proto.Message)
+		if idempotencyKey == "" || !ok {
+			return handler(ctx, req)
+		}
+
+		log := logger.WithContext(ctx).With(
+			zap.String("method", info.FullMethod),
+			zap.String("idempotency_key", idempotencyKey),
+		)
+		key := idempotencyStoreKey(ctx, info.FullMethod, idempotencyKey)
+		reqHash, err := requestHash(reqMsg)
+		if err != nil {
+			log.Warn("failed to hash idempotent request", zap.Error(err))
+			return handler(ctx, req)
+		}
+
+		// replay returns stored response; done is false if there is none
+		// and the request must be processed
+		replay := func() (resp any, done bool, err error) {
+			data, found, err := store.Get(ctx, key)
+			if err != nil {
+				log.Warn("idempotency store get failed", zap.Error(err))
+				return nil, false, nil
+			}
+			if !found {
+				return nil, false, nil
+			}
+			resp, err = decodeResponse(info.FullMethod, reqHash, data)
+			if errors.Is(err, errRequestMismatch) {
+				return nil, true, status.Error(codes.InvalidArgument, "idempotency key was already used with a different request")
+			}
+			if err != nil {
+				log.Warn("failed to decode stored response", zap.Error(err))
+				return nil, false, nil
+			}
+			log.Debug("replaying idempotent response")
+			_ = grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayHeader, "true"))
+			return resp, true, nil
+		}
+
+		if resp, done, err := replay(); done {
+			return resp, err
+		}
+
+		reserved, err := store.Reserve(ctx, key, lockTTL)
+		if err != nil {
+			log.Warn("idempotency store reserve failed", zap.Error(err))
+			return handler(ctx, req)
+		}
+		if !reserved {
+			return nil, status.Error(codes.Aborted, "request with the same idempotency key is in progress")
+		}
+
+		// Use a detached context so the store is updated even if the client went away
+		storeCtx := context.WithoutCancel(ctx)
+
+		// A request holding the reservation may have saved its response and
+		// released the lock between Get and Reserve, check again so the
+		// handler doesn't run twice
+		if resp, done, err := replay(); done {
+			if relErr := store.Release(storeCtx, key); relErr != nil {
+				log.Warn("idempotency store release failed", zap.Error(relErr))
+			}
+			return resp, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if relErr := store.Release(storeCtx, key); relErr != nil {
+				log.Warn("idempotency store release failed", zap.Error(relErr))
+			}
+			return resp, err
+		}
+
+		msg, ok := resp.(proto.Message)
+		if !ok {
+			_ = store.Release(storeCtx, key)
+			return resp, nil
+		}
+		encoded, marshalErr := encodeResponse(reqHash, msg)
+		if marshalErr == nil {
+			marshalErr = store.Save(storeCtx, key, encoded, ttl)
+		}
+		if marshalErr != nil {
+			log.Warn("failed to store idempotent response", zap.Error(marshalErr))
+			_ = store.Release(storeCtx, key)
+		}
+
+		return resp, nil
+	}
+}
+
+// idempotencyStoreKey scopes idempotency key by method and user
+func idempotencyStoreKey(ctx context.Context, method, key string) string {
+	var userID int64
+	if info, ok := GetAuthInfo(ctx); ok {
+		userID = info.UserID
+	}
+	return fmt.Sprintf("%s:%d:%s", method, userID, key)
+}
+
+// storedResponseVersion prefixes stored responses: version byte, SHA-256 of
+// the request, response
+const storedResponseVersion = 0x01
+
+var errRequestMismatch = errors.New("request doesn't match stored request")
+
+// requestHash returns SHA-256 of deterministically marshalled request
+func requestHash(req proto.Message) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// encodeResponse marshals response together with request hash
+func encodeResponse(reqHash []byte, resp proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+	encoded := make([]byte, 0, 1+len(reqHash)+len(data))
+	encoded = append(encoded, storedResponseVersion)
+	encoded = append(encoded, reqHash...)
+	return append(encoded, data...), nil
+}
+
+// decodeResponse checks request hash and unmarshals stored response into
+// the method's output type
+func decodeResponse(fullMethod string, reqHash, data []byte) (any, error) {
+	if len(data) < 1+sha256.Size || data[0] != storedResponseVersion {
+		return nil, errors.New("unknown stored response format")
+	}
+	if !bytes.Equal(data[1:1+sha256.Size], reqHash) {
+		return nil, errRequestMismatch
+	}
+	data = data[1+sha256.Size:]
+
+	md, err := findMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, fmt.Errorf("find response type: %w", err)
+	}
+	msg := mt.New().Interface()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	return msg, nil
+}
+
+// RedisIdempotencyStore implements IdempotencyStore on Redis
+type RedisIdempotencyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisIdempotencyStore creates Redis-backed idempotency store
+func NewRedisIdempotencyStore(client *redis.Client, prefix string) *RedisIdempotencyStore {
+	if prefix == "" {
+		prefix = "idempotency:"
+	}
+	return &RedisIdempotencyStore{client: client, prefix: prefix}
+}
+
+// Get returns stored response
+func (s *RedisIdempotencyStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if redis.IsNil(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Reserve marks key as in progress
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.lockKey(key), 1, ttl).Result()
+}
+
+// Save stores response and clears the reservation
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, s.prefix+key, data, ttl)
+	pipe.Del(ctx, s.lockKey(key))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Release clears the reservation
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.lockKey(key)).Err()
+}
+
+func (s *RedisIdempotencyStore) lockKey(key string) string {
+	return s.prefix + key + ":lock"
+}
@@ -0,0 +1,232 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const healthCheckMethod = "/grpc.health.v1.Health/Check"
+
+func newTestIdempotencyStore(t *testing.T) (*miniredis.Miniredis, *RedisIdempotencyStore) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := &redis.Client{Client: goredis.NewClient(&goredis.Options{Addr: srv.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	return srv, NewRedisIdempotencyStore(client, "")
+}
+
+// idempotentCall runs interceptor with the idempotency key in incoming metadata
+func idempotentCall(ctx context.Context, interceptor grpc.UnaryServerInterceptor, key string, req *healthpb.HealthCheckRequest, handler grpc.UnaryHandler) (any, error) {
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(IdempotencyKeyHeader, key))
+	return interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: healthCheckMethod}, handler)
+}
+
+func TestRedisIdempotencyStore(t *testing.T) {
+	srv, store := newTestIdempotencyStore(t)
+	ctx := context.Background()
+
+	reserved, err := store.Reserve(ctx, "k", time.Minute)
+	if err != nil || !reserved {
+		t.Fatalf("Reserve = %v, %v; want true", reserved, err)
+	}
+	if reserved, _ := store.Reserve(ctx, "k", time.Minute); reserved {
+		t.Fatal("second Reserve must fail while the key is reserved")
+	}
+
+	if err := store.Release(ctx, "k"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if reserved, _ := store.Reserve(ctx, "k", time.Minute); !reserved {
+		t.Fatal("Reserve must succeed after Release")
+	}
+
+	if err := store.Save(ctx, "k", []byte("resp"), time.Hour); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if srv.Exists(store.lockKey("k")) {
+		t.Error("Save must clear the reservation")
+	}
+	data, found, err := store.Get(ctx, "k")
+	if err != nil || !found || string(data) != "resp" {
+		t.Fatalf("Get = %q, %v, %v; want resp", data, found, err)
+	}
+	if ttl := srv.TTL("idempotency:k"); ttl != time.Hour {
+		t.Errorf("response TTL = %v, want 1h", ttl)
+	}
+
+	if _, found, _ := store.Get(ctx, "unknown"); found {
+		t.Error("unknown key must not be found")
+	}
+}
+
+func TestIdempotencyInterceptor_Replay(t *testing.T) {
+	_, store := newTestIdempotencyStore(t)
+	interceptor := IdempotencyInterceptor(store, IdempotencyConfig{})
+
+	var calls atomic.Int32
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls.Add(1)
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+	}
+	req := &healthpb.HealthCheckRequest{Service: "orders"}
+
+	first, err := idempotentCall(context.Background(), interceptor, "k1", req, handler)
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	second, err := idempotentCall(context.Background(), interceptor, "k1", req, handler)
+	if err != nil {
+		t.Fatalf("replayed call: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler calls = %d, want 1", calls.Load())
+	}
+	if !proto.Equal(first.(proto.Message), second.(proto.Message)) {
+		t.Errorf("replayed response = %v, want %v", second, first)
+	}
+
+	// Other users don't see the stored response
+	ctx := context.WithValue(context.Background(), authContextKey{}, &AuthInfo{UserID: 42})
+	if _, err := idempotentCall(ctx, interceptor, "k1", req, handler); err != nil {
+		t.Fatalf("other user call: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestIdempotencyInterceptor_RequestMismatch(t *testing.T) {
+	_, store := newTestIdempotencyStore(t)
+	interceptor := IdempotencyInterceptor(store, IdempotencyConfig{})
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &healthpb.HealthCheckResponse{}, nil
+	}
+
+	if _, err := idempotentCall(context.Background(), interceptor, "k1", &healthpb.HealthCheckRequest{Service: "a"}, handler); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	_, err := idempotentCall(context.Background(), interceptor, "k1", &healthpb.HealthCheckRequest{Service: "b"}, handler)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("reused key with different request = %v, want InvalidArgument", err)
+	}
+}
+
+func TestIdempotencyInterceptor_FailureReleases(t *testing.T) {
+	_, store := newTestIdempotencyStore(t)
+	interceptor := IdempotencyInterceptor(store, IdempotencyConfig{})
+	req := &healthpb.HealthCheckRequest{}
+
+	_, err := idempotentCall(context.Background(), interceptor, "k1", req, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("failed call = %v, want Unavailable", err)
+	}
+
+	called := false
+	_, err = idempotentCall(context.Background(), interceptor, "k1", req, func(ctx context.Context, req any) (any, error) {
+		called = true
+		return &healthpb.HealthCheckResponse{}, nil
+	})
+	if err != nil || !called {
+		t.Fatalf("retry after failure = %v, called %v; want handler called", err, called)
+	}
+}
+
+func TestIdempotencyInterceptor_InFlight(t *testing.T) {
+	_, store := newTestIdempotencyStore(t)
+	interceptor := IdempotencyInterceptor(store, IdempotencyConfig{})
+	req := &healthpb.HealthCheckRequest{}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := idempotentCall(context.Background(), interceptor, "k1", req, func(ctx context.Context, req any) (any, error) {
+			close(started)
+			<-release
+			return &healthpb.HealthCheckResponse{}, nil
+		})
+		firstErr <- err
+	}()
+	<-started
+
+	_, err := idempotentCall(context.Background(), interceptor, "k1", req, func(ctx context.Context, req any) (any, error) {
+		return nil, errors.New("duplicate must not reach handler")
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("concurrent call = %v, want Aborted", err)
+	}
+
+	close(release)
+	if err := <-firstErr; err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+}
+
+// staleGetStore misses on the first Get, as if the response was saved right
+// after it was checked
+type staleGetStore struct {
+	*RedisIdempotencyStore
+	gets atomic.Int32
+}
+
+func (s *staleGetStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if s.gets.Add(1) == 1 {
+		return nil, false, nil
+	}
+	return s.RedisIdempotencyStore.Get(ctx, key)
+}
+
+func TestIdempotencyInterceptor_SavedBeforeReserve(t *testing.T) {
+	srv, redisStore := newTestIdempotencyStore(t)
+	req := &healthpb.HealthCheckRequest{}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+	}
+	if _, err := idempotentCall(context.Background(), IdempotencyInterceptor(redisStore, IdempotencyConfig{}), "k1", req, handler); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	store := &staleGetStore{RedisIdempotencyStore: redisStore}
+	resp, err := idempotentCall(context.Background(), IdempotencyInterceptor(store, IdempotencyConfig{}), "k1", req,
+		func(ctx context.Context, req any) (any, error) {
+			return nil, errors.New("retry must not reach handler")
+		})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if resp.(*healthpb.HealthCheckResponse).GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("retry response = %v, want stored response", resp)
+	}
+	if srv.Exists(redisStore.lockKey(idempotencyStoreKey(context.Background(), healthCheckMethod, "k1"))) {
+		t.Error("reservation must be released after replay")
+	}
+}
+
+func TestDecodeResponse_RejectsUnversioned(t *testing.T) {
+	reqHash, err := requestHash(&healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeResponse(healthCheckMethod, reqHash, data); err == nil {
+		t.Error("response stored without request hash must not be replayed")
+	}
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// payloadLogger builds log fields for request/response payloads
+// applying redaction, size limits and debug sampling from LoggingConfig
+type payloadLogger struct {
+	redact          *redactor
+	disablePayloads bool
+	maxPayloadSize  int
+	sampleRate      float64
+	random          func() float64
+}
+
+func newPayloadLogger(cfg LoggingConfig) *payloadLogger {
+	return &payloadLogger{
+		redact:          newRedactor(cfg.RedactFields),
+		disablePayloads: cfg.DisablePayloads,
+		maxPayloadSize:  cfg.MaxPayloadSize,
+		sampleRate:      cfg.DebugSampleRate,
+		random:          rand.Float64,
+	}
+}
+
+// debugEnabled reports whether debug logs should be written for this request
+func (p *payloadLogger) debugEnabled(log *zap.Logger) bool {
+	if p.sampleRate <= 0 || !log.Core().Enabled(zapcore.DebugLevel) {
+		return false
+	}
+	return p.sampleRate >= 1 || p.random() < p.sampleRate
+}
+
+// field returns a redacted and size-limited payload field
+func (p *payloadLogger) field(key string, v any) zap.Field {
+	if p.disablePayloads {
+		return zap.Skip()
+	}
+
+	redacted := p.redact.redact(v)
+	if p.maxPayloadSize <= 0 {
+		return zap.Any(key, redacted)
+	}
+
+	data, err := marshalPayload(redacted)
+	if err != nil {
+		return zap.Any(key, redacted)
+	}
+	if len(data) <= p.maxPayloadSize {
+		return zap.Reflect(key, json.RawMessage(data))
+	}
+
+	// Cut on a rune boundary to keep the log line valid UTF-8
+	cut := p.maxPayloadSize
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return zap.String(key, fmt.Sprintf("%s... (truncated, %d bytes)", data[:cut], len(data)))
+}
+
+// metadataField returns a redacted metadata field
+func (p *payloadLogger) metadataField(md metadata.MD) zap.Field {
+	return zap.Any("metadata", p.redact.redactMetadata(md))
+}
+
+func marshalPayload(v any) ([]byte, error) {
+	if msg, ok := v.(proto.Message); ok {
+		return protojson.Marshal(msg)
+	}
+	return json.Marshal(v)
+}
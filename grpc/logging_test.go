@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestPayloadLogger_Field(t *testing.T) {
+	// Marshalled as {"service":"orders"}, 20 bytes
+	req := &healthpb.HealthCheckRequest{Service: "orders"}
+
+	tests := []struct {
+		name           string
+		maxPayloadSize int
+		value          any
+		want           string
+	}{
+		{"unlimited", 0, map[string]string{"service": "orders"}, "map[service:orders]"},
+		{"under limit", 100, req, `{"service":"orders"}`},
+		{"at limit", 20, req, `{"service":"orders"}`},
+		{"over limit", 19, req, `{"service":"orders"... (truncated, 20 bytes)`},
+		// Limit falls inside "е", the cut moves back to the previous rune
+		{"cut on rune boundary", 10, "привет", `"прив... (truncated, 14 bytes)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPayloadLogger(LoggingConfig{MaxPayloadSize: tt.maxPayloadSize})
+			core, logs := observer.New(zapcore.InfoLevel)
+			zap.New(core).Info("payload", p.field("payload", tt.value))
+			if got := fmt.Sprintf("%s", logs.All()[0].ContextMap()["payload"]); got != tt.want {
+				t.Errorf("field = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayloadLogger_DebugEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		level      zapcore.Level
+		sampleRate float64
+		random     float64
+		want       bool
+	}{
+		{"zero rate disables", zapcore.DebugLevel, 0, 0, false},
+		{"negative rate disables", zapcore.DebugLevel, -1, 0, false},
+		{"full rate", zapcore.DebugLevel, 1, 0.99, true},
+		{"rate above one", zapcore.DebugLevel, 2, 0.99, true},
+		{"sampled in", zapcore.DebugLevel, 0.5, 0.49, true},
+		{"sampled out at rate", zapcore.DebugLevel, 0.5, 0.5, false},
+		{"debug level disabled", zapcore.InfoLevel, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPayloadLogger(LoggingConfig{DebugSampleRate: tt.sampleRate})
+			p.random = func() float64 { return tt.random }
+			core, _ := observer.New(tt.level)
+			if got := p.debugEnabled(zap.New(core)); got != tt.want {
+				t.Errorf("debugEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
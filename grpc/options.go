@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+// Names of the default server interceptors, used with WithInterceptorOrder
+const (
+	InterceptorRequestID = "request_id"
+	InterceptorMetrics   = "metrics"
+	InterceptorRecovery  = "recovery"
+	InterceptorLogging   = "logging"
+	InterceptorTimeout   = "timeout"
+	// InterceptorCustom marks where interceptors added with WithUnaryInterceptors are placed
+	InterceptorCustom = "custom"
+)
+
+// defaultInterceptorOrder is the chain applied when no order is given
+var defaultInterceptorOrder = []string{
+	InterceptorRequestID,
+	InterceptorMetrics,
+	InterceptorRecovery,
+	InterceptorLogging,
+	InterceptorTimeout,
+	InterceptorCustom,
+}
+
+// Option configures Server created by NewServerWithOptions
+type Option func(*serverOptions)
+
+type serverOptions struct {
+	grpcOptions       []grpc.ServerOption
+	unaryInterceptors []grpc.UnaryServerInterceptor
+	order             []string
+	panicHandler      PanicHandler
+}
+
+// WithServerOptions adds raw grpc.ServerOption values.
+// Interceptors added via grpc.ChainUnaryInterceptor here run after the managed chain.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *serverOptions) {
+		o.grpcOptions = append(o.grpcOptions, opts...)
+	}
+}
+
+// WithUnaryInterceptors adds interceptors at the InterceptorCustom position of the chain
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(o *serverOptions) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptors...)
+	}
+}
+
+// WithoutDefaultInterceptors drops all default interceptors,
+// leaving only the ones added with WithUnaryInterceptors
+func WithoutDefaultInterceptors() Option {
+	return func(o *serverOptions) {
+		o.order = []string{InterceptorCustom}
+	}
+}
+
+// WithInterceptorOrder sets the order of the unary interceptor chain.
+// Default interceptors not listed are dropped, e.g. to put a tracing
+// interceptor first and skip payload logging:
+//
+//	WithInterceptorOrder(InterceptorCustom, InterceptorRequestID, InterceptorRecovery, InterceptorTimeout)
+func WithInterceptorOrder(order ...string) Option {
+	return func(o *serverOptions) {
+		o.order = order
+	}
+}
+
+// buildUnaryChain assembles interceptors in the configured order
+func (o *serverOptions) buildUnaryChain(defaults map[string]grpc.UnaryServerInterceptor) ([]grpc.UnaryServerInterceptor, error) {
+	order := o.order
+	if order == nil {
+		order = defaultInterceptorOrder
+	}
+
+	chain := make([]grpc.UnaryServerInterceptor, 0, len(order)+len(o.unaryInterceptors))
+	seen := make(map[string]bool, len(order))
+	for _, name := range order {
+		if seen[name] {
+			return nil, fmt.Errorf("interceptor %q listed more than once", name)
+		}
+		seen[name] = true
+
+		if name == InterceptorCustom {
+			chain = append(chain, o.unaryInterceptors...)
+			continue
+		}
+		interceptor, ok := defaults[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown interceptor %q", name)
+		}
+		// Known but not configured (e.g. metrics without ServerConfig.Metrics)
+		if interceptor == nil {
+			continue
+		}
+		chain = append(chain, interceptor)
+	}
+
+	if !seen[InterceptorCustom] && len(o.unaryInterceptors) > 0 {
+		chain = append(chain, o.unaryInterceptors...)
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+// namedInterceptor records its name when called
+func namedInterceptor(name string, calls *[]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		*calls = append(*calls, name)
+		return handler(ctx, req)
+	}
+}
+
+// runChain calls chain like grpc.ChainUnaryInterceptor and returns the call order
+func runChain(t *testing.T, chain []grpc.UnaryServerInterceptor, calls *[]string) string {
+	t.Helper()
+	*calls = nil
+	handler := func(ctx context.Context, req any) (any, error) { return nil, nil }
+	for i := len(chain) - 1; i >= 0; i-- {
+		interceptor, next := chain[i], handler
+		handler = func(ctx context.Context, req any) (any, error) {
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{}, next)
+		}
+	}
+	if _, err := handler(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(*calls, ",")
+}
+
+func TestBuildUnaryChain(t *testing.T) {
+	var calls []string
+	defaults := map[string]grpc.UnaryServerInterceptor{
+		InterceptorRequestID: namedInterceptor("request_id", &calls),
+		InterceptorMetrics:   nil, // not configured
+		InterceptorRecovery:  namedInterceptor("recovery", &calls),
+		InterceptorLogging:   namedInterceptor("logging", &calls),
+		InterceptorTimeout:   namedInterceptor("timeout", &calls),
+	}
+	custom := WithUnaryInterceptors(namedInterceptor("auth", &calls), namedInterceptor("tracing", &calls))
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    string
+		wantErr string
+	}{
+		{
+			name: "default order",
+			opts: []Option{custom},
+			want: "request_id,recovery,logging,timeout,auth,tracing",
+		},
+		{
+			name: "default order without custom",
+			want: "request_id,recovery,logging,timeout",
+		},
+		{
+			name: "custom order drops unlisted defaults",
+			opts: []Option{custom, WithInterceptorOrder(InterceptorCustom, InterceptorRequestID, InterceptorRecovery)},
+			want: "auth,tracing,request_id,recovery",
+		},
+		{
+			name: "custom interceptors appended when not listed",
+			opts: []Option{custom, WithInterceptorOrder(InterceptorRecovery)},
+			want: "recovery,auth,tracing",
+		},
+		{
+			name: "without defaults",
+			opts: []Option{custom, WithoutDefaultInterceptors()},
+			want: "auth,tracing",
+		},
+		{
+			name: "options applied in order",
+			opts: []Option{WithoutDefaultInterceptors(), WithInterceptorOrder(InterceptorTimeout), custom},
+			want: "timeout,auth,tracing",
+		},
+		{
+			name:    "unknown name",
+			opts:    []Option{WithInterceptorOrder(InterceptorRecovery, "tracing")},
+			wantErr: `unknown interceptor "tracing"`,
+		},
+		{
+			name:    "duplicate name",
+			opts:    []Option{WithInterceptorOrder(InterceptorRecovery, InterceptorLogging, InterceptorRecovery)},
+			wantErr: `interceptor "recovery" listed more than once`,
+		},
+		{
+			name:    "duplicate custom",
+			opts:    []Option{custom, WithInterceptorOrder(InterceptorCustom, InterceptorCustom)},
+			wantErr: `interceptor "custom" listed more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &serverOptions{}
+			for _, opt := range tt.opts {
+				opt(o)
+			}
+
+			chain, err := o.buildUnaryChain(defaults)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := runChain(t, chain, &calls); got != tt.want {
+				t.Errorf("order = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewServerWithOptions_InvalidOrder(t *testing.T) {
+	_, err := NewServerWithOptions(ServerConfig{}, WithInterceptorOrder(InterceptorRecovery, "unknown"))
+	if err == nil || !strings.Contains(err.Error(), `unknown interceptor "unknown"`) {
+		t.Errorf("error = %v, want unknown interceptor", err)
+	}
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UserIDHeader is the metadata key carrying the authenticated user ID
+const UserIDHeader = "x-user-id"
+
+// DefaultPropagatedKeys are copied to outgoing calls by MetadataPropagationInterceptor
+var DefaultPropagatedKeys = []string{RequestIDHeader, UserIDHeader, "authorization"}
+
+// MetadataPropagationInterceptor copies request ID, user ID and authorization token
+// (or the given keys) from the incoming context to outgoing metadata, so
+// service-to-service calls made from a handler carry the caller identity.
+// User ID is taken from AuthInfo, never from incoming metadata.
+// Values already set in outgoing metadata are not overwritten.
+func MetadataPropagationInterceptor(keys ...string) grpc.UnaryClientInterceptor {
+	if len(keys) == 0 {
+		keys = DefaultPropagatedKeys
+	}
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return invoker(propagateMetadata(ctx, keys), method, req, reply, cc, opts...)
+	}
+}
+
+// propagateMetadata appends incoming metadata values missing from outgoing metadata
+// and the authenticated user ID
+func propagateMetadata(ctx context.Context, keys []string) context.Context {
+	in, _ := metadata.FromIncomingContext(ctx)
+	out, _ := metadata.FromOutgoingContext(ctx)
+
+	var pairs []string
+	for _, key := range keys {
+		if len(out.Get(key)) > 0 {
+			continue
+		}
+		values := in.Get(key)
+		// User ID comes only from authentication, a client-supplied header
+		// would let unauthenticated callers impersonate any user downstream
+		if key == UserIDHeader {
+			values = nil
+			if info, ok := GetAuthInfo(ctx); ok {
+				values = []string{strconv.FormatInt(info.UserID, 10)}
+			}
+		}
+		for _, value := range values {
+			pairs = append(pairs, key, value)
+		}
+	}
+
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPropagateMetadata(t *testing.T) {
+	authenticated := context.WithValue(context.Background(), authContextKey{}, &AuthInfo{UserID: 42})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		incoming metadata.MD
+		outgoing metadata.MD
+		want     metadata.MD
+	}{
+		{
+			name:     "incoming keys",
+			ctx:      context.Background(),
+			incoming: metadata.Pairs(RequestIDHeader, "req-1", "authorization", "Bearer abc", "x-other", "1"),
+			want:     metadata.Pairs(RequestIDHeader, "req-1", "authorization", "Bearer abc"),
+		},
+		{
+			name: "user ID from auth info",
+			ctx:  authenticated,
+			want: metadata.Pairs(UserIDHeader, "42"),
+		},
+		{
+			name:     "spoofed user ID is not forwarded",
+			ctx:      context.Background(),
+			incoming: metadata.Pairs(UserIDHeader, "1"),
+			want:     metadata.MD{},
+		},
+		{
+			name:     "spoofed user ID is replaced",
+			ctx:      authenticated,
+			incoming: metadata.Pairs(UserIDHeader, "1"),
+			want:     metadata.Pairs(UserIDHeader, "42"),
+		},
+		{
+			name:     "outgoing values kept",
+			ctx:      authenticated,
+			incoming: metadata.Pairs(RequestIDHeader, "req-1"),
+			outgoing: metadata.Pairs(RequestIDHeader, "req-2", UserIDHeader, "7"),
+			want:     metadata.Pairs(RequestIDHeader, "req-2", UserIDHeader, "7"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(tt.ctx, tt.incoming)
+			if tt.outgoing != nil {
+				ctx = metadata.NewOutgoingContext(ctx, tt.outgoing)
+			}
+			got, _ := metadata.FromOutgoingContext(propagateMetadata(ctx, DefaultPropagatedKeys))
+			for _, key := range []string{RequestIDHeader, UserIDHeader, "authorization", "x-other"} {
+				if !slices.Equal(got.Get(key), tt.want.Get(key)) {
+					t.Errorf("%s = %v, want %v", key, got.Get(key), tt.want.Get(key))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/google/uuid"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryConfig holds panic recovery configuration
+type RecoveryConfig struct {
+	// IncludeErrorID - add correlation ID (request ID) to the Internal error message,
+	// so clients can report it and it can be matched with the panic log
+	IncludeErrorID bool `yaml:"include_error_id" env:"GRPC_RECOVERY_INCLUDE_ERROR_ID" env-default:"false"`
+}
+
+// PanicInfo describes a recovered panic
+type PanicInfo struct {
+	Method  string
+	Value   any
+	Stack   []byte
+	ErrorID string // request ID, or generated ID if request has none
+}
+
+// PanicHandler is called for every recovered panic (e.g. to report to Sentry)
+type PanicHandler func(ctx context.Context, info PanicInfo)
+
+// WithPanicHandler sets a hook called by the recovery interceptor
+func WithPanicHandler(handler PanicHandler) Option {
+	return func(o *serverOptions) {
+		o.panicHandler = handler
+	}
+}
+
+func recoveryInterceptor(cfg RecoveryConfig, onPanic PanicHandler) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = handlePanic(ctx, cfg, onPanic, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// handlePanic logs recovered panic, calls the hook and builds Internal error
+func handlePanic(ctx context.Context, cfg RecoveryConfig, onPanic PanicHandler, method string, r any) error {
+	p := PanicInfo{
+		Method:  method,
+		Value:   r,
+		Stack:   debug.Stack(),
+		ErrorID: GetRequestID(ctx),
+	}
+	if p.ErrorID == "" {
+		p.ErrorID = uuid.NewString()
+	}
+
+	logger.WithContext(ctx).Error("gRPC panic recovered",
+		zap.Any("panic", r),
+		zap.String("method", method),
+		zap.String("error_id", p.ErrorID),
+		zap.ByteString("stack", p.Stack),
+	)
+
+	if onPanic != nil {
+		// A failing hook must not crash the server
+		func() {
+			defer func() {
+				if hr := recover(); hr != nil {
+					logger.WithContext(ctx).Error("panic handler failed", zap.Any("panic", hr))
+				}
+			}()
+			onPanic(ctx, p)
+		}()
+	}
+
+	if cfg.IncludeErrorID {
+		return status.Error(codes.Internal, fmt.Sprintf("internal error (error_id: %s)", p.ErrorID))
+	}
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	for _, includeErrorID := range []bool{true, false} {
+		panics := make(chan PanicInfo, 1)
+		s, err := NewServerWithOptions(ServerConfig{Recovery: RecoveryConfig{IncludeErrorID: includeErrorID}},
+			WithPanicHandler(func(ctx context.Context, info PanicInfo) {
+				panics <- info
+				panic("panic handler must not crash the server")
+			}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		registerHealthCheck(s, func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+			panic("boom")
+		})
+		client := healthpb.NewHealthClient(dialServer(t, s))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDHeader, "req-1")
+		_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+		st := status.Convert(err)
+		if st.Code() != codes.Internal {
+			t.Fatalf("includeErrorID=%v: code = %s, want Internal", includeErrorID, st.Code())
+		}
+		if got := strings.Contains(st.Message(), "error_id: req-1"); got != includeErrorID {
+			t.Errorf("includeErrorID=%v: message = %q", includeErrorID, st.Message())
+		}
+
+		info := <-panics
+		if info.Method != healthCheckMethod || info.Value != "boom" || info.ErrorID != "req-1" || len(info.Stack) == 0 {
+			t.Errorf("includeErrorID=%v: PanicInfo = %+v", includeErrorID, info)
+		}
+	}
+}
+
+func TestRecoveryInterceptor_GeneratedErrorID(t *testing.T) {
+	var info PanicInfo
+	interceptor := recoveryInterceptor(RecoveryConfig{IncludeErrorID: true}, func(ctx context.Context, p PanicInfo) {
+		info = p
+	})
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: healthCheckMethod},
+		func(ctx context.Context, req any) (any, error) { panic("boom") })
+
+	if info.ErrorID == "" || !strings.Contains(status.Convert(err).Message(), info.ErrorID) {
+		t.Errorf("error = %v, want generated error ID %q", err, info.ErrorID)
+	}
+}
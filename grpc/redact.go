@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// redactedValue replaces sensitive values in logs
+const redactedValue = "[REDACTED]"
+
+// defaultRedactFields are masked when no fields are configured
+var defaultRedactFields = []string{
+	"password", "token", "access_token", "refresh_token", "secret",
+	"authorization", "phone", "api_key", "x-api-key",
+}
+
+// redactor masks sensitive fields in payloads and metadata before logging.
+// Field names are matched case-insensitively ignoring "_" and "-", so
+// "access_token", "accessToken" and "Access-Token" are treated the same.
+// Proto fields marked with [debug_redact = true] are always masked.
+type redactor struct {
+	fields map[string]struct{}
+}
+
+func newRedactor(fields []string) *redactor {
+	if len(fields) == 0 {
+		fields = defaultRedactFields
+	}
+	r := &redactor{fields: make(map[string]struct{}, len(fields))}
+	for _, f := range fields {
+		if f = normalizeFieldName(f); f != "" {
+			r.fields[f] = struct{}{}
+		}
+	}
+	return r
+}
+
+func normalizeFieldName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "", "-", "").Replace(name)
+}
+
+func (r *redactor) sensitive(name string) bool {
+	_, ok := r.fields[normalizeFieldName(name)]
+	return ok
+}
+
+// redact returns a copy of v with sensitive fields masked
+func (r *redactor) redact(v any) any {
+	if v == nil {
+		return nil
+	}
+
+	if msg, ok := v.(proto.Message); ok {
+		if !msg.ProtoReflect().IsValid() {
+			return v
+		}
+		clone := proto.Clone(msg)
+		r.redactMessage(clone.ProtoReflect())
+		return clone
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return v
+	}
+	return r.redactValue(generic)
+}
+
+// redactMetadata returns a copy of md with sensitive keys masked
+func (r *redactor) redactMetadata(md metadata.MD) metadata.MD {
+	out := make(metadata.MD, len(md))
+	for k, v := range md {
+		if r.sensitive(k) {
+			out[k] = []string{redactedValue}
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// redactMetadataValue returns value of metadata key, masked if key is sensitive
+func (r *redactor) redactMetadataValue(key, value string) string {
+	if r.sensitive(key) {
+		return redactedValue
+	}
+	return value
+}
+
+func (r *redactor) redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if r.sensitive(k) {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = r.redactValue(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = r.redactValue(item)
+		}
+	}
+	return v
+}
+
+func (r *redactor) redactMessage(m protoreflect.Message) {
+	var sensitive []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if r.sensitiveField(fd) {
+			sensitive = append(sensitive, fd)
+			return true
+		}
+
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				r.redactMessage(list.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				r.redactMessage(mv.Message())
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			r.redactMessage(v.Message())
+		}
+		return true
+	})
+
+	for _, fd := range sensitive {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			m.Set(fd, protoreflect.ValueOfString(redactedValue))
+		} else {
+			m.Clear(fd)
+		}
+	}
+}
+
+func (r *redactor) sensitiveField(fd protoreflect.FieldDescriptor) bool {
+	if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDebugRedact() {
+		return true
+	}
+	return r.sensitive(string(fd.Name()))
+}
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	type credentials struct {
+		Login       string `json:"login"`
+		Password    string `json:"password"`
+		AccessToken string `json:"accessToken"`
+	}
+	type request struct {
+		Credentials credentials `json:"credentials"`
+		Phones      []string    `json:"phone"`
+	}
+
+	r := newRedactor([]string{"password", "access_token", "phone"})
+	got, ok := r.redact(request{
+		Credentials: credentials{Login: "user", Password: "secret", AccessToken: "abc"},
+		Phones:      []string{"+77001234567"},
+	}).(map[string]any)
+	if !ok {
+		t.Fatalf("expected map, got %T", got)
+	}
+
+	creds := got["credentials"].(map[string]any)
+	if creds["login"] != "user" {
+		t.Errorf("login should not be redacted, got %v", creds["login"])
+	}
+	if creds["password"] != redactedValue {
+		t.Errorf("password should be redacted, got %v", creds["password"])
+	}
+	if creds["accessToken"] != redactedValue {
+		t.Errorf("accessToken should be redacted, got %v", creds["accessToken"])
+	}
+	if got["phone"] != redactedValue {
+		t.Errorf("phone should be redacted, got %v", got["phone"])
+	}
+}
+
+func TestRedactor_RedactMetadata(t *testing.T) {
+	r := newRedactor(nil)
+	md := metadata.Pairs("authorization", "Bearer abc", "x-request-id", "42")
+
+	got := r.redactMetadata(md)
+	if got.Get("authorization")[0] != redactedValue {
+		t.Errorf("authorization should be redacted, got %v", got.Get("authorization"))
+	}
+	if got.Get("x-request-id")[0] != "42" {
+		t.Errorf("x-request-id should not be redacted, got %v", got.Get("x-request-id"))
+	}
+	if md.Get("authorization")[0] != "Bearer abc" {
+		t.Error("original metadata must not be modified")
+	}
+}
@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the metadata key carrying the request ID
+const RequestIDHeader = "x-request-id"
+
+// RequestIDInterceptor ensures every request has a request ID.
+// The ID is taken from incoming x-request-id metadata or generated if missing,
+// then stored in the incoming metadata (so GetRequestID works), sent back in
+// response headers and trailers (so failed calls carry it too) and attached
+// to the context logger.
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx, requestID := ensureRequestID(ctx)
+
+		md := metadata.Pairs(RequestIDHeader, requestID)
+		if err := grpc.SetHeader(ctx, md); err != nil {
+			logger.Debug("failed to set request_id header",
+				zap.String("method", info.FullMethod),
+				zap.Error(err),
+			)
+		}
+		if err := grpc.SetTrailer(ctx, md); err != nil {
+			logger.Debug("failed to set request_id trailer",
+				zap.String("method", info.FullMethod),
+				zap.Error(err),
+			)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// ensureRequestID returns context with request ID in incoming metadata and logger
+func ensureRequestID(ctx context.Context) (context.Context, string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+
+	var requestID string
+	if values := md.Get(RequestIDHeader); len(values) > 0 && values[0] != "" {
+		requestID = values[0]
+	} else {
+		requestID = uuid.NewString()
+		md = md.Copy()
+		md.Set(RequestIDHeader, requestID)
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	return logger.WithRequestID(ctx, requestID), requestID
+}
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequestIDInterceptor(t *testing.T) {
+	s, err := NewServer(ServerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerHealthCheck(s, func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+		// Handler sees the same ID the client gets back
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-handler-request-id", GetRequestID(ctx)))
+		if req.Service == "fail" {
+			return nil, status.Error(codes.NotFound, "not found")
+		}
+		return &healthpb.HealthCheckResponse{}, nil
+	})
+	client := healthpb.NewHealthClient(dialServer(t, s))
+
+	tests := []struct {
+		name      string
+		requestID string
+		service   string
+	}{
+		{"propagated", "req-1", ""},
+		{"generated", "", ""},
+		{"propagated on error", "req-2", "fail"},
+		{"generated on error", "", "fail"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, RequestIDHeader, tt.requestID)
+			}
+			var header, trailer metadata.MD
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: tt.service}, grpc.Header(&header), grpc.Trailer(&trailer))
+			if (tt.service == "fail") != (err != nil) {
+				t.Fatalf("unexpected result: %v", err)
+			}
+
+			got := firstValue(trailer, RequestIDHeader)
+			if tt.requestID != "" && got != tt.requestID {
+				t.Errorf("trailer request ID = %q, want %q", got, tt.requestID)
+			}
+			if tt.requestID == "" {
+				if _, err := uuid.Parse(got); err != nil {
+					t.Errorf("trailer request ID = %q, want generated UUID", got)
+				}
+			}
+			if err == nil {
+				if h := firstValue(header, RequestIDHeader); h != got {
+					t.Errorf("header request ID = %q, trailer %q", h, got)
+				}
+				if h := firstValue(header, "x-handler-request-id"); h != got {
+					t.Errorf("handler request ID = %q, want %q", h, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultRetryableCodes are retried when RetryableCodes is not set.
+// Internal is not retried: it usually means a bug, not a transient failure.
+var defaultRetryableCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
+
+// maxBudgetTokens caps retries accumulated by the retry budget
+const maxBudgetTokens = 100
+
+// RetryConfig holds client retry configuration.
+// Number of retries is set by ClientConfig.MaxRetries.
+type RetryConfig struct {
+	// InitialBackoff - upper bound of the first backoff (ClientConfig.RetryWaitTime if not set)
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// MaxBackoff - upper bound of any backoff
+	MaxBackoff time.Duration `yaml:"max_backoff" env-default:"2s"`
+	// Multiplier - backoff growth factor per attempt
+	Multiplier float64 `yaml:"multiplier" env-default:"2"`
+	// RetryableCodes - status codes to retry (e.g. "UNAVAILABLE"), default Unavailable, ResourceExhausted, Aborted
+	RetryableCodes []string `yaml:"retryable_codes"`
+	// Methods - per-method policies keyed by full method name, zero fields inherit the defaults
+	Methods map[string]RetryPolicy `yaml:"methods"`
+	// NonIdempotentMethods - methods that are never retried
+	NonIdempotentMethods []string `yaml:"non_idempotent_methods"`
+	// BudgetRatio - retries allowed as a share of calls (0.2 = 20%), 0 disables the budget
+	BudgetRatio float64 `yaml:"budget_ratio" env-default:"0.2"`
+	// BudgetMinPerSecond - retries per second allowed regardless of BudgetRatio
+	BudgetMinPerSecond float64 `yaml:"budget_min_per_second" env-default:"10"`
+}
+
+// RetryPolicy holds retry settings for a single method
+type RetryPolicy struct {
+	MaxRetries     int           `yaml:"max_retries"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	Multiplier     float64       `yaml:"multiplier"`
+	RetryableCodes []string      `yaml:"retryable_codes"`
+}
+
+// retryPolicy is RetryPolicy with defaults applied and codes parsed
+type retryPolicy struct {
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	multiplier     float64
+	retryable      map[codes.Code]bool
+}
+
+// backoff returns full-jitter delay before the given retry (1-based)
+func (p *retryPolicy) backoff(retry int) time.Duration {
+	ceiling := float64(p.initialBackoff) * math.Pow(p.multiplier, float64(retry-1))
+	if ceiling > float64(p.maxBackoff) {
+		ceiling = float64(p.maxBackoff)
+	}
+	if ceiling < 1 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)))
+}
+
+// merge returns policy with non-zero fields of override applied
+func (p retryPolicy) merge(override RetryPolicy) (retryPolicy, error) {
+	if override.MaxRetries > 0 {
+		p.maxRetries = override.MaxRetries
+	}
+	if override.InitialBackoff > 0 {
+		p.initialBackoff = override.InitialBackoff
+	}
+	if override.MaxBackoff > 0 {
+		p.maxBackoff = override.MaxBackoff
+	}
+	if override.Multiplier > 0 {
+		p.multiplier = override.Multiplier
+	}
+	if len(override.RetryableCodes) > 0 {
+		retryable, err := parseCodes(override.RetryableCodes)
+		if err != nil {
+			return p, err
+		}
+		p.retryable = retryable
+	}
+	return p, nil
+}
+
+// parseCodes parses status code names, case and underscores are ignored
+// ("UNAVAILABLE", "DeadlineExceeded", "deadline_exceeded")
+func parseCodes(names []string) (map[codes.Code]bool, error) {
+	byName := make(map[string]codes.Code, 17)
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		byName[normalizeCodeName(c.String())] = c
+	}
+
+	result := make(map[codes.Code]bool, len(names))
+	for _, name := range names {
+		c, ok := byName[normalizeCodeName(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown status code %q", name)
+		}
+		result[c] = true
+	}
+	return result, nil
+}
+
+func normalizeCodeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// retryBudget limits retries to a share of calls, so retries cannot
+// multiply load on a dependency that is already failing
+type retryBudget struct {
+	ratio        float64
+	minPerSecond float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRetryBudget(ratio, minPerSecond float64) *retryBudget {
+	return &retryBudget{ratio: ratio, minPerSecond: minPerSecond, tokens: minPerSecond, last: time.Now()}
+}
+
+// deposit is called for every original call
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens = math.Min(b.tokens+b.ratio, maxBudgetTokens)
+}
+
+// withdraw reports whether a retry is allowed
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *retryBudget) refill() {
+	now := time.Now()
+	b.tokens = math.Min(b.tokens+now.Sub(b.last).Seconds()*b.minPerSecond, maxBudgetTokens)
+	b.last = now
+}
+
+// retrier holds retry policies shared by unary and stream interceptors
+type retrier struct {
+	base     retryPolicy
+	policies map[string]retryPolicy
+	budget   *retryBudget
+}
+
+// policy returns retry policy for the method
+func (r *retrier) policy(method string) retryPolicy {
+	if policy, ok := r.policies[method]; ok {
+		return policy
+	}
+	return r.base
+}
+
+// wait sleeps before the given retry (1-based); returns false if
+// the retry budget is exhausted or the context is done
+func (r *retrier) wait(ctx context.Context, policy retryPolicy, method string, retry int) bool {
+	if r.budget != nil && !r.budget.withdraw() {
+		logger.Warn("gRPC client retry budget exhausted",
+			zap.String("method", method),
+			zap.Int("attempt", retry),
+		)
+		return false
+	}
+
+	waitDuration := policy.backoff(retry)
+	logger.Debug("waiting before retry",
+		zap.String("method", method),
+		zap.Int("next_attempt", retry+1),
+		zap.Duration("wait_time", waitDuration),
+	)
+	timer := time.NewTimer(waitDuration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		logger.Warn("context cancelled during retry wait",
+			zap.String("method", method),
+			zap.Error(ctx.Err()),
+		)
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// newRetrier builds retry policies from client config
+func newRetrier(cfg ClientConfig) (*retrier, error) {
+	rc := cfg.Retry
+
+	// Apply defaults if not set
+	base := retryPolicy{
+		maxRetries:     cfg.MaxRetries,
+		initialBackoff: rc.InitialBackoff,
+		maxBackoff:     rc.MaxBackoff,
+		multiplier:     rc.Multiplier,
+	}
+	if base.initialBackoff == 0 {
+		base.initialBackoff = cfg.RetryWaitTime
+	}
+	if base.initialBackoff == 0 {
+		base.initialBackoff = 100 * time.Millisecond
+	}
+	if base.maxBackoff == 0 {
+		base.maxBackoff = 2 * time.Second
+	}
+	if base.multiplier == 0 {
+		base.multiplier = 2
+	}
+	if len(rc.RetryableCodes) > 0 {
+		retryable, err := parseCodes(rc.RetryableCodes)
+		if err != nil {
+			return nil, fmt.Errorf("retryable_codes: %w", err)
+		}
+		base.retryable = retryable
+	} else {
+		base.retryable = make(map[codes.Code]bool, len(defaultRetryableCodes))
+		for _, c := range defaultRetryableCodes {
+			base.retryable[c] = true
+		}
+	}
+
+	policies := make(map[string]retryPolicy, len(rc.Methods)+len(rc.NonIdempotentMethods))
+	for method, override := range rc.Methods {
+		policy, err := base.merge(override)
+		if err != nil {
+			return nil, fmt.Errorf("retry policy for %s: %w", method, err)
+		}
+		policies[method] = policy
+	}
+	for _, method := range rc.NonIdempotentMethods {
+		policies[method] = retryPolicy{}
+	}
+
+	var budget *retryBudget
+	if rc.BudgetRatio > 0 {
+		budget = newRetryBudget(rc.BudgetRatio, rc.BudgetMinPerSecond)
+	}
+
+	return &retrier{base: base, policies: policies, budget: budget}, nil
+}
+
+// unaryInterceptor retries failed unary calls
+func (r *retrier) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		policy := r.policy(method)
+		if r.budget != nil {
+			r.budget.deposit()
+		}
+
+		var lastErr error
+
+		for i := 0; i <= policy.maxRetries; i++ {
+			attempt := i + 1
+			logger.Debug("gRPC client call attempt",
+				zap.String("method", method),
+				zap.Int("attempt", attempt),
+				zap.Int("max_retries", policy.maxRetries),
+			)
+
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil {
+				if attempt > 1 {
+					logger.Info("gRPC client call succeeded after retry",
+						zap.String("method", method),
+						zap.Int("attempt", attempt),
+					)
+				}
+				return nil
+			}
+
+			lastErr = err
+			code := status.Code(err)
+			var breakerOpen *breakerOpenError
+			retryable := policy.retryable[code] && !errors.As(err, &breakerOpen)
+
+			logger.Debug("gRPC client call attempt failed",
+				zap.String("method", method),
+				zap.Int("attempt", attempt),
+				zap.String("code", code.String()),
+				zap.Bool("retryable", retryable),
+				zap.Error(err),
+			)
+
+			// Only retry on specific codes
+			if !retryable {
+				return err
+			}
+			if i == policy.maxRetries {
+				break
+			}
+			if !r.wait(ctx, policy, method, attempt) {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return err
+			}
+		}
+
+		logger.Warn("gRPC client call failed after all retries",
+			zap.String("method", method),
+			zap.Int("total_attempts", policy.maxRetries+1),
+			zap.Error(lastErr),
+		)
+
+		return lastErr
+	}
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestParseCodes(t *testing.T) {
+	got, err := parseCodes([]string{"UNAVAILABLE", "DeadlineExceeded", "resource_exhausted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted} {
+		if !got[c] {
+			t.Errorf("code %s not parsed", c)
+		}
+	}
+
+	if _, err := parseCodes([]string{"NOT_A_CODE"}); err == nil {
+		t.Error("expected error for unknown code")
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := retryPolicy{initialBackoff: 100 * time.Millisecond, maxBackoff: time.Second, multiplier: 2}
+	ceilings := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, ceiling := range ceilings {
+		for range 100 {
+			if d := p.backoff(i + 1); d < 0 || d >= ceiling {
+				t.Fatalf("backoff(%d) = %s, want [0, %s)", i+1, d, ceiling)
+			}
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := newRetryBudget(0.5, 0)
+	if b.withdraw() {
+		t.Fatal("empty budget must reject retries")
+	}
+	b.deposit()
+	b.deposit()
+	if !b.withdraw() {
+		t.Fatal("budget must allow retry after two calls with ratio 0.5")
+	}
+	if b.withdraw() {
+		t.Fatal("budget must be exhausted")
+	}
+}
@@ -2,14 +2,23 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	channelzservice "google.golang.org/grpc/channelz/service"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
@@ -22,22 +31,112 @@ type ServerConfig struct {
 	MaxSendMsgSize  int           `yaml:"max_send_msg_size" env:"GRPC_MAX_SEND_MSG_SIZE" env-default:"4194304"` // 4MB
 	ConnectionLimit int           `yaml:"connection_limit" env:"GRPC_CONN_LIMIT" env-default:"1000"`
 	Timeout         time.Duration `yaml:"timeout" env:"GRPC_TIMEOUT" env-default:"30s"`
+
+	// Keepalive parameters. MaxConnectionIdle/MaxConnectionAge force clients to
+	// reconnect periodically so connections are rebalanced behind load balancers.
+	// Both are disabled (0) by default; 5m idle and 30m age suit services behind
+	// L4 balancers or Kubernetes Services with long-lived client connections.
+	MaxConnectionIdle     time.Duration `yaml:"max_connection_idle" env:"GRPC_MAX_CONNECTION_IDLE" env-default:"0s"`
+	MaxConnectionAge      time.Duration `yaml:"max_connection_age" env:"GRPC_MAX_CONNECTION_AGE" env-default:"0s"`
+	MaxConnectionAgeGrace time.Duration `yaml:"max_connection_age_grace" env:"GRPC_MAX_CONNECTION_AGE_GRACE" env-default:"10s"`
+	KeepAliveTime         time.Duration `yaml:"keep_alive_time" env:"GRPC_KEEP_ALIVE_TIME" env-default:"2h"`
+	KeepAliveTimeout      time.Duration `yaml:"keep_alive_timeout" env:"GRPC_KEEP_ALIVE_TIMEOUT" env-default:"20s"`
+
+	// Keepalive enforcement policy. MinTime must not exceed the clients'
+	// KeepAliveTime, otherwise the server closes connections with "too_many_pings".
+	KeepAliveMinTime             time.Duration `yaml:"keep_alive_min_time" env:"GRPC_KEEP_ALIVE_MIN_TIME" env-default:"10s"`
+	KeepAlivePermitWithoutStream bool          `yaml:"keep_alive_permit_without_stream" env:"GRPC_KEEP_ALIVE_PERMIT_WITHOUT_STREAM" env-default:"true"`
+
+	// Network - "tcp" or "unix"; for "unix" the server listens on SocketPath
+	Network    string `yaml:"network" env:"GRPC_NETWORK" env-default:"tcp"`
+	SocketPath string `yaml:"socket_path" env:"GRPC_SOCKET_PATH"`
+	// Listeners - additional endpoints served alongside the main one (e.g. TCP + UDS for sidecars)
+	Listeners []ListenerConfig `yaml:"listeners"`
+
+	// EnableWeb - serve gRPC-Web and Connect (unary) alongside native gRPC on the same port
+	EnableWeb         bool     `yaml:"enable_web" env:"GRPC_ENABLE_WEB" env-default:"false"`
+	WebAllowedOrigins []string `yaml:"web_allowed_origins" env:"GRPC_WEB_ALLOWED_ORIGINS"`
+
+	// Compression - "gzip" compresses responses for clients that accept it, "none" disables.
+	// Compressed requests are always accepted.
+	Compression string `yaml:"compression" env:"GRPC_COMPRESSION" env-default:"none"`
+
+	// EnableTracing - create OpenTelemetry spans per RPC and extract trace context
+	// from metadata, using the provider set up by tracing.Init
+	EnableTracing bool `yaml:"enable_tracing" env:"GRPC_ENABLE_TRACING" env-default:"false"`
+
+	// Metrics - optional, records request counters, latency and errors for every RPC
+	Metrics *metrics.Metrics `yaml:"-" json:"-"`
+
+	Logging  LoggingConfig  `yaml:"logging"`
+	Recovery RecoveryConfig `yaml:"recovery"`
+	Debug    DebugConfig    `yaml:"debug"`
+}
+
+// ListenerConfig describes an additional listener endpoint
+type ListenerConfig struct {
+	Network string `yaml:"network"` // "tcp" or "unix"
+	Address string `yaml:"address"` // host:port or socket path
+}
+
+// LoggingConfig holds request/response logging configuration
+type LoggingConfig struct {
+	// RedactFields - payload fields and metadata keys masked in logs
+	RedactFields []string `yaml:"redact_fields" env:"GRPC_LOG_REDACT_FIELDS" env-default:"password,token,access_token,refresh_token,secret,authorization,phone,api_key,x-api-key"`
+	// DisablePayloads - do not log request/response bodies at all
+	DisablePayloads bool `yaml:"disable_payloads" env:"GRPC_LOG_DISABLE_PAYLOADS" env-default:"false"`
+	// MaxPayloadSize - payloads larger than this (in bytes of JSON) are truncated, 0 = unlimited
+	MaxPayloadSize int `yaml:"max_payload_size" env:"GRPC_LOG_MAX_PAYLOAD_SIZE" env-default:"4096"`
+	// DebugSampleRate - fraction of requests [0..1] written to debug logs, 0 disables
+	// debug logs, failures are always logged
+	DebugSampleRate float64 `yaml:"debug_sample_rate" env:"GRPC_LOG_DEBUG_SAMPLE_RATE" env-default:"1"`
 }
 
-// Addr returns server address
+// Addr returns server address (socket path for unix network)
 func (c *ServerConfig) Addr() string {
+	if c.Network == "unix" {
+		return c.SocketPath
+	}
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// endpoints returns main and additional listener endpoints
+func (c *ServerConfig) endpoints() []ListenerConfig {
+	network := c.Network
+	if network == "" {
+		network = "tcp"
+	}
+	endpoints := []ListenerConfig{{Network: network, Address: c.Addr()}}
+	return append(endpoints, c.Listeners...)
+}
+
 // Server wraps gRPC server
 type Server struct {
-	server   *grpc.Server
-	listener net.Listener
-	config   ServerConfig
+	server     *grpc.Server
+	httpServer *http.Server
+	listeners  []net.Listener
+	config     ServerConfig
+
+	debugStats  *debugStats
+	debugServer *http.Server
 }
 
 // NewServer creates a new gRPC server
 func NewServer(cfg ServerConfig, opts ...grpc.ServerOption) (*Server, error) {
+	return NewServerWithOptions(cfg, WithServerOptions(opts...))
+}
+
+// NewServerWithOptions creates a new gRPC server configured with functional options
+func NewServerWithOptions(cfg ServerConfig, opts ...Option) (*Server, error) {
+	var options serverOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := validateCompression(cfg.Compression); err != nil {
+		return nil, err
+	}
+
 	// Apply defaults if not set
 	maxRecvMsgSize := cfg.MaxRecvMsgSize
 	if maxRecvMsgSize == 0 {
@@ -49,30 +148,84 @@ func NewServer(cfg ServerConfig, opts ...grpc.ServerOption) (*Server, error) {
 	}
 
 	logger.Info("gRPC server configuration",
+		zap.String("network", cfg.Network),
 		zap.String("host", cfg.Host),
 		zap.Int("port", cfg.Port),
+		zap.Int("additional_listeners", len(cfg.Listeners)),
 		zap.Int("max_recv_msg_size", maxRecvMsgSize),
 		zap.Int("max_send_msg_size", maxSendMsgSize),
 		zap.Int("connection_limit", cfg.ConnectionLimit),
 		zap.Duration("timeout", cfg.Timeout),
+		zap.Duration("max_connection_idle", cfg.MaxConnectionIdle),
+		zap.Duration("max_connection_age", cfg.MaxConnectionAge),
+		zap.Duration("keep_alive_min_time", cfg.KeepAliveMinTime),
+		zap.String("compression", cfg.Compression),
+		zap.Bool("tracing", cfg.EnableTracing),
 		zap.String("addr", cfg.Addr()),
 	)
 
+	var metricsInterceptor grpc.UnaryServerInterceptor
+	if cfg.Metrics != nil {
+		metricsInterceptor = cfg.Metrics.GRPCMetricsInterceptor()
+	}
+
+	unaryChain, err := options.buildUnaryChain(map[string]grpc.UnaryServerInterceptor{
+		InterceptorRequestID: RequestIDInterceptor(),
+		InterceptorMetrics:   metricsInterceptor,
+		InterceptorRecovery:  recoveryInterceptor(cfg.Recovery, options.panicHandler),
+		InterceptorLogging:   loggingInterceptor(cfg.Logging),
+		InterceptorTimeout:   timeoutInterceptor(cfg.Timeout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build interceptor chain: %w", err)
+	}
+
 	// Add default interceptors
 	defaultOpts := []grpc.ServerOption{
 		grpc.MaxRecvMsgSize(maxRecvMsgSize),
 		grpc.MaxSendMsgSize(maxSendMsgSize),
-		grpc.ChainUnaryInterceptor(
-			recoveryInterceptor(),
-			loggingInterceptor(),
-			timeoutInterceptor(cfg.Timeout),
-		),
+		// Zero values fall back to grpc-go defaults
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     cfg.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.MaxConnectionAgeGrace,
+			Time:                  cfg.KeepAliveTime,
+			Timeout:               cfg.KeepAliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.KeepAliveMinTime,
+			PermitWithoutStream: cfg.KeepAlivePermitWithoutStream,
+		}),
+		grpc.ChainUnaryInterceptor(unaryChain...),
+	}
+
+	if cfg.Metrics != nil {
+		defaultOpts = append(defaultOpts, grpc.ChainStreamInterceptor(cfg.Metrics.GRPCStreamMetricsInterceptor()))
+	}
+
+	if cfg.Compression == CompressionGzip {
+		defaultOpts = append(defaultOpts,
+			grpc.ChainUnaryInterceptor(compressionUnaryInterceptor()),
+			grpc.ChainStreamInterceptor(compressionStreamInterceptor()),
+		)
+	}
+
+	if cfg.EnableTracing {
+		defaultOpts = append(defaultOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+
+	var dbgStats *debugStats
+	if cfg.Debug.Enabled {
+		dbgStats = newDebugStats()
+		defaultOpts = append(defaultOpts, grpc.StatsHandler(dbgStats))
 	}
 
-	// User opts come first, then defaults (so defaults can override user opts if needed)
-	// Actually, we want defaults first, then user opts can override
-	allOpts := append(defaultOpts, opts...)
+	// Defaults first, then user opts can override
+	allOpts := append(defaultOpts, options.grpcOptions...)
 	server := grpc.NewServer(allOpts...)
+	if cfg.Debug.Enabled {
+		channelzservice.RegisterChannelzServiceToServer(server)
+	}
 
 	logger.Info("gRPC server created successfully",
 		zap.String("addr", cfg.Addr()),
@@ -81,8 +234,9 @@ func NewServer(cfg ServerConfig, opts ...grpc.ServerOption) (*Server, error) {
 	)
 
 	return &Server{
-		server: server,
-		config: cfg,
+		server:     server,
+		config:     cfg,
+		debugStats: dbgStats,
 	}, nil
 }
 
@@ -91,50 +245,126 @@ func (s *Server) Server() *grpc.Server {
 	return s.server
 }
 
-// Start starts the gRPC server
+// Start starts the gRPC server on all configured endpoints
 func (s *Server) Start() error {
-	listener, err := net.Listen("tcp", s.config.Addr())
-	if err != nil {
-		return fmt.Errorf("listen: %w", err)
+	endpoints := s.config.endpoints()
+	listeners := make([]net.Listener, 0, len(endpoints))
+	for _, ep := range endpoints {
+		listener, err := listen(ep.Network, ep.Address)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return fmt.Errorf("listen %s %s: %w", ep.Network, ep.Address, err)
+		}
+		listeners = append(listeners, listener)
 	}
-	s.listener = listener
 
-	logger.Info("gRPC server starting",
-		zap.String("addr", s.config.Addr()),
-	)
+	return s.Serve(listeners...)
+}
+
+// Serve serves gRPC on the given listeners and blocks until the server stops.
+// If any listener fails, the whole server is stopped and the error returned.
+func (s *Server) Serve(listeners ...net.Listener) error {
+	if len(listeners) == 0 {
+		return fmt.Errorf("no listeners to serve")
+	}
+	s.listeners = listeners
 
-	return s.server.Serve(listener)
+	if s.config.Debug.Enabled {
+		s.startDebugServer()
+	}
+
+	serve := s.server.Serve
+	if s.config.EnableWeb {
+		// gRPC-Web and Connect need HTTP/1.1, native gRPC needs h2c
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		s.httpServer = &http.Server{
+			Handler:           s.Handler(),
+			Protocols:         protocols,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		serve = func(l net.Listener) error {
+			if err := s.httpServer.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		}
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		logger.Info("gRPC server starting",
+			zap.String("network", l.Addr().Network()),
+			zap.String("addr", l.Addr().String()),
+			zap.Bool("web", s.config.EnableWeb),
+		)
+		go func(l net.Listener) {
+			errCh <- serve(l)
+		}(l)
+	}
+
+	var firstErr error
+	for range listeners {
+		if err := <-errCh; err != nil && firstErr == nil {
+			firstErr = err
+			s.stopNow()
+		}
+	}
+	return firstErr
+}
+
+// listen creates listener, removing a stale unix socket file if present
+func listen(network, address string) (net.Listener, error) {
+	if network == "unix" {
+		if address == "" {
+			return nil, fmt.Errorf("socket path is required for unix network")
+		}
+		if fi, err := os.Stat(address); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(address); err != nil {
+				return nil, fmt.Errorf("remove stale socket: %w", err)
+			}
+		}
+	}
+	return net.Listen(network, address)
 }
 
 // Stop gracefully stops the server
 func (s *Server) Stop() {
 	logger.Info("gRPC server stopping")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if s.debugServer != nil {
+		if err := s.debugServer.Shutdown(ctx); err != nil {
+			logger.Warn("debug server shutdown failed", zap.Error(err))
+		}
+	}
+	if s.httpServer != nil {
+		if err := s.httpServer.Shutdown(ctx); err != nil {
+			logger.Warn("HTTP server shutdown failed", zap.Error(err))
+		}
+	}
 	s.server.GracefulStop()
 }
 
-// Interceptors
-
-func recoveryInterceptor() grpc.UnaryServerInterceptor {
-	return func(
-		ctx context.Context,
-		req any,
-		info *grpc.UnaryServerInfo,
-		handler grpc.UnaryHandler,
-	) (resp any, err error) {
-		defer func() {
-			if r := recover(); r != nil {
-				logger.Error("gRPC panic recovered",
-					zap.Any("panic", r),
-					zap.String("method", info.FullMethod),
-				)
-				err = status.Errorf(codes.Internal, "internal error")
-			}
-		}()
-		return handler(ctx, req)
+// stopNow stops the server without waiting for in-flight requests
+func (s *Server) stopNow() {
+	if s.debugServer != nil {
+		_ = s.debugServer.Close()
+	}
+	if s.httpServer != nil {
+		_ = s.httpServer.Close()
 	}
+	s.server.Stop()
 }
 
-func loggingInterceptor() grpc.UnaryServerInterceptor {
+// Interceptors
+
+func loggingInterceptor(cfg LoggingConfig) grpc.UnaryServerInterceptor {
+	payload := newPayloadLogger(cfg)
+
 	return func(
 		ctx context.Context,
 		req any,
@@ -142,20 +372,24 @@ func loggingInterceptor() grpc.UnaryServerInterceptor {
 		handler grpc.UnaryHandler,
 	) (any, error) {
 		start := time.Now()
+		log := logger.WithContext(ctx)
+		debug := payload.debugEnabled(log)
 
-		// Log incoming request details
-		logger.Debug("gRPC request received",
-			zap.String("method", info.FullMethod),
-			zap.Any("request", req),
-		)
-
-		// Extract metadata
-		md, ok := metadata.FromIncomingContext(ctx)
-		if ok {
-			logger.Debug("gRPC request metadata",
+		if debug {
+			// Log incoming request details
+			log.Debug("gRPC request received",
 				zap.String("method", info.FullMethod),
-				zap.Any("metadata", md),
+				payload.field("request", req),
 			)
+
+			// Extract metadata
+			md, ok := metadata.FromIncomingContext(ctx)
+			if ok {
+				log.Debug("gRPC request metadata",
+					zap.String("method", info.FullMethod),
+					payload.metadataField(md),
+				)
+			}
 		}
 
 		resp, err := handler(ctx, req)
@@ -168,17 +402,19 @@ func loggingInterceptor() grpc.UnaryServerInterceptor {
 
 		// Log based on status
 		if code == codes.OK {
-			logger.Debug("gRPC request completed",
-				zap.String("method", info.FullMethod),
-				zap.Duration("duration", duration),
-				zap.Any("response", resp),
-			)
+			if debug {
+				log.Debug("gRPC request completed",
+					zap.String("method", info.FullMethod),
+					zap.Duration("duration", duration),
+					payload.field("response", resp),
+				)
+			}
 		} else {
-			logger.Warn("gRPC request failed",
+			log.Warn("gRPC request failed",
 				zap.String("method", info.FullMethod),
 				zap.Duration("duration", duration),
 				zap.String("code", code.String()),
-				zap.Any("request", req),
+				payload.field("request", req),
 				zap.Error(err),
 			)
 		}
@@ -194,36 +430,52 @@ func timeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (any, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
 		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 		return handler(ctx, req)
 	}
 }
 
-// GetMetadata extracts metadata from context
+// metadataRedactor masks sensitive values logged by GetMetadata
+var metadataRedactor = newRedactor(nil)
+
+// GetMetadata extracts metadata from context. Values of sensitive keys
+// (authorization, tokens, API keys) are masked in debug logs.
 func GetMetadata(ctx context.Context, key string) string {
+	log := logger.WithContext(ctx)
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		logger.Debug("metadata not found in context",
+		log.Debug("metadata not found in context",
 			zap.String("key", key),
 		)
 		return ""
 	}
 	values := md.Get(key)
 	if len(values) == 0 {
-		logger.Debug("metadata key not found",
+		log.Debug("metadata key not found",
 			zap.String("key", key),
 		)
 		return ""
 	}
 	value := values[0]
-	logger.Debug("metadata extracted",
+	log.Debug("metadata extracted",
 		zap.String("key", key),
-		zap.String("value", value),
+		zap.String("value", metadataRedactor.redactMetadataValue(key, value)),
 	)
 	return value
 }
 
+// firstValue returns the first value of key in md
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
 // GetUserID extracts user_id from metadata
 func GetUserID(ctx context.Context) int64 {
 	val := GetMetadata(ctx, "x-user-id")
@@ -248,13 +500,21 @@ func GetUserID(ctx context.Context) int64 {
 
 // GetRequestID extracts request_id from metadata
 func GetRequestID(ctx context.Context) string {
-	return GetMetadata(ctx, "x-request-id")
+	return GetMetadata(ctx, RequestIDHeader)
 }
 
 // AuthInterceptorConfig holds auth interceptor configuration
 type AuthInterceptorConfig struct {
 	// SkipMethods - list of methods to skip auth (e.g., "/auth.AuthService/SendCode")
 	SkipMethods []string
+	// Header - metadata key carrying the token (default "authorization")
+	Header string
+	// Scheme - prefix stripped from the Header value, case-insensitive (default "Bearer")
+	Scheme string
+	// FallbackHeaders - metadata keys with a raw token checked when Header is empty (e.g., "x-api-token")
+	FallbackHeaders []string
+	// Cookie - cookie name to read the token from when no header is set (e.g., for gRPC-Web clients)
+	Cookie string
 }
 
 // JWTValidator interface for JWT validation
@@ -267,6 +527,8 @@ type JWTClaims struct {
 	UserID   int64
 	Phone    string
 	DeviceID string
+	Roles    []string
+	Scopes   []string
 }
 
 // AuthContextKey is the key for auth info in context
@@ -277,6 +539,18 @@ type AuthInfo struct {
 	UserID   int64
 	Phone    string
 	DeviceID string
+	Roles    []string
+	Scopes   []string
+}
+
+// HasRole reports whether user has role
+func (a *AuthInfo) HasRole(role string) bool {
+	return slices.Contains(a.Roles, role)
+}
+
+// HasScope reports whether token grants scope
+func (a *AuthInfo) HasScope(scope string) bool {
+	return slices.Contains(a.Scopes, scope)
 }
 
 // GetAuthInfo extracts auth info from context
@@ -296,10 +570,7 @@ func MustGetAuthInfo(ctx context.Context) *AuthInfo {
 
 // AuthInterceptor creates authentication interceptor
 func AuthInterceptor(validator JWTValidator, cfg AuthInterceptorConfig) grpc.UnaryServerInterceptor {
-	skipMap := make(map[string]bool)
-	for _, method := range cfg.SkipMethods {
-		skipMap[method] = true
-	}
+	auth := newAuthenticator(validator, cfg)
 
 	return func(
 		ctx context.Context,
@@ -307,64 +578,136 @@ func AuthInterceptor(validator JWTValidator, cfg AuthInterceptorConfig) grpc.Una
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (any, error) {
-		// Skip auth for certain methods
-		if skipMap[info.FullMethod] {
-			return handler(ctx, req)
+		ctx, err := auth.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
+		return handler(ctx, req)
+	}
+}
 
-		// Extract token from metadata
-		token := GetMetadata(ctx, "authorization")
-		if token == "" {
-			logger.Warn("authorization token missing",
-				zap.String("method", info.FullMethod),
-			)
-			return nil, status.Error(codes.Unauthenticated, "missing authorization token")
-		}
+// authenticator validates tokens for AuthInterceptor and ConnectAuthInterceptor
+type authenticator struct {
+	validator JWTValidator
+	cfg       AuthInterceptorConfig
+	header    string
+	scheme    string
+	skipMap   map[string]bool
+}
 
-		// Remove "Bearer " prefix if present
-		originalToken := token
-		if len(token) > 7 && token[:7] == "Bearer " {
-			token = token[7:]
-			logger.Debug("removed Bearer prefix from token",
-				zap.String("method", info.FullMethod),
-				zap.Int("original_length", len(originalToken)),
-				zap.Int("token_length", len(token)),
-			)
-		}
+func newAuthenticator(validator JWTValidator, cfg AuthInterceptorConfig) *authenticator {
+	header := cfg.Header
+	if header == "" {
+		header = "authorization"
+	}
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	skipMap := make(map[string]bool)
+	for _, method := range cfg.SkipMethods {
+		skipMap[method] = true
+	}
+	return &authenticator{
+		validator: validator,
+		cfg:       cfg,
+		header:    header,
+		scheme:    scheme,
+		skipMap:   skipMap,
+	}
+}
 
-		// Validate token
-		logger.Debug("validating token",
-			zap.String("method", info.FullMethod),
-			zap.Int("token_length", len(token)),
+// authenticate validates token from incoming metadata and returns context with AuthInfo
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	// Skip auth for certain methods
+	if a.skipMap[method] {
+		return ctx, nil
+	}
+
+	// Already authenticated by APIKeyInterceptor
+	if _, ok := GetAPIKeyInfo(ctx); ok {
+		return ctx, nil
+	}
+
+	// Extract token from metadata
+	token := extractToken(ctx, a.header, a.scheme, a.cfg.FallbackHeaders, a.cfg.Cookie)
+	if token == "" {
+		logger.Warn("authorization token missing",
+			zap.String("method", method),
 		)
-		claims, err := validator.ValidateAccessToken(token)
-		if err != nil {
-			logger.Warn("invalid token",
-				zap.Error(err),
-				zap.String("method", info.FullMethod),
-				zap.Int("token_length", len(token)),
-			)
-			return nil, status.Error(codes.Unauthenticated, "invalid token")
-		}
+		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+	}
 
-		logger.Debug("token validated successfully",
-			zap.String("method", info.FullMethod),
-			zap.Int64("user_id", claims.UserID),
-			zap.String("phone", claims.Phone),
-			zap.String("device_id", claims.DeviceID),
+	// Validate token
+	logger.Debug("validating token",
+		zap.String("method", method),
+		zap.Int("token_length", len(token)),
+	)
+	claims, err := a.validator.ValidateAccessToken(token)
+	if err != nil {
+		logger.Warn("invalid token",
+			zap.Error(err),
+			zap.String("method", method),
+			zap.Int("token_length", len(token)),
 		)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	logger.Debug("token validated successfully",
+		zap.String("method", method),
+		zap.Int64("user_id", claims.UserID),
+		zap.String("phone", claims.Phone),
+		zap.String("device_id", claims.DeviceID),
+	)
+
+	// Add auth info to context
+	authInfo := &AuthInfo{
+		UserID:   claims.UserID,
+		Phone:    claims.Phone,
+		DeviceID: claims.DeviceID,
+		Roles:    claims.Roles,
+		Scopes:   claims.Scopes,
+	}
+	ctx = context.WithValue(ctx, authContextKey{}, authInfo)
 
-		// Add auth info to context
-		authInfo := &AuthInfo{
-			UserID:   claims.UserID,
-			Phone:    claims.Phone,
-			DeviceID: claims.DeviceID,
+	// Also set user_id in metadata for backward compatibility
+	ctx = metadata.AppendToOutgoingContext(ctx, UserIDHeader, fmt.Sprintf("%d", claims.UserID))
+
+	return ctx, nil
+}
+
+// extractToken reads token from the main header, fallback headers or cookie
+func extractToken(ctx context.Context, header, scheme string, fallbackHeaders []string, cookie string) string {
+	// Tokens are read without GetMetadata, so custom token headers aren't
+	// debug logged either
+	md, _ := metadata.FromIncomingContext(ctx)
+	if token := firstValue(md, header); token != "" {
+		// Remove scheme prefix if present
+		if len(token) > len(scheme) && strings.EqualFold(token[:len(scheme)], scheme) && token[len(scheme)] == ' ' {
+			token = strings.TrimSpace(token[len(scheme)+1:])
 		}
-		ctx = context.WithValue(ctx, authContextKey{}, authInfo)
+		return token
+	}
 
-		// Also set user_id in metadata for backward compatibility
-		ctx = metadata.AppendToOutgoingContext(ctx, "x-user-id", fmt.Sprintf("%d", claims.UserID))
+	for _, key := range fallbackHeaders {
+		if token := firstValue(md, key); token != "" {
+			return token
+		}
+	}
 
-		return handler(ctx, req)
+	if cookie != "" {
+		for _, line := range md.Get("cookie") {
+			cookies, err := http.ParseCookie(line)
+			if err != nil {
+				continue
+			}
+			for _, c := range cookies {
+				if c.Name == cookie && c.Value != "" {
+					return c.Value
+				}
+			}
+		}
 	}
+
+	return ""
 }
@@ -0,0 +1,212 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialServer serves s over bufconn until the test ends and returns a client connection
+func dialServer(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.stopNow)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return cc
+}
+
+// registerHealthCheck registers Health/Check served by handler
+func registerHealthCheck(s *Server, handler func(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error)) {
+	s.Server().RegisterService(&grpc.ServiceDesc{
+		ServiceName: "grpc.health.v1.Health",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Check",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := new(healthpb.HealthCheckRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				unary := func(ctx context.Context, req any) (any, error) {
+					return handler(ctx, req.(*healthpb.HealthCheckRequest))
+				}
+				if interceptor == nil {
+					return unary(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: healthCheckMethod}, unary)
+			},
+		}},
+	}, struct{}{})
+}
+
+func TestServer_ServeTCPAndUnix(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "grpc.sock")
+
+	// Stale socket file left by a crashed process is replaced
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	tcpListener, err := listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unixListener, err := listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on stale socket: %v", err)
+	}
+
+	s, err := NewServer(ServerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	healthpb.RegisterHealthServer(s.Server(), health.NewServer())
+
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(tcpListener, unixListener) }()
+
+	for _, target := range []string{tcpListener.Addr().String(), "unix://" + socket} {
+		cc, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{})
+		cancel()
+		_ = cc.Close()
+		if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Check via %s = %v, %v", target, resp, err)
+		}
+	}
+
+	s.Stop()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() after Stop = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after Stop")
+	}
+}
+
+func TestExtractToken(t *testing.T) {
+	fallbacks := []string{"x-access-token", "x-token"}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string
+	}{
+		{"no metadata", metadata.MD{}, ""},
+		{"bearer", metadata.Pairs("authorization", "Bearer abc"), "abc"},
+		{"scheme is case-insensitive", metadata.Pairs("authorization", "bEARER abc"), "abc"},
+		{"spaces after scheme", metadata.Pairs("authorization", "Bearer   abc "), "abc"},
+		{"token without scheme", metadata.Pairs("authorization", "abc"), "abc"},
+		// Left as is so the validator rejects it
+		{"wrong scheme", metadata.Pairs("authorization", "Basic abc"), "Basic abc"},
+		{"scheme without separator", metadata.Pairs("authorization", "Bearerabc"), "Bearerabc"},
+		{"empty token after scheme", metadata.Pairs("authorization", "Bearer "), ""},
+		{"blank token after scheme", metadata.Pairs("authorization", "Bearer   "), ""},
+		{
+			"header wins over fallbacks",
+			metadata.Pairs("authorization", "Bearer abc", "x-access-token", "def"),
+			"abc",
+		},
+		{
+			"fallbacks in order",
+			metadata.Pairs("x-token", "ghi", "x-access-token", "def"),
+			"def",
+		},
+		{"second fallback", metadata.Pairs("x-token", "ghi"), "ghi"},
+		{
+			"fallback wins over cookie",
+			metadata.Pairs("x-token", "ghi", "cookie", "session=jkl"),
+			"ghi",
+		},
+		{"cookie", metadata.Pairs("cookie", "session=jkl"), "jkl"},
+		{"cookie among others", metadata.Pairs("cookie", "theme=dark; session=jkl; lang=en"), "jkl"},
+		{
+			"cookie in second header",
+			metadata.Pairs("cookie", "theme=dark", "cookie", "session=jkl"),
+			"jkl",
+		},
+		{
+			"malformed cookie header skipped",
+			metadata.Pairs("cookie", "theme=\"dark", "cookie", "session=jkl"),
+			"jkl",
+		},
+		{"empty cookie", metadata.Pairs("cookie", "session=; theme=dark"), ""},
+		{"other cookie", metadata.Pairs("cookie", "sessions=jkl"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			if got := extractToken(ctx, "authorization", "Bearer", fallbacks, "session"); got != tt.want {
+				t.Errorf("extractToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMetadata_RedactsLogs(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := logger.ToContext(context.Background(), zap.New(core))
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+		"authorization", "Bearer secret-token",
+		"x-access-token", "secret-fallback",
+		RequestIDHeader, "req-1",
+	))
+
+	if got := GetMetadata(ctx, "authorization"); got != "Bearer secret-token" {
+		t.Errorf("GetMetadata(authorization) = %q", got)
+	}
+	if got := GetMetadata(ctx, RequestIDHeader); got != "req-1" {
+		t.Errorf("GetMetadata(%s) = %q", RequestIDHeader, got)
+	}
+	if got := extractToken(ctx, "x-token", "Bearer", []string{"x-access-token"}, ""); got != "secret-fallback" {
+		t.Errorf("extractToken() = %q", got)
+	}
+
+	values := make(map[string]any)
+	for _, entry := range logs.All() {
+		fields := entry.ContextMap()
+		values[fmt.Sprint(fields["key"])] = fields["value"]
+	}
+	want := map[string]any{"authorization": redactedValue, RequestIDHeader: "req-1"}
+	if len(values) != len(want) {
+		t.Fatalf("logged values = %v, want %v", values, want)
+	}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("logged %s = %v, want %v", key, values[key], value)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// clientStreamLoggingInterceptor logs stream lifecycle: start, message counts and result
+func clientStreamLoggingInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		start := time.Now()
+		log := logger.WithContext(ctx).With(
+			zap.String("method", method),
+			zap.String("target", cc.Target()),
+		)
+		log.Debug("gRPC client stream started",
+			zap.Bool("client_stream", desc.ClientStreams),
+			zap.Bool("server_stream", desc.ServerStreams),
+		)
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			log.Warn("gRPC client stream failed to start",
+				zap.String("code", status.Code(err).String()),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+
+		return &loggingClientStream{ClientStream: stream, log: log, start: start}, nil
+	}
+}
+
+// loggingClientStream counts messages and logs stream result once
+type loggingClientStream struct {
+	grpc.ClientStream
+	log   *zap.Logger
+	start time.Time
+
+	mu       sync.Mutex
+	sent     int
+	received int
+	done     bool
+}
+
+func (s *loggingClientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	s.mu.Lock()
+	if err == nil {
+		s.sent++
+	}
+	s.mu.Unlock()
+	return err
+}
+
+func (s *loggingClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.received++
+		return nil
+	}
+	if s.done {
+		return err
+	}
+	s.done = true
+
+	fields := []zap.Field{
+		zap.Duration("duration", time.Since(s.start)),
+		zap.Int("sent", s.sent),
+		zap.Int("received", s.received),
+	}
+	if errors.Is(err, io.EOF) {
+		s.log.Debug("gRPC client stream completed", fields...)
+	} else {
+		s.log.Warn("gRPC client stream failed",
+			append(fields, zap.String("code", status.Code(err).String()), zap.Error(err))...,
+		)
+	}
+	return err
+}
+
+// streamInterceptor retries stream creation. For server-streaming calls the
+// request is replayed on a new stream if it fails before the first response,
+// so no messages are lost or duplicated. Client and bidi streams are not
+// replayed once created.
+func (r *retrier) streamInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		policy := r.policy(method)
+		if r.budget != nil {
+			r.budget.deposit()
+		}
+
+		newStream := func() (grpc.ClientStream, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		stream, retries, err := r.openStream(ctx, policy, method, newStream, 0)
+		if err != nil {
+			return nil, err
+		}
+		if desc.ClientStreams {
+			return stream, nil
+		}
+
+		return &retryingClientStream{
+			ClientStream: stream,
+			ctx:          ctx,
+			retrier:      r,
+			policy:       policy,
+			method:       method,
+			newStream:    newStream,
+			retries:      retries,
+		}, nil
+	}
+}
+
+// openStream creates stream, retrying retryable failures; returns retries used
+func (r *retrier) openStream(
+	ctx context.Context,
+	policy retryPolicy,
+	method string,
+	newStream func() (grpc.ClientStream, error),
+	retries int,
+) (grpc.ClientStream, int, error) {
+	for {
+		stream, err := newStream()
+		if err == nil {
+			return stream, retries, nil
+		}
+		if !policy.retryable[status.Code(err)] || retries >= policy.maxRetries {
+			return nil, retries, err
+		}
+		retries++
+		if !r.wait(ctx, policy, method, retries) {
+			return nil, retries, err
+		}
+	}
+}
+
+// retryingClientStream replays the request of a server-streaming call
+// when the stream fails before any response is received
+type retryingClientStream struct {
+	grpc.ClientStream
+	ctx       context.Context
+	retrier   *retrier
+	policy    retryPolicy
+	method    string
+	newStream func() (grpc.ClientStream, error)
+
+	mu         sync.Mutex
+	retries    int
+	request    any
+	closedSend bool
+	received   bool
+}
+
+func (s *retryingClientStream) current() grpc.ClientStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ClientStream
+}
+
+func (s *retryingClientStream) SendMsg(m any) error {
+	s.mu.Lock()
+	s.request = m
+	s.mu.Unlock()
+	return s.current().SendMsg(m)
+}
+
+func (s *retryingClientStream) CloseSend() error {
+	s.mu.Lock()
+	s.closedSend = true
+	s.mu.Unlock()
+	return s.current().CloseSend()
+}
+
+func (s *retryingClientStream) Header() (metadata.MD, error) {
+	return s.current().Header()
+}
+
+func (s *retryingClientStream) Trailer() metadata.MD {
+	return s.current().Trailer()
+}
+
+func (s *retryingClientStream) RecvMsg(m any) error {
+	for {
+		err := s.current().RecvMsg(m)
+		if err == nil {
+			s.mu.Lock()
+			s.received = true
+			s.mu.Unlock()
+			return nil
+		}
+		if !s.replay(err) {
+			return err
+		}
+	}
+}
+
+// replay opens a new stream and resends the request; reports whether RecvMsg should be retried
+func (s *retryingClientStream) replay(err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := status.Code(err)
+	if s.received || errors.Is(err, io.EOF) || code == codes.OK || !s.policy.retryable[code] ||
+		s.retries >= s.policy.maxRetries {
+		return false
+	}
+
+	s.retries++
+	if !s.retrier.wait(s.ctx, s.policy, s.method, s.retries) {
+		return false
+	}
+	stream, retries, openErr := s.retrier.openStream(s.ctx, s.policy, s.method, s.newStream, s.retries)
+	s.retries = retries
+	if openErr != nil {
+		return false
+	}
+	if s.request != nil {
+		if err := stream.SendMsg(s.request); err != nil {
+			return false
+		}
+	}
+	if s.closedSend {
+		if err := stream.CloseSend(); err != nil {
+			return false
+		}
+	}
+
+	logger.Debug("gRPC client stream replayed",
+		zap.String("method", s.method),
+		zap.Int("attempt", s.retries+1),
+		zap.String("code", code.String()),
+	)
+	s.ClientStream = stream
+	return true
+}
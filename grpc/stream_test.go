@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const healthWatchMethod = "/grpc.health.v1.Health/Watch"
+
+// newBufconnClient serves handler as Health/Watch over bufconn and returns
+// a client connection using the given stream interceptor
+func newBufconnClient(t *testing.T, handler grpc.StreamHandler, interceptor grpc.StreamClientInterceptor) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "grpc.health.v1.Health",
+		HandlerType: (*any)(nil),
+		Streams:     []grpc.StreamDesc{{StreamName: "Watch", Handler: handler, ServerStreams: true}},
+	}, struct{}{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStreamInterceptor(interceptor),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return cc
+}
+
+func TestStreamRetry_ReplaysOnlyBeforeFirstResponse(t *testing.T) {
+	r, err := newRetrier(ClientConfig{MaxRetries: 3, Retry: RetryConfig{InitialBackoff: time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts atomic.Int32
+	cc := newBufconnClient(t, func(srv any, stream grpc.ServerStream) error {
+		attempt := attempts.Add(1)
+		var req healthpb.HealthCheckRequest
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		if req.Service != "orders" {
+			return status.Errorf(codes.InvalidArgument, "attempt %d got service %q", attempt, req.Service)
+		}
+		// First attempt fails before responding and is replayed, the second
+		// fails after a response and must not be
+		if attempt > 1 {
+			if err := stream.SendMsg(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}); err != nil {
+				return err
+			}
+		}
+		return status.Error(codes.Unavailable, "unavailable")
+	}, r.streamInterceptor())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := cc.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, healthWatchMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.SendMsg(&healthpb.HealthCheckRequest{Service: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp healthpb.HealthCheckResponse
+	if err := stream.RecvMsg(&resp); err != nil {
+		t.Fatalf("first RecvMsg: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("response = %v, want SERVING", &resp)
+	}
+	if err := stream.RecvMsg(&resp); status.Code(err) != codes.Unavailable {
+		t.Fatalf("second RecvMsg = %v, want Unavailable", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
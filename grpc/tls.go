@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ClientTLSConfig holds client TLS configuration
+type ClientTLSConfig struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// CAFile - PEM bundle to verify the server, system roots are used if empty
+	CAFile string `yaml:"ca_file"`
+	// CertFile/KeyFile - client certificate for mTLS
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// InsecureSkipVerify - do not verify server certificate (testing only)
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify" env-default:"false"`
+	// ServerNameOverride - server name used for verification instead of the host
+	ServerNameOverride string `yaml:"server_name_override"`
+}
+
+// transportCredentials returns TLS credentials, or insecure ones if TLS is disabled
+func (c *ClientTLSConfig) transportCredentials() (credentials.TransportCredentials, error) {
+	if !c.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerNameOverride,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"buf.build/go/protovalidate"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ValidateFunc validates a request message, e.g. with a protovalidate
+// validator built with custom options
+type ValidateFunc func(req any) error
+
+// FieldViolationsError is implemented by validation errors that can describe
+// field-level violations; they are returned as google.rpc.BadRequest details
+type FieldViolationsError interface {
+	error
+	FieldViolations() []*errdetails.BadRequest_FieldViolation
+}
+
+// protoc-gen-validate generated interfaces
+type (
+	validatorAll interface{ ValidateAll() error }
+	validator    interface{ Validate() error }
+	multiError   interface{ AllErrors() []error }
+	fieldError   interface {
+		Field() string
+		Reason() string
+	}
+)
+
+// ValidationInterceptor validates incoming requests and returns
+// codes.InvalidArgument with BadRequest field violations on failure.
+// Without arguments messages with protoc-gen-validate generated
+// ValidateAll/Validate methods are validated with them, all other messages
+// with protovalidate (buf.validate rules).
+func ValidationInterceptor(validate ...ValidateFunc) grpc.UnaryServerInterceptor {
+	validateFn := validateDefault
+	if len(validate) > 0 && validate[0] != nil {
+		validateFn = validate[0]
+	}
+
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if err := validateFn(req); err != nil {
+			var compileErr *protovalidate.CompilationError
+			var runtimeErr *protovalidate.RuntimeError
+			if errors.As(err, &compileErr) || errors.As(err, &runtimeErr) {
+				// Broken rules are a server bug, not a bad request
+				logger.WithContext(ctx).Error("request validation rules failed",
+					zap.String("method", info.FullMethod),
+					zap.Error(err),
+				)
+				return nil, status.Error(codes.Internal, "request validation failed")
+			}
+			logger.WithContext(ctx).Debug("request validation failed",
+				zap.String("method", info.FullMethod),
+				zap.Error(err),
+			)
+			return nil, validationStatus(err).Err()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// validateDefault calls protoc-gen-validate methods if the message has
+// them, otherwise validates it with protovalidate
+func validateDefault(req any) error {
+	switch v := req.(type) {
+	case validatorAll:
+		return v.ValidateAll()
+	case validator:
+		return v.Validate()
+	case proto.Message:
+		return protovalidate.Validate(v)
+	}
+	return nil
+}
+
+// validationStatus converts validation error to InvalidArgument status with details
+func validationStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.InvalidArgument {
+		return st
+	}
+
+	violations := fieldViolations(err)
+	if len(violations) == 0 {
+		return status.New(codes.InvalidArgument, err.Error())
+	}
+
+	st := status.New(codes.InvalidArgument, "validation failed")
+	detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailErr != nil {
+		logger.Warn("failed to attach validation details", zap.Error(detailErr))
+		return status.New(codes.InvalidArgument, err.Error())
+	}
+	return detailed
+}
+
+// fieldViolations extracts field-level violations from validation error
+func fieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var fvErr FieldViolationsError
+	if errors.As(err, &fvErr) {
+		return fvErr.FieldViolations()
+	}
+
+	var pvErr *protovalidate.ValidationError
+	if errors.As(err, &pvErr) {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(pvErr.Violations))
+		for _, v := range pvErr.Violations {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       protovalidate.FieldPathString(v.Proto.GetField()),
+				Description: v.Proto.GetMessage(),
+				Reason:      v.Proto.GetRuleId(),
+			})
+		}
+		return violations
+	}
+
+	errs := []error{err}
+	var multi multiError
+	if errors.As(err, &multi) {
+		errs = multi.AllErrors()
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(errs))
+	for _, e := range errs {
+		var fe fieldError
+		if errors.As(e, &fe) {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       fe.Field(),
+				Description: fe.Reason(),
+			})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// pgvFieldError mimics protoc-gen-validate generated field errors
+type pgvFieldError struct {
+	field, reason string
+}
+
+func (e pgvFieldError) Error() string  { return e.field + ": " + e.reason }
+func (e pgvFieldError) Field() string  { return e.field }
+func (e pgvFieldError) Reason() string { return e.reason }
+
+// pgvMultiError mimics protoc-gen-validate generated multi errors
+type pgvMultiError []error
+
+func (e pgvMultiError) Error() string      { return errors.Join(e...).Error() }
+func (e pgvMultiError) AllErrors() []error { return e }
+
+// pgvRequest has protoc-gen-validate style ValidateAll
+type pgvRequest struct {
+	err error
+}
+
+func (r *pgvRequest) ValidateAll() error { return r.err }
+
+// newValidatedMessage builds a dynamic message with a buf.validate
+// min_len rule on its name field
+func newValidatedMessage(t *testing.T, name string) proto.Message {
+	t.Helper()
+	fieldOpts := &descriptorpb.FieldOptions{}
+	proto.SetExtension(fieldOpts, validate.E_Field, &validate.FieldRules{
+		Type: &validate.FieldRules_String_{String_: &validate.StringRules{MinLen: proto.Uint64(3)}},
+	})
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("validation_test.proto"),
+		Package: proto.String("cg.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("CreateUserRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("name"),
+				JsonName: proto.String("name"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Options:  fieldOpts,
+			}},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := fd.Messages().Get(0)
+	msg := dynamicpb.NewMessage(md)
+	msg.Set(md.Fields().ByName("name"), protoreflect.ValueOfString(name))
+	return msg
+}
+
+// validateRequest runs ValidationInterceptor and returns its error
+func validateRequest(req any, validate ...ValidateFunc) error {
+	_, err := ValidationInterceptor(validate...)(context.Background(), req,
+		&grpc.UnaryServerInfo{FullMethod: "/cg.test.Users/Create"},
+		func(ctx context.Context, req any) (any, error) { return "ok", nil })
+	return err
+}
+
+// badRequest returns BadRequest details of InvalidArgument error
+func badRequest(t *testing.T, err error) *errdetails.BadRequest {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %s, want InvalidArgument", st.Code())
+	}
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			return br
+		}
+	}
+	t.Fatalf("no BadRequest details in %v", st.Details())
+	return nil
+}
+
+func TestValidationInterceptor_Protovalidate(t *testing.T) {
+	if err := validateRequest(newValidatedMessage(t, "alice")); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+
+	br := badRequest(t, validateRequest(newValidatedMessage(t, "al")))
+	if len(br.FieldViolations) != 1 {
+		t.Fatalf("violations = %v, want 1", br.FieldViolations)
+	}
+	v := br.FieldViolations[0]
+	if v.Field != "name" || v.Reason != "string.min_len" || v.Description == "" {
+		t.Errorf("violation = %v, want name string.min_len", v)
+	}
+}
+
+func TestValidationInterceptor_PGV(t *testing.T) {
+	if err := validateRequest(&pgvRequest{}); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+
+	req := &pgvRequest{err: pgvMultiError{
+		pgvFieldError{field: "Phone", reason: "value does not match regex pattern"},
+		pgvFieldError{field: "Name", reason: "value length must be at least 3 runes"},
+	}}
+	br := badRequest(t, validateRequest(req))
+	if len(br.FieldViolations) != 2 || br.FieldViolations[0].Field != "Phone" || br.FieldViolations[1].Field != "Name" {
+		t.Errorf("violations = %v, want Phone and Name", br.FieldViolations)
+	}
+}
+
+func TestValidationInterceptor_Custom(t *testing.T) {
+	err := validateRequest(newValidatedMessage(t, "al"), func(req any) error {
+		return errors.New("custom failure")
+	})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || st.Message() != "custom failure" {
+		t.Errorf("status = %v, want InvalidArgument custom failure", st)
+	}
+}
@@ -0,0 +1,485 @@
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	grpcContentType    = "application/grpc"
+	grpcWebContentType = "application/grpc-web"
+	grpcWebTextType    = "application/grpc-web-text"
+	connectProtoType   = "application/proto"
+	connectJSONType    = "application/json"
+
+	// gRPC message frame: 1 byte flags + 4 bytes length
+	frameHeaderSize = 5
+	frameCompressed = 0x01
+	frameTrailer    = 0x80
+)
+
+// Handler returns http.Handler serving native gRPC (HTTP/2), gRPC-Web and
+// Connect unary requests with the registered services.
+// All services must be registered before the handler receives requests.
+func (s *Server) Handler() http.Handler {
+	errWriter := connect.NewErrorWriter()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.handleCORS(w, r) {
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		switch {
+		case strings.HasPrefix(contentType, grpcWebContentType):
+			s.serveGRPCWeb(w, r, strings.HasPrefix(contentType, grpcWebTextType))
+		case strings.HasPrefix(contentType, grpcContentType) && r.ProtoMajor == 2:
+			s.server.ServeHTTP(w, r)
+		case r.Method == http.MethodPost && isConnectUnaryContentType(contentType):
+			s.serveConnectUnary(w, r, errWriter)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported content type %q", contentType), http.StatusUnsupportedMediaType)
+		}
+	})
+}
+
+// handleCORS sets CORS headers for allowed origins and answers preflight requests
+func (s *Server) handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !s.originAllowed(origin) {
+		return false
+	}
+
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Add("Vary", "Origin")
+	h.Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin, X-Request-Id")
+
+	if r.Method != http.MethodOptions {
+		return false
+	}
+	h.Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	h.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Grpc-Web, X-User-Agent, X-Request-Id, "+
+		"Grpc-Timeout, Connect-Protocol-Version, Connect-Timeout-Ms")
+	h.Set("Access-Control-Max-Age", "7200")
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func (s *Server) originAllowed(origin string) bool {
+	for _, allowed := range s.config.WebAllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveGRPCWeb translates gRPC-Web request into native gRPC and streams the
+// response back, moving trailers into the body as gRPC-Web requires
+func (s *Server) serveGRPCWeb(w http.ResponseWriter, r *http.Request, text bool) {
+	var body io.Reader = r.Body
+	if text {
+		body = base64.NewDecoder(base64.StdEncoding, r.Body)
+	}
+
+	rw := &grpcWebResponseWriter{
+		w:           w,
+		header:      make(http.Header),
+		text:        text,
+		contentType: r.Header.Get("Content-Type"),
+	}
+	s.server.ServeHTTP(rw, toGRPCRequest(r, body))
+	rw.finish()
+}
+
+// serveConnectUnary translates Connect unary request into native gRPC
+func (s *Server) serveConnectUnary(w http.ResponseWriter, r *http.Request, errWriter *connect.ErrorWriter) {
+	contentType := r.Header.Get("Content-Type")
+	isJSON := strings.HasPrefix(contentType, connectJSONType)
+
+	payload, err := readConnectBody(r, s.maxRecvMsgSize())
+	if err != nil {
+		_ = errWriter.Write(w, r, connect.NewError(connect.CodeInvalidArgument, err))
+		return
+	}
+
+	var method protoreflect.MethodDescriptor
+	if isJSON {
+		if method, err = findMethod(r.URL.Path); err != nil {
+			_ = errWriter.Write(w, r, connect.NewError(connect.CodeUnimplemented, err))
+			return
+		}
+		if payload, err = convertMessage(payload, method.Input(), protojson.Unmarshal, proto.Marshal); err != nil {
+			_ = errWriter.Write(w, r, connect.NewError(connect.CodeInvalidArgument, err))
+			return
+		}
+	}
+
+	frame := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(len(payload)))
+	copy(frame[frameHeaderSize:], payload)
+
+	grpcReq := toGRPCRequest(r, bytes.NewReader(frame))
+	if ms := r.Header.Get("Connect-Timeout-Ms"); ms != "" {
+		timeout, err := grpcTimeout(ms)
+		if err != nil {
+			_ = errWriter.Write(w, r, connect.NewError(connect.CodeInvalidArgument, err))
+			return
+		}
+		grpcReq.Header.Set("Grpc-Timeout", timeout)
+	}
+
+	rec := &grpcResponseRecorder{header: make(http.Header)}
+	s.server.ServeHTTP(rec, grpcReq)
+
+	if code := rec.code(); code != codes.OK {
+		connectErr := statusToConnectError(code, rec.message(), rec.header.Get("Grpc-Status-Details-Bin"))
+		for k, v := range rec.metadata() {
+			connectErr.Meta()[k] = v
+		}
+		_ = errWriter.Write(w, r, connectErr)
+		return
+	}
+
+	out, err := rec.responseMessage()
+	if err == nil && isJSON {
+		out, err = convertMessage(out, method.Output(), proto.Unmarshal, protojson.Marshal)
+	}
+	if err != nil {
+		logger.Error("failed to translate gRPC response to Connect",
+			zap.String("path", r.URL.Path),
+			zap.Error(err),
+		)
+		_ = errWriter.Write(w, r, connect.NewError(connect.CodeInternal, err))
+		return
+	}
+
+	h := w.Header()
+	for k, v := range rec.metadata() {
+		h[k] = v
+	}
+	for k, v := range rec.trailers() {
+		h["Trailer-"+k] = v
+	}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(out)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// grpcTimeout converts Connect-Timeout-Ms (up to 10 digits) to Grpc-Timeout,
+// which allows at most 8 digits, switching to coarser units rounded up
+func grpcTimeout(connectMs string) (string, error) {
+	ms, err := strconv.ParseInt(connectMs, 10, 64)
+	if err != nil || ms < 0 || len(connectMs) > 10 {
+		return "", fmt.Errorf("invalid Connect-Timeout-Ms %q", connectMs)
+	}
+
+	const maxValue = 99999999
+	value, unit := ms, "m"
+	for _, next := range []struct {
+		factor int64
+		unit   string
+	}{{1000, "S"}, {60, "M"}, {60, "H"}} {
+		if value <= maxValue {
+			break
+		}
+		value, unit = (value+next.factor-1)/next.factor, next.unit
+	}
+	return strconv.FormatInt(value, 10) + unit, nil
+}
+
+func (s *Server) maxRecvMsgSize() int {
+	if s.config.MaxRecvMsgSize > 0 {
+		return s.config.MaxRecvMsgSize
+	}
+	return 4194304 // 4MB default
+}
+
+func isConnectUnaryContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, connectProtoType) || strings.HasPrefix(contentType, connectJSONType)
+}
+
+// toGRPCRequest clones request making it acceptable for grpc.Server.ServeHTTP
+func toGRPCRequest(r *http.Request, body io.Reader) *http.Request {
+	req := r.Clone(r.Context())
+	req.ProtoMajor, req.ProtoMinor, req.Proto = 2, 0, "HTTP/2.0"
+	req.Body = io.NopCloser(body)
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", grpcContentType+"+proto")
+	req.Header.Set("Te", "trailers")
+	req.Header.Del("Content-Length")
+	req.Header.Del("Content-Encoding")
+	return req
+}
+
+// readConnectBody reads (and decompresses) Connect unary request body
+func readConnectBody(r *http.Request, limit int) ([]byte, error) {
+	var body io.Reader = r.Body
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read gzip body: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("message larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+// findMethod resolves "/pkg.Service/Method" path in the global proto registry
+func findMethod(path string) (protoreflect.MethodDescriptor, error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid method path %q", path)
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %q not found: %w", service, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("method %q not found", path)
+	}
+	return md, nil
+}
+
+// convertMessage re-encodes message between JSON and binary protobuf
+func convertMessage(
+	data []byte,
+	desc protoreflect.MessageDescriptor,
+	unmarshal func([]byte, proto.Message) error,
+	marshal func(proto.Message) ([]byte, error),
+) ([]byte, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err != nil {
+		return nil, fmt.Errorf("message type %q not found: %w", desc.FullName(), err)
+	}
+	msg := mt.New().Interface()
+	if err := unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", desc.FullName(), err)
+	}
+	return marshal(msg)
+}
+
+// statusToConnectError builds Connect error from gRPC status parts, preserving details
+func statusToConnectError(code codes.Code, message, detailsBin string) *connect.Error {
+	st := &spb.Status{Code: int32(code), Message: message}
+	if detailsBin != "" {
+		if data, err := decodeBinHeader(detailsBin); err == nil {
+			var withDetails spb.Status
+			if err := proto.Unmarshal(data, &withDetails); err == nil {
+				st.Details = withDetails.GetDetails()
+			}
+		}
+	}
+	return connectErrorFromStatus(st)
+}
+
+func decodeBinHeader(v string) ([]byte, error) {
+	if len(v)%4 == 0 {
+		return base64.StdEncoding.DecodeString(v)
+	}
+	return base64.RawStdEncoding.DecodeString(v)
+}
+
+// grpcWebResponseWriter adapts native gRPC response to gRPC-Web framing
+type grpcWebResponseWriter struct {
+	w             http.ResponseWriter
+	header        http.Header
+	text          bool
+	contentType   string
+	headerWritten bool
+}
+
+func (rw *grpcWebResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *grpcWebResponseWriter) WriteHeader(code int) {
+	if rw.headerWritten {
+		return
+	}
+	rw.headerWritten = true
+
+	h := rw.w.Header()
+	for k, v := range rw.header {
+		if k == "Trailer" || strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		h[k] = v
+	}
+	h.Set("Content-Type", rw.contentType)
+	h.Del("Content-Length")
+	rw.w.WriteHeader(code)
+}
+
+func (rw *grpcWebResponseWriter) Write(b []byte) (int, error) {
+	if !rw.headerWritten {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.text {
+		if _, err := io.WriteString(rw.w, base64.StdEncoding.EncodeToString(b)); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return rw.w.Write(b)
+}
+
+func (rw *grpcWebResponseWriter) Flush() {
+	if !rw.headerWritten {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes trailers as the final gRPC-Web frame
+func (rw *grpcWebResponseWriter) finish() {
+	var buf bytes.Buffer
+	for k, v := range collectTrailers(rw.header) {
+		for _, value := range v {
+			fmt.Fprintf(&buf, "%s: %s\r\n", strings.ToLower(k), value)
+		}
+	}
+
+	frame := make([]byte, frameHeaderSize+buf.Len())
+	frame[0] = frameTrailer
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(buf.Len()))
+	copy(frame[frameHeaderSize:], buf.Bytes())
+
+	_, _ = rw.Write(frame)
+	rw.Flush()
+}
+
+// collectTrailers returns declared and "Trailer:"-prefixed trailer values
+func collectTrailers(h http.Header) http.Header {
+	trailers := make(http.Header)
+	for _, declared := range h.Values("Trailer") {
+		key := http.CanonicalHeaderKey(declared)
+		if v, ok := h[key]; ok {
+			trailers[key] = v
+		}
+	}
+	for k, v := range h {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			trailers[http.CanonicalHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix))] = v
+		}
+	}
+	return trailers
+}
+
+// grpcResponseRecorder buffers native gRPC response for unary translation
+type grpcResponseRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rec *grpcResponseRecorder) Header() http.Header         { return rec.header }
+func (rec *grpcResponseRecorder) WriteHeader(int)             {}
+func (rec *grpcResponseRecorder) Write(b []byte) (int, error) { return rec.body.Write(b) }
+func (rec *grpcResponseRecorder) Flush()                      {}
+
+func (rec *grpcResponseRecorder) code() codes.Code {
+	code, err := strconv.Atoi(rec.header.Get("Grpc-Status"))
+	if err != nil {
+		return codes.Unknown
+	}
+	return codes.Code(code)
+}
+
+func (rec *grpcResponseRecorder) message() string {
+	msg := rec.header.Get("Grpc-Message")
+	if decoded, err := url.PathUnescape(msg); err == nil {
+		return decoded
+	}
+	return msg
+}
+
+// responseMessage returns the single unary response message from the body
+func (rec *grpcResponseRecorder) responseMessage() ([]byte, error) {
+	data := rec.body.Bytes()
+	if len(data) < frameHeaderSize {
+		return nil, fmt.Errorf("response frame too short")
+	}
+	size := binary.BigEndian.Uint32(data[1:frameHeaderSize])
+	if int(size) > len(data)-frameHeaderSize {
+		return nil, fmt.Errorf("response frame truncated")
+	}
+	payload := data[frameHeaderSize : frameHeaderSize+int(size)]
+	if data[0]&frameCompressed == 0 {
+		return payload, nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("decompress response: %w", err)
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+// metadata returns response header metadata without protocol headers
+func (rec *grpcResponseRecorder) metadata() http.Header {
+	md := make(http.Header)
+	trailers := collectTrailers(rec.header)
+	for k, v := range rec.header {
+		if k == "Trailer" || k == "Content-Type" || k == "Date" ||
+			strings.HasPrefix(k, "Grpc-") || strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		if _, isTrailer := trailers[k]; isTrailer {
+			continue
+		}
+		md[k] = v
+	}
+	return md
+}
+
+// trailers returns response trailer metadata without gRPC status fields
+func (rec *grpcResponseRecorder) trailers() http.Header {
+	trailers := collectTrailers(rec.header)
+	for k := range trailers {
+		if strings.HasPrefix(k, "Grpc-") {
+			delete(trailers, k)
+		}
+	}
+	return trailers
+}
@@ -0,0 +1,292 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// webTestHealthCheck serves Health/Check: service "invalid" fails with
+// BadRequest details, other requests succeed setting header and trailer
+func webTestHealthCheck(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	req := new(healthpb.HealthCheckRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		if req.(*healthpb.HealthCheckRequest).Service == "invalid" {
+			st, _ := status.New(codes.InvalidArgument, "invalid service").WithDetails(&errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "service", Description: "unknown"}},
+			})
+			return nil, st.Err()
+		}
+		header := metadata.Pairs("x-header", "h")
+		if _, ok := ctx.Deadline(); ok {
+			header.Set("x-deadline", "set")
+		}
+		_ = grpc.SetHeader(ctx, header)
+		_ = grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", "t"))
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+	}
+	if interceptor == nil {
+		return handler(ctx, req)
+	}
+	return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: healthCheckMethod}, handler)
+}
+
+func newWebTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	s, err := NewServer(ServerConfig{EnableWeb: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Server().RegisterService(&grpc.ServiceDesc{
+		ServiceName: "grpc.health.v1.Health",
+		HandlerType: (*any)(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "Check", Handler: webTestHealthCheck}},
+	}, struct{}{})
+	return s.Handler()
+}
+
+// grpcFrame frames message as gRPC length-prefixed message
+func grpcFrame(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := make([]byte, frameHeaderSize+len(data))
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(len(data)))
+	copy(frame[frameHeaderSize:], data)
+	return frame
+}
+
+// parseWebFrames splits gRPC-Web response body into data messages and trailers
+func parseWebFrames(t *testing.T, body []byte) ([][]byte, map[string]string) {
+	t.Helper()
+	var messages [][]byte
+	trailers := make(map[string]string)
+	for len(body) > 0 {
+		if len(body) < frameHeaderSize {
+			t.Fatalf("truncated frame header: %q", body)
+		}
+		flags, size := body[0], binary.BigEndian.Uint32(body[1:frameHeaderSize])
+		payload := body[frameHeaderSize : frameHeaderSize+int(size)]
+		body = body[frameHeaderSize+int(size):]
+		if flags&frameTrailer == 0 {
+			messages = append(messages, payload)
+			continue
+		}
+		for line := range strings.SplitSeq(strings.TrimSpace(string(payload)), "\r\n") {
+			key, value, _ := strings.Cut(line, ": ")
+			trailers[key] = value
+		}
+	}
+	return messages, trailers
+}
+
+// decodeWebText decodes gRPC-Web text body written as separately padded base64 chunks
+func decodeWebText(t *testing.T, body string) []byte {
+	t.Helper()
+	var out []byte
+	for len(body) >= 4 {
+		quantum, err := base64.StdEncoding.DecodeString(body[:4])
+		if err != nil {
+			t.Fatalf("decode base64 body: %v", err)
+		}
+		out = append(out, quantum...)
+		body = body[4:]
+	}
+	return out
+}
+
+func TestWeb_GRPCWeb(t *testing.T) {
+	handler := newWebTestServer(t)
+
+	for _, text := range []bool{false, true} {
+		contentType := grpcWebContentType + "+proto"
+		body := grpcFrame(t, &healthpb.HealthCheckRequest{})
+		if text {
+			contentType = grpcWebTextType
+			body = []byte(base64.StdEncoding.EncodeToString(body))
+		}
+
+		req := httptest.NewRequest(http.MethodPost, healthCheckMethod, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != contentType {
+			t.Fatalf("text=%v: response %d %q", text, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if got := rec.Header().Get("X-Header"); got != "h" {
+			t.Errorf("text=%v: x-header = %q, want h", text, got)
+		}
+
+		respBody := rec.Body.Bytes()
+		if text {
+			respBody = decodeWebText(t, rec.Body.String())
+		}
+		messages, trailers := parseWebFrames(t, respBody)
+		if len(messages) != 1 {
+			t.Fatalf("text=%v: messages = %d, want 1", text, len(messages))
+		}
+		var resp healthpb.HealthCheckResponse
+		if err := proto.Unmarshal(messages[0], &resp); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("text=%v: response = %v, %v", text, &resp, err)
+		}
+		if trailers["grpc-status"] != "0" || trailers["x-trailer"] != "t" {
+			t.Errorf("text=%v: trailers = %v, want status 0 and x-trailer", text, trailers)
+		}
+	}
+}
+
+func TestWeb_GRPCWebError(t *testing.T) {
+	handler := newWebTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, healthCheckMethod,
+		bytes.NewReader(grpcFrame(t, &healthpb.HealthCheckRequest{Service: "invalid"})))
+	req.Header.Set("Content-Type", grpcWebContentType)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	messages, trailers := parseWebFrames(t, rec.Body.Bytes())
+	if len(messages) != 0 {
+		t.Errorf("messages = %d, want none", len(messages))
+	}
+	if trailers["grpc-status"] != "3" || trailers["grpc-status-details-bin"] == "" {
+		t.Errorf("trailers = %v, want InvalidArgument with details", trailers)
+	}
+}
+
+func TestWeb_ConnectProto(t *testing.T) {
+	handler := newWebTestServer(t)
+
+	data, _ := proto.Marshal(&healthpb.HealthCheckRequest{Service: "orders"})
+	req := httptest.NewRequest(http.MethodPost, healthCheckMethod, bytes.NewReader(data))
+	req.Header.Set("Content-Type", connectProtoType)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp healthpb.HealthCheckResponse
+	if err := proto.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("response = %v, %v", &resp, err)
+	}
+	if rec.Header().Get("X-Header") != "h" || rec.Header().Get("Trailer-X-Trailer") != "t" {
+		t.Errorf("headers = %v, want x-header and trailer-x-trailer", rec.Header())
+	}
+}
+
+func TestWeb_ConnectJSON(t *testing.T) {
+	handler := newWebTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, healthCheckMethod, strings.NewReader(`{"service":"orders"}`))
+	req.Header.Set("Content-Type", connectJSONType)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp["status"] != "SERVING" {
+		t.Errorf("response = %s, %v", rec.Body, err)
+	}
+}
+
+func TestWeb_ConnectError(t *testing.T) {
+	handler := newWebTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, healthCheckMethod, strings.NewReader(`{"service":"invalid"}`))
+	req.Header.Set("Content-Type", connectJSONType)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	var resp struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Type string `json:"type"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error body %s: %v", rec.Body, err)
+	}
+	if resp.Code != "invalid_argument" || resp.Message != "invalid service" {
+		t.Errorf("error = %s, want invalid_argument", rec.Body)
+	}
+	if len(resp.Details) != 1 || resp.Details[0].Type != "google.rpc.BadRequest" {
+		t.Errorf("details = %+v, want google.rpc.BadRequest", resp.Details)
+	}
+}
+
+func TestWeb_ConnectTimeout(t *testing.T) {
+	handler := newWebTestServer(t)
+
+	for _, tt := range []struct {
+		timeout string
+		code    int
+	}{
+		{"1500", http.StatusOK},
+		{"9999999999", http.StatusOK}, // more digits than Grpc-Timeout allows
+		{"soon", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, healthCheckMethod, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", connectJSONType)
+		req.Header.Set("Connect-Timeout-Ms", tt.timeout)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.code {
+			t.Errorf("timeout %s: status = %d, want %d: %s", tt.timeout, rec.Code, tt.code, rec.Body)
+			continue
+		}
+		if tt.code == http.StatusOK && rec.Header().Get("X-Deadline") != "set" {
+			t.Errorf("timeout %s: handler has no deadline", tt.timeout)
+		}
+	}
+}
+
+func TestGRPCTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		ms, want string
+	}{
+		{"0", "0m"},
+		{"1500", "1500m"},
+		{"99999999", "99999999m"},
+		{"100000000", "100000S"},
+		{"100000001", "100001S"},
+		{"9999999999", "10000000S"},
+	} {
+		got, err := grpcTimeout(tt.ms)
+		if err != nil || got != tt.want {
+			t.Errorf("grpcTimeout(%s) = %q, %v; want %q", tt.ms, got, err, tt.want)
+		}
+	}
+
+	for _, ms := range []string{"", "-1", "1.5", "12345678901"} {
+		if _, err := grpcTimeout(ms); err == nil {
+			t.Errorf("grpcTimeout(%q) must fail", ms)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package jwt
+
+import (
+	"fmt"
+	"time"
+)
+
+// CustomClaims carries service-specific claims in the ext claim next to
+// the common ones, so tokens stay readable by Manager.ValidateAccessToken
+// and the gRPC auth interceptor:
+//
+//	type UserClaims struct {
+//		Role     string `json:"role"`
+//		TenantID int64  `json:"tenant_id"`
+//	}
+//
+//	pair, err := jwt.GenerateTokenPair(m, userID, phone, deviceID, UserClaims{Role: "admin"})
+//	claims, err := jwt.ValidateAccessToken[UserClaims](m, pair.AccessToken)
+//	claims.Extra.Role // "admin"
+type CustomClaims[T any] struct {
+	Claims
+	Extra T `json:"ext,omitzero"`
+}
+
+// GenerateToken generates token of typ carrying extra claims
+func GenerateToken[T any](m *Manager, typ TokenType, userID int64, phone, deviceID string, extra T, opts ...TokenOption) (string, time.Time, error) {
+	ttl := m.accessTokenTTL
+	if typ == TokenTypeRefresh {
+		ttl = m.refreshTokenTTL
+	}
+
+	claims := CustomClaims[T]{
+		Claims: m.newClaims(userID, phone, deviceID, typ, ttl, opts),
+		Extra:  extra,
+	}
+	tokenString, err := m.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, claims.ExpiresAt.Time, nil
+}
+
+// GenerateTokenPair generates access and refresh tokens carrying extra claims
+func GenerateTokenPair[T any](m *Manager, userID int64, phone, deviceID string, extra T, opts ...TokenOption) (*TokenPair, error) {
+	accessToken, expiresAt, err := GenerateToken(m, TokenTypeAccess, userID, phone, deviceID, extra, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token: %w", err)
+	}
+
+	refreshToken, _, err := GenerateToken(m, TokenTypeRefresh, userID, phone, deviceID, extra, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// Parse parses and validates a token with custom claims, like Manager.Parse
+// it doesn't check token type or revocation
+func Parse[T any](m *Manager, tokenString string) (*CustomClaims[T], error) {
+	claims := &CustomClaims[T]{}
+	if err := parseInto(tokenString, claims, m.keys.keyFunc, m.parserOpts...); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ValidateAccessToken validates access token with custom claims
+func ValidateAccessToken[T any](m *Manager, tokenString string) (*CustomClaims[T], error) {
+	return validate[T](m, tokenString, TokenTypeAccess)
+}
+
+// ValidateRefreshToken validates refresh token with custom claims
+func ValidateRefreshToken[T any](m *Manager, tokenString string) (*CustomClaims[T], error) {
+	return validate[T](m, tokenString, TokenTypeRefresh)
+}
+
+// Refresh generates new token pair carrying over extra claims of refresh
+// token, see Manager.Refresh
+func Refresh[T any](m *Manager, refreshToken string) (*TokenPair, error) {
+	claims, err := ValidateRefreshToken[T](m, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.store != nil {
+		if err := m.consumeRefreshToken(&claims.Claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return GenerateTokenPair(m, claims.UserID, claims.Phone, claims.DeviceID, claims.Extra, carryOver(&claims.Claims)...)
+}
+
+func validate[T any](m *Manager, tokenString string, typ TokenType) (*CustomClaims[T], error) {
+	claims, err := Parse[T](m, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.check(&claims.Claims, typ); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
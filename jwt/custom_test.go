@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+)
+
+type testExtra struct {
+	Role     string   `json:"role"`
+	TenantID int64    `json:"tenant_id"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+func TestCustomClaims(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	extra := testExtra{Role: "admin", TenantID: 7, Scopes: []string{"orders:read"}}
+	pair, err := GenerateTokenPair(m, 42, "+79990000000", "device-1", extra)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	claims, err := ValidateAccessToken[testExtra](m, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.UserID != 42 || claims.DeviceID != "device-1" {
+		t.Errorf("common claims = %+v", claims.Claims)
+	}
+	if claims.Extra.Role != "admin" || claims.Extra.TenantID != 7 || len(claims.Extra.Scopes) != 1 {
+		t.Errorf("Extra = %+v, want %+v", claims.Extra, extra)
+	}
+
+	// Common fields are readable without knowing the custom type
+	common, err := m.ValidateAccessToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Manager.ValidateAccessToken() error = %v", err)
+	}
+	if common.UserID != 42 {
+		t.Errorf("UserID = %d, want 42", common.UserID)
+	}
+
+	if _, err := ValidateAccessToken[testExtra](m, pair.RefreshToken); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("ValidateAccessToken(refresh) error = %v, want ErrInvalidTokenType", err)
+	}
+
+	refreshed, err := Refresh[testExtra](m, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	claims, err = ValidateAccessToken[testExtra](m, refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken(refreshed) error = %v", err)
+	}
+	if claims.Extra.Role != "admin" {
+		t.Errorf("refreshed Extra.Role = %q, want admin", claims.Extra.Role)
+	}
+}
@@ -0,0 +1,65 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+// Confirmation is the cnf claim (RFC 7800) binding token to a device
+type Confirmation struct {
+	// DeviceFingerprint - base64url SHA-256 of the device fingerprint
+	DeviceFingerprint string `json:"dfp,omitempty"`
+}
+
+// WithDeviceFingerprint binds token to device fingerprint, only its hash
+// is stored in the token. Bound tokens are checked with
+// ValidateAccessTokenForDevice and RefreshForDevice.
+func WithDeviceFingerprint(fingerprint string) TokenOption {
+	return func(c *Claims) {
+		c.Confirmation = &Confirmation{DeviceFingerprint: fingerprintHash(fingerprint)}
+	}
+}
+
+// ValidateAccessTokenForDevice validates access token and checks it is
+// bound to fingerprint, tokens without binding are rejected
+func (m *Manager) ValidateAccessTokenForDevice(tokenString, fingerprint string) (*Claims, error) {
+	claims, err := m.ValidateAccessToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDevice(claims, fingerprint); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// RefreshForDevice is Refresh accepting only refresh tokens bound to
+// fingerprint, so a stolen refresh token can't be used from another device
+func (m *Manager) RefreshForDevice(refreshToken, fingerprint string) (*TokenPair, error) {
+	claims, err := m.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDevice(claims, fingerprint); err != nil {
+		return nil, err
+	}
+	return m.Refresh(refreshToken)
+}
+
+// checkDevice compares bound fingerprint hash with fingerprint
+func checkDevice(claims *Claims, fingerprint string) error {
+	if claims.Confirmation == nil || claims.Confirmation.DeviceFingerprint == "" || fingerprint == "" {
+		return ErrDeviceMismatch
+	}
+	want := []byte(claims.Confirmation.DeviceFingerprint)
+	if subtle.ConstantTimeCompare(want, []byte(fingerprintHash(fingerprint))) != 1 {
+		return ErrDeviceMismatch
+	}
+	return nil
+}
+
+func fingerprintHash(fingerprint string) string {
+	sum := sha256.Sum256([]byte(fingerprint))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
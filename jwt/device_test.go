@@ -0,0 +1,51 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestManager_DeviceBinding(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	const fingerprint = "ios|A1B2C3|secure-enclave-key"
+	pair, err := m.GenerateTokenPair(42, "", "device-1", WithDeviceFingerprint(fingerprint))
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	claims, err := m.ValidateAccessTokenForDevice(pair.AccessToken, fingerprint)
+	if err != nil {
+		t.Fatalf("ValidateAccessTokenForDevice() error = %v", err)
+	}
+	if strings.Contains(claims.Confirmation.DeviceFingerprint, "A1B2C3") {
+		t.Error("cnf contains raw fingerprint, want hash")
+	}
+	if _, err := m.ValidateAccessTokenForDevice(pair.AccessToken, "android|other"); !errors.Is(err, ErrDeviceMismatch) {
+		t.Errorf("other device: error = %v, want ErrDeviceMismatch", err)
+	}
+	if _, err := m.RefreshForDevice(pair.RefreshToken, "android|other"); !errors.Is(err, ErrDeviceMismatch) {
+		t.Errorf("RefreshForDevice(other device) error = %v, want ErrDeviceMismatch", err)
+	}
+
+	// Binding survives refresh
+	pair, err = m.RefreshForDevice(pair.RefreshToken, fingerprint)
+	if err != nil {
+		t.Fatalf("RefreshForDevice() error = %v", err)
+	}
+	if _, err := m.ValidateAccessTokenForDevice(pair.AccessToken, fingerprint); err != nil {
+		t.Fatalf("refreshed: ValidateAccessTokenForDevice() error = %v", err)
+	}
+
+	unbound, _, err := m.GenerateAccessToken(42, "", "device-1")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	if _, err := m.ValidateAccessTokenForDevice(unbound, fingerprint); !errors.Is(err, ErrDeviceMismatch) {
+		t.Errorf("unbound token: error = %v, want ErrDeviceMismatch", err)
+	}
+}
@@ -0,0 +1,337 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// JWK is a JSON Web Key (RFC 7517) holding an RSA or EC public key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public keys of the set. HMAC keys are secret and never exported.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.Keys() {
+		jwk, ok := publicJWK(key)
+		if ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
+
+// JWKSHandler serves public keys, mount it at /.well-known/jwks.json
+func (m *Manager) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(m.keys.JWKS()); err != nil {
+			logger.Warn("failed to write JWKS", zap.Error(err))
+		}
+	})
+}
+
+func publicJWK(key Key) (JWK, bool) {
+	jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+	switch pub := key.VerifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
+
+// Key converts JWK to verification key
+func (k JWK) Key() (Key, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("decode modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("decode exponent: %w", err)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		key := NewRSAPublicKey(k.Kid, pub)
+		// RSA keys may be used with PS* algorithms too
+		switch method := jwt.GetSigningMethod(k.Alg).(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			key.Method = method
+		}
+		return key, nil
+	case "EC":
+		pub, err := k.ecdsaPublicKey()
+		if err != nil {
+			return Key{}, err
+		}
+		return NewECDSAPublicKey(k.Kid, pub)
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func (k JWK) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch k.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("decode x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("decode y: %w", err)
+	}
+
+	// Reject points not on the curve
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, errors.New("invalid EC point size")
+	}
+	if _, err := ecdhCurve.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, fmt.Errorf("invalid EC point: %w", err)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// JWKSConfig configures remote JWKS verification
+type JWKSConfig struct {
+	URL string `yaml:"url" env:"JWT_JWKS_URL"`
+	// Issuer - expected iss claim, not checked if empty
+	Issuer string `yaml:"issuer" env:"JWT_JWKS_ISSUER"`
+	// Audience - expected aud claim (any of), not checked if empty
+	Audience []string `yaml:"audience" env:"JWT_JWKS_AUDIENCE"`
+	// Leeway - allowed clock skew for exp, nbf and iat checks
+	Leeway time.Duration `yaml:"leeway" env:"JWT_JWKS_LEEWAY" env-default:"0s"`
+	// RequireType - reject tokens without typ claim, see Config.RequireType
+	RequireType bool `yaml:"require_type" env:"JWT_JWKS_REQUIRE_TYPE" env-default:"false"`
+	// RefreshInterval - keys are refetched when older
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"JWT_JWKS_REFRESH_INTERVAL" env-default:"1h"`
+	// MinRefreshInterval limits refetches caused by unknown kid
+	MinRefreshInterval time.Duration `yaml:"min_refresh_interval" env:"JWT_JWKS_MIN_REFRESH_INTERVAL" env-default:"1m"`
+	Timeout            time.Duration `yaml:"timeout" env:"JWT_JWKS_TIMEOUT" env-default:"10s"`
+}
+
+// JWKSVerifier verifies tokens with keys fetched from a remote JWKS URL.
+// Keys are cached and refetched every RefreshInterval or when a token has
+// an unknown kid (rotation at the issuer). Concurrent refetches share one
+// request and cached keys stay readable while it runs.
+type JWKSVerifier struct {
+	cfg    JWKSConfig
+	client *http.Client
+	group  singleflight.Group
+
+	keys atomic.Pointer[map[string]Key]
+	// fetchedAt - unix nanoseconds of the last fetch attempt
+	fetchedAt atomic.Int64
+}
+
+// NewJWKSVerifier creates verifier, keys are fetched on first use
+func NewJWKSVerifier(cfg JWKSConfig) (*JWKSVerifier, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("jwks url is required")
+	}
+
+	// Apply defaults if not set
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = time.Hour
+	}
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	v := &JWKSVerifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+	v.keys.Store(&map[string]Key{})
+	return v, nil
+}
+
+// Parse parses and validates a token
+func (v *JWKSVerifier) Parse(tokenString string) (*Claims, error) {
+	var opts []jwt.ParserOption
+	if v.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.cfg.Issuer))
+	}
+	if len(v.cfg.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(v.cfg.Audience...))
+	}
+	if v.cfg.Leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(v.cfg.Leeway))
+	}
+	return parseClaims(tokenString, v.Keyfunc, opts...)
+}
+
+// ValidateAccessToken validates access token
+func (v *JWKSVerifier) ValidateAccessToken(tokenString string) (*Claims, error) {
+	claims, err := v.Parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkType(claims, TokenTypeAccess, v.cfg.RequireType); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Keyfunc returns verification key for token, usable with jwt.Parse
+func (v *JWKSVerifier) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := v.key(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.VerifyKey, nil
+}
+
+// key returns cached key. Stale keys are served while they are refreshed
+// in background, a missing kid waits for the refetch.
+func (v *JWKSVerifier) key(kid string) (Key, error) {
+	key, ok := (*v.keys.Load())[kid]
+	age := time.Since(time.Unix(0, v.fetchedAt.Load()))
+	switch {
+	case ok && age > v.cfg.RefreshInterval:
+		go v.refreshShared()
+	case !ok && age > v.cfg.MinRefreshInterval:
+		v.refreshShared()
+		key, ok = (*v.keys.Load())[kid]
+	}
+	if !ok {
+		return Key{}, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// refreshShared refetches keys, concurrent callers share one fetch
+func (v *JWKSVerifier) refreshShared() {
+	_, _, _ = v.group.Do(v.cfg.URL, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), v.cfg.Timeout)
+		defer cancel()
+		if err := v.refresh(ctx); err != nil {
+			// Stale keys are better than rejecting every token
+			logger.Warn("failed to refresh JWKS", zap.String("url", v.cfg.URL), zap.Error(err))
+		}
+		return nil, nil
+	})
+}
+
+// Refresh fetches keys now
+func (v *JWKSVerifier) Refresh(ctx context.Context) error {
+	return v.refresh(ctx)
+}
+
+func (v *JWKSVerifier) refresh(ctx context.Context) error {
+	// Rate-limit failing fetches too
+	v.fetchedAt.Store(time.Now().UnixNano())
+
+	jwks, err := fetchJWKS(ctx, v.client, v.cfg.URL)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]Key, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			logger.Warn("skipping invalid JWK", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	v.keys.Store(&keys)
+	return nil
+}
+
+// fetchJWKS downloads key set from url
+func fetchJWKS(ctx context.Context, client *http.Client, url string) (*JWKS, error) {
+	var jwks JWKS
+	if err := getJSON(ctx, client, url, &jwks); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	return &jwks, nil
+}
+
+// getJSON decodes JSON response of GET url into v
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
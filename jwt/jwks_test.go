@@ -0,0 +1,143 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJWKSVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySet(NewRSAKey("rsa-1", rsaKey), NewHMACKey("hmac", []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := NewManagerWithKeys(testConfig(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jwks := keys.JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "rsa-1" {
+		t.Fatalf("JWKS() = %+v, want only the RSA public key", jwks)
+	}
+
+	server := httptest.NewServer(issuer.JWKSHandler())
+	defer server.Close()
+
+	verifier, err := NewJWKSVerifier(JWKSConfig{URL: server.URL, Issuer: "test", MinRefreshInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("NewJWKSVerifier() error = %v", err)
+	}
+
+	token, _, err := issuer.GenerateAccessToken(42, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := verifier.ValidateAccessToken(token)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.UserID != 42 {
+		t.Errorf("UserID = %d, want 42", claims.UserID)
+	}
+
+	// Unknown kid after rotation at the issuer triggers refetch
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := NewECDSAKey("ec-2", ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Rotate(next); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err = issuer.GenerateAccessToken(43, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.ValidateAccessToken(token); err != nil {
+		t.Errorf("token signed with rotated key: %v", err)
+	}
+
+	// HMAC tokens can't be verified with public keys
+	hmacManager, err := NewManager(Config{SecretKey: "secret", KeyID: "hmac", AccessTokenTTL: time.Minute, Issuer: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err = hmacManager.GenerateAccessToken(1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.ValidateAccessToken(token); err == nil {
+		t.Error("expected HMAC token to be rejected")
+	}
+}
+
+func TestJWKSVerifier_ServesCachedKeysWhileRefreshing(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySet(NewRSAKey("rsa-1", rsaKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := NewManagerWithKeys(testConfig(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every fetch after the first hangs until released
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		issuer.JWKSHandler().ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	verifier, err := NewJWKSVerifier(JWKSConfig{URL: server.URL, RefreshInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	// Keys are stale, lookups start one background refetch and don't wait for it
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := verifier.key("rsa-1"); err != nil {
+				t.Errorf("key() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(time.Second)
+	for fetches.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+}
@@ -1,11 +1,16 @@
 package jwt
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
 )
 
 // Config holds JWT configuration
@@ -14,16 +19,87 @@ type Config struct {
 	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL" env-default:"15m"`
 	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL" env-default:"720h"` // 30 days
 	Issuer          string        `yaml:"issuer" env:"JWT_ISSUER" env-default:"cg-platform"`
+	// KeyID - kid header of tokens signed with SecretKey, empty omits the header
+	KeyID string `yaml:"key_id" env:"JWT_KEY_ID"`
+	// VerificationKeys - previous HMAC secrets still accepted during rotation,
+	// "kid:secret" entries (":secret" for tokens without kid)
+	VerificationKeys []string `yaml:"verification_keys" env:"JWT_VERIFICATION_KEYS"`
+
+	// Audience - aud claim of issued tokens; validation requires any of them
+	// in the token, not checked if empty
+	Audience []string `yaml:"audience" env:"JWT_AUDIENCE"`
+	// StrictIssuer - reject tokens whose iss claim differs from Issuer
+	StrictIssuer bool `yaml:"strict_issuer" env:"JWT_STRICT_ISSUER" env-default:"false"`
+	// Leeway - allowed clock skew for exp, nbf and iat checks
+	Leeway time.Duration `yaml:"leeway" env:"JWT_LEEWAY" env-default:"0s"`
+	// RequireType - reject tokens without typ claim. Tokens issued before typ
+	// was added have none and pass as both access and refresh tokens, so
+	// existing sessions survive the upgrade. Turn it on once such tokens
+	// expire (RefreshTokenTTL after all issuers are upgraded); it becomes
+	// the default in a later release.
+	RequireType bool `yaml:"require_type" env:"JWT_REQUIRE_TYPE" env-default:"false"`
+
+	// TokenStore - optional, validation rejects revoked tokens
+	TokenStore TokenStore `yaml:"-"`
 }
 
+// TokenType distinguishes access and refresh tokens (typ claim)
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
 // Claims represents JWT claims
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Phone    string `json:"phone,omitempty"`
-	DeviceID string `json:"device_id,omitempty"`
+	UserID   int64     `json:"user_id"`
+	Phone    string    `json:"phone,omitempty"`
+	DeviceID string    `json:"device_id,omitempty"`
+	Type     TokenType `json:"typ,omitempty"`
+	Roles    []string  `json:"roles,omitempty"`
+	Scopes   []string  `json:"scopes,omitempty"`
+	// Confirmation binds token to a device, see WithDeviceFingerprint
+	Confirmation *Confirmation `json:"cnf,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// HasRole reports whether claims contain role
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasScope reports whether claims contain scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// TokenOption sets optional claims of generated tokens
+type TokenOption func(*Claims)
+
+// WithRoles sets roles claim
+func WithRoles(roles ...string) TokenOption {
+	return func(c *Claims) {
+		c.Roles = roles
+	}
+}
+
+// WithScopes sets scopes claim
+func WithScopes(scopes ...string) TokenOption {
+	return func(c *Claims) {
+		c.Scopes = scopes
+	}
+}
+
+// carryOver returns options keeping optional claims of refreshed token
+func carryOver(claims *Claims) []TokenOption {
+	return []TokenOption{
+		WithRoles(claims.Roles...),
+		WithScopes(claims.Scopes...),
+		func(c *Claims) { c.Confirmation = claims.Confirmation },
+	}
+}
+
 // TokenPair contains access and refresh tokens
 type TokenPair struct {
 	AccessToken  string    `json:"access_token"`
@@ -33,34 +109,82 @@ type TokenPair struct {
 
 // Manager handles JWT operations
 type Manager struct {
-	secretKey       []byte
+	keys            *KeySet
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
 	issuer          string
+	audience        []string
+	store           TokenStore
+	parserOpts      []jwt.ParserOption
+	// requireType - see Config.RequireType
+	requireType bool
 }
 
-// NewManager creates a new JWT manager
+// NewManager creates a new JWT manager signing with SecretKey
 func NewManager(cfg Config) (*Manager, error) {
 	if cfg.SecretKey == "" {
 		return nil, errors.New("jwt secret key is required")
 	}
 
+	others, err := parseHMACKeys(cfg.VerificationKeys)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := NewKeySet(NewHMACKey(cfg.KeyID, []byte(cfg.SecretKey)), others...)
+	if err != nil {
+		return nil, err
+	}
+	return NewManagerWithKeys(cfg, keys)
+}
+
+// NewManagerWithKeys creates a JWT manager signing with keys.Current(),
+// Config.SecretKey and VerificationKeys are ignored
+func NewManagerWithKeys(cfg Config, keys *KeySet) (*Manager, error) {
+	if keys == nil {
+		return nil, errors.New("jwt key set is required")
+	}
+
 	return &Manager{
-		secretKey:       []byte(cfg.SecretKey),
+		keys:            keys,
 		accessTokenTTL:  cfg.AccessTokenTTL,
 		refreshTokenTTL: cfg.RefreshTokenTTL,
 		issuer:          cfg.Issuer,
+		audience:        cfg.Audience,
+		store:           cfg.TokenStore,
+		parserOpts:      parserOptions(cfg),
+
+		requireType: cfg.RequireType,
 	}, nil
 }
 
+// parserOptions returns claim validation options for cfg
+func parserOptions(cfg Config) []jwt.ParserOption {
+	var opts []jwt.ParserOption
+	if len(cfg.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(cfg.Audience...))
+	}
+	if cfg.StrictIssuer {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(cfg.Leeway))
+	}
+	return opts
+}
+
+// Keys returns key set for rotation
+func (m *Manager) Keys() *KeySet {
+	return m.keys
+}
+
 // GenerateTokenPair generates access and refresh tokens
-func (m *Manager) GenerateTokenPair(userID int64, phone, deviceID string) (*TokenPair, error) {
-	accessToken, expiresAt, err := m.generateToken(userID, phone, deviceID, m.accessTokenTTL)
+func (m *Manager) GenerateTokenPair(userID int64, phone, deviceID string, opts ...TokenOption) (*TokenPair, error) {
+	accessToken, expiresAt, err := m.generateToken(userID, phone, deviceID, TokenTypeAccess, m.accessTokenTTL, opts)
 	if err != nil {
 		return nil, fmt.Errorf("generate access token: %w", err)
 	}
 
-	refreshToken, _, err := m.generateToken(userID, phone, deviceID, m.refreshTokenTTL)
+	refreshToken, _, err := m.generateToken(userID, phone, deviceID, TokenTypeRefresh, m.refreshTokenTTL, opts)
 	if err != nil {
 		return nil, fmt.Errorf("generate refresh token: %w", err)
 	}
@@ -73,80 +197,237 @@ func (m *Manager) GenerateTokenPair(userID int64, phone, deviceID string) (*Toke
 }
 
 // GenerateAccessToken generates only access token
-func (m *Manager) GenerateAccessToken(userID int64, phone, deviceID string) (string, time.Time, error) {
-	return m.generateToken(userID, phone, deviceID, m.accessTokenTTL)
+func (m *Manager) GenerateAccessToken(userID int64, phone, deviceID string, opts ...TokenOption) (string, time.Time, error) {
+	return m.generateToken(userID, phone, deviceID, TokenTypeAccess, m.accessTokenTTL, opts)
 }
 
-func (m *Manager) generateToken(userID int64, phone, deviceID string, ttl time.Duration) (string, time.Time, error) {
-	expiresAt := time.Now().Add(ttl)
+func (m *Manager) generateToken(userID int64, phone, deviceID string, typ TokenType, ttl time.Duration, opts []TokenOption) (string, time.Time, error) {
+	claims := m.newClaims(userID, phone, deviceID, typ, ttl, opts)
+	tokenString, err := m.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, claims.ExpiresAt.Time, nil
+}
 
+// newClaims returns common claims of a new token
+func (m *Manager) newClaims(userID int64, phone, deviceID string, typ TokenType, ttl time.Duration, opts []TokenOption) Claims {
+	now := time.Now()
 	claims := Claims{
 		UserID:   userID,
 		Phone:    phone,
 		DeviceID: deviceID,
+		Type:     typ,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    m.issuer,
+			Audience:  m.audience,
 		},
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(m.secretKey)
-	if err != nil {
-		return "", time.Time{}, err
+	for _, opt := range opts {
+		opt(&claims)
 	}
+	return claims
+}
 
-	return tokenString, expiresAt, nil
+// sign signs claims with current key
+func (m *Manager) sign(claims jwt.Claims) (string, error) {
+	key := m.keys.Current()
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.SignKey)
 }
 
 // Parse parses and validates a token
 func (m *Manager) Parse(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return m.secretKey, nil
-	})
+	return parseClaims(tokenString, m.keys.keyFunc, m.parserOpts...)
+}
+
+// parseClaims parses token verified with keyFunc
+func parseClaims(tokenString string, keyFunc jwt.Keyfunc, opts ...jwt.ParserOption) (*Claims, error) {
+	claims := &Claims{}
+	if err := parseInto(tokenString, claims, keyFunc, opts...); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// parseInto parses token verified with keyFunc into claims
+func parseInto(tokenString string, claims jwt.Claims, keyFunc jwt.Keyfunc, opts ...jwt.ParserOption) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc, opts...)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, ErrTokenExpired
+			return ErrTokenExpired
 		}
-		return nil, fmt.Errorf("parse token: %w", err)
+		return fmt.Errorf("parse token: %w", err)
 	}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, ErrInvalidToken
+	if !token.Valid {
+		return ErrInvalidToken
 	}
 
-	return claims, nil
+	return nil
 }
 
-// ValidateAccessToken validates access token
+// checkType rejects tokens of other type, so refresh tokens can't be used
+// as access tokens and vice versa. Unless requireType is set tokens without
+// type pass as either.
+func checkType(claims *Claims, want TokenType, requireType bool) error {
+	if claims.Type == "" && !requireType {
+		return nil
+	}
+	if claims.Type != want {
+		return fmt.Errorf("%w: %s token expected", ErrInvalidTokenType, want)
+	}
+	return nil
+}
+
+// ValidateAccessToken validates access token and checks it is not revoked
 func (m *Manager) ValidateAccessToken(tokenString string) (*Claims, error) {
-	return m.Parse(tokenString)
+	return m.validate(tokenString, TokenTypeAccess)
 }
 
-// ValidateRefreshToken validates refresh token
+// ValidateRefreshToken validates refresh token and checks it is not revoked
 func (m *Manager) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	return m.Parse(tokenString)
+	return m.validate(tokenString, TokenTypeRefresh)
+}
+
+// validate parses token, checks its type and revocation
+func (m *Manager) validate(tokenString string, typ TokenType) (*Claims, error) {
+	claims, err := m.Parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.check(claims, typ); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// check checks parsed claims type and revocation in TokenStore if configured
+func (m *Manager) check(claims *Claims, typ TokenType) error {
+	if err := checkType(claims, typ, m.requireType); err != nil {
+		return err
+	}
+	if m.store == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenStoreTimeout)
+	defer cancel()
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := m.store.IsRevoked(ctx, claims.ID, claims.UserID, issuedAt)
+	if err != nil {
+		// Fail closed: revoked tokens must not pass while the store is down
+		return fmt.Errorf("check token revocation: %w", err)
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// Revoke revokes token until it expires, e.g. on logout.
+// Expired tokens are ignored.
+func (m *Manager) Revoke(ctx context.Context, tokenString string) error {
+	if m.store == nil {
+		return errors.New("jwt token store is not configured")
+	}
+
+	claims, err := m.Parse(tokenString)
+	if errors.Is(err, ErrTokenExpired) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: jti and exp are required for revocation", ErrInvalidToken)
+	}
+
+	if err := m.store.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes all tokens of user issued so far,
+// e.g. "log out all devices"
+func (m *Manager) RevokeAllForUser(ctx context.Context, userID int64) error {
+	if m.store == nil {
+		return errors.New("jwt token store is not configured")
+	}
+
+	// Entry must outlive the longest-lived token issued before now
+	ttl := max(m.accessTokenTTL, m.refreshTokenTTL)
+	if err := m.store.RevokeAllForUser(ctx, userID, time.Now(), ttl); err != nil {
+		return fmt.Errorf("revoke user tokens: %w", err)
+	}
+	return nil
 }
 
-// Refresh generates new token pair using refresh token
+// Refresh generates new token pair using refresh token. With TokenStore
+// refresh tokens are single use: presenting a used one again means it was
+// stolen, so all tokens of the user are revoked and ErrRefreshTokenReused
+// is returned.
 func (m *Manager) Refresh(refreshToken string) (*TokenPair, error) {
 	claims, err := m.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.GenerateTokenPair(claims.UserID, claims.Phone, claims.DeviceID)
+	if m.store != nil {
+		if err := m.consumeRefreshToken(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.GenerateTokenPair(claims.UserID, claims.Phone, claims.DeviceID, carryOver(claims)...)
+}
+
+// consumeRefreshToken marks refresh token used, revoking user tokens on reuse
+func (m *Manager) consumeRefreshToken(claims *Claims) error {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenStoreTimeout)
+	defer cancel()
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: jti and exp are required for rotation", ErrInvalidToken)
+	}
+	first, err := m.store.MarkUsed(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return fmt.Errorf("mark refresh token used: %w", err)
+	}
+	if first {
+		return nil
+	}
+
+	logger.Warn("refresh token reuse detected, revoking user tokens",
+		zap.Int64("user_id", claims.UserID),
+		zap.String("device_id", claims.DeviceID),
+	)
+	if err := m.RevokeAllForUser(ctx, claims.UserID); err != nil {
+		logger.Error("failed to revoke user tokens after refresh token reuse", zap.Error(err))
+	}
+	return ErrRefreshTokenReused
 }
 
 // Errors
 var (
 	ErrTokenExpired = errors.New("token expired")
 	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenRevoked = errors.New("token revoked")
+
+	ErrInvalidTokenType   = errors.New("invalid token type")
+	ErrDeviceMismatch     = errors.New("token is bound to another device")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
 )
 
@@ -0,0 +1,155 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestManager_ValidationOptions(t *testing.T) {
+	issue := func(t *testing.T, cfg Config) string {
+		t.Helper()
+		m, err := NewManager(cfg)
+		if err != nil {
+			t.Fatalf("NewManager() error = %v", err)
+		}
+		token, _, err := m.GenerateAccessToken(42, "", "")
+		if err != nil {
+			t.Fatalf("GenerateAccessToken() error = %v", err)
+		}
+		return token
+	}
+
+	withAudience := testConfig()
+	withAudience.Audience = []string{"orders", "billing"}
+	otherIssuer := testConfig()
+	otherIssuer.Issuer = "other"
+	expired := testConfig()
+	expired.AccessTokenTTL = -5 * time.Second
+
+	tests := []struct {
+		name    string
+		issuer  Config
+		mutate  func(cfg *Config)
+		wantErr error
+	}{
+		{"audience matches any", withAudience, func(cfg *Config) { cfg.Audience = []string{"billing"} }, nil},
+		{"audience mismatch", withAudience, func(cfg *Config) { cfg.Audience = []string{"users"} }, jwt.ErrTokenInvalidAudience},
+		{"audience missing", testConfig(), func(cfg *Config) { cfg.Audience = []string{"orders"} }, jwt.ErrTokenRequiredClaimMissing},
+		{"issuer not checked", otherIssuer, func(cfg *Config) {}, nil},
+		{"strict issuer mismatch", otherIssuer, func(cfg *Config) { cfg.StrictIssuer = true }, jwt.ErrTokenInvalidIssuer},
+		{"strict issuer match", testConfig(), func(cfg *Config) { cfg.StrictIssuer = true }, nil},
+		{"expired", expired, func(cfg *Config) {}, ErrTokenExpired},
+		{"expired within leeway", expired, func(cfg *Config) { cfg.Leeway = 10 * time.Second }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := issue(t, tt.issuer)
+
+			cfg := testConfig()
+			tt.mutate(&cfg)
+			m, err := NewManager(cfg)
+			if err != nil {
+				t.Fatalf("NewManager() error = %v", err)
+			}
+
+			_, err = m.ValidateAccessToken(token)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ValidateAccessToken() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateAccessToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManager_RolesAndScopes(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	pair, err := m.GenerateTokenPair(42, "", "", WithRoles("admin"), WithScopes("orders:read", "orders:write"))
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	// Refreshed tokens keep roles and scopes
+	pair, err = m.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	claims, err := m.ValidateAccessToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if !claims.HasRole("admin") || claims.HasRole("support") {
+		t.Errorf("Roles = %v, want [admin]", claims.Roles)
+	}
+	if !claims.HasScope("orders:write") || claims.HasScope("billing:read") {
+		t.Errorf("Scopes = %v, want [orders:read orders:write]", claims.Scopes)
+	}
+}
+
+func TestManager_MissingType(t *testing.T) {
+	signer, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	// Token issued before typ was added
+	legacy, err := signer.sign(signer.newClaims(42, "", "", "", time.Minute, nil))
+	if err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+	pair, err := signer.GenerateTokenPair(42, "", "")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		requireType bool
+		token       string
+		typ         TokenType
+		wantErr     bool
+	}{
+		{"legacy as access during rollout", false, legacy, TokenTypeAccess, false},
+		{"legacy as refresh during rollout", false, legacy, TokenTypeRefresh, false},
+		{"legacy as access with type required", true, legacy, TokenTypeAccess, true},
+		{"legacy as refresh with type required", true, legacy, TokenTypeRefresh, true},
+		{"refresh as access during rollout", false, pair.RefreshToken, TokenTypeAccess, true},
+		{"access as refresh during rollout", false, pair.AccessToken, TokenTypeRefresh, true},
+		{"access with type required", true, pair.AccessToken, TokenTypeAccess, false},
+		{"refresh with type required", true, pair.RefreshToken, TokenTypeRefresh, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.RequireType = tt.requireType
+			m, err := NewManager(cfg)
+			if err != nil {
+				t.Fatalf("NewManager() error = %v", err)
+			}
+			_, err = m.validate(tt.token, tt.typ)
+			if tt.wantErr && !errors.Is(err, ErrInvalidTokenType) {
+				t.Errorf("validate() error = %v, want ErrInvalidTokenType", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validate() error = %v", err)
+			}
+		})
+	}
+
+	// Sessions started before the upgrade can still be refreshed
+	refreshed, err := signer.Refresh(legacy)
+	if err != nil {
+		t.Fatalf("Refresh() with legacy token error = %v", err)
+	}
+	if claims, err := signer.ValidateRefreshToken(refreshed.RefreshToken); err != nil || claims.Type != TokenTypeRefresh {
+		t.Errorf("refreshed token claims = %+v, %v; want refresh type", claims, err)
+	}
+}
@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a token signing key. Verification-only keys (e.g. retired or
+// third-party public keys) have nil SignKey.
+type Key struct {
+	// ID is put into the kid header, empty ID omits the header
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+}
+
+// NewHMACKey creates HS256 key
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+}
+
+// NewRSAKey creates RS256 key
+func NewRSAKey(id string, key *rsa.PrivateKey) Key {
+	return Key{ID: id, Method: jwt.SigningMethodRS256, SignKey: key, VerifyKey: &key.PublicKey}
+}
+
+// NewRSAPublicKey creates RS256 verification-only key
+func NewRSAPublicKey(id string, key *rsa.PublicKey) Key {
+	return Key{ID: id, Method: jwt.SigningMethodRS256, VerifyKey: key}
+}
+
+// NewECDSAKey creates ES256, ES384 or ES512 key depending on the curve
+func NewECDSAKey(id string, key *ecdsa.PrivateKey) (Key, error) {
+	method, err := ecdsaMethod(key.Curve)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, Method: method, SignKey: key, VerifyKey: &key.PublicKey}, nil
+}
+
+// NewECDSAPublicKey creates ECDSA verification-only key
+func NewECDSAPublicKey(id string, key *ecdsa.PublicKey) (Key, error) {
+	method, err := ecdsaMethod(key.Curve)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, Method: method, VerifyKey: key}, nil
+}
+
+func ecdsaMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384, nil
+	case elliptic.P521():
+		return jwt.SigningMethodES512, nil
+	default:
+		return nil, fmt.Errorf("unsupported ECDSA curve %s", curve.Params().Name)
+	}
+}
+
+// KeySet signs with the current key and verifies with any key in the set.
+// To rotate, Rotate to a new key and Remove the old one once tokens signed
+// with it have expired (after RefreshTokenTTL).
+type KeySet struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string]Key
+}
+
+// NewKeySet creates key set signing with current, others are verification keys
+func NewKeySet(current Key, others ...Key) (*KeySet, error) {
+	s := &KeySet{keys: make(map[string]Key, len(others)+1)}
+	for _, key := range others {
+		if err := s.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Rotate(current); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add adds verification key, replacing a key with the same ID
+func (s *KeySet) Add(key Key) error {
+	if key.Method == nil || key.VerifyKey == nil {
+		return fmt.Errorf("key %q: method and verify key are required", key.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[s.current]; ok && key.ID == s.current {
+		return fmt.Errorf("key %q is current signing key, use Rotate", key.ID)
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+// Rotate makes key the signing key, previous keys stay valid for verification
+func (s *KeySet) Rotate(key Key) error {
+	if key.Method == nil || key.SignKey == nil || key.VerifyKey == nil {
+		return fmt.Errorf("key %q: method, sign and verify keys are required", key.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	s.current = key.ID
+	return nil
+}
+
+// Remove removes retired key, tokens signed with it become invalid
+func (s *KeySet) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == s.current {
+		return fmt.Errorf("key %q is current signing key", id)
+	}
+	delete(s.keys, id)
+	return nil
+}
+
+// Current returns signing key
+func (s *KeySet) Current() Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[s.current]
+}
+
+// Key returns key by ID
+func (s *KeySet) Key(id string) (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	return key, ok
+}
+
+// Keys returns all keys sorted by ID
+func (s *KeySet) Keys() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// keyFunc selects verification key by kid header (tokens without kid use
+// the key with empty ID) and rejects tokens signed with another algorithm
+func (s *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.VerifyKey, nil
+}
+
+// parseHMACKeys parses "kid:secret" entries, ":secret" is a key without kid
+func parseHMACKeys(entries []string) ([]Key, error) {
+	keys := make([]Key, 0, len(entries))
+	for _, entry := range entries {
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || secret == "" {
+			return nil, errors.New("verification key must be in kid:secret format")
+		}
+		keys = append(keys, NewHMACKey(id, []byte(secret)))
+	}
+	return keys, nil
+}
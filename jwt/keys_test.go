@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		SecretKey:       "secret",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: time.Hour,
+		Issuer:          "test",
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	// Token issued before rotation has no kid
+	oldToken, _, err := m.GenerateAccessToken(42, "", "")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Keys().Rotate(NewRSAKey("2026-10", rsaKey)); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	newToken, _, err := m.GenerateAccessToken(42, "", "")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if claims, err := m.ValidateAccessToken(token); err != nil || claims.UserID != 42 {
+			t.Errorf("%s token: claims = %+v, err = %v", name, claims, err)
+		}
+	}
+
+	// Retiring the old key invalidates its tokens only
+	if err := m.Keys().Remove(""); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := m.ValidateAccessToken(oldToken); err == nil {
+		t.Error("expected token of removed key to be rejected")
+	}
+	if _, err := m.ValidateAccessToken(newToken); err != nil {
+		t.Errorf("new token error = %v", err)
+	}
+	if err := m.Keys().Remove("2026-10"); err == nil {
+		t.Error("expected error removing current key")
+	}
+}
+
+func TestKeySet_ECDSA(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewECDSAKey("ec", ecKey)
+	if err != nil {
+		t.Fatalf("NewECDSAKey() error = %v", err)
+	}
+	if key.Method.Alg() != "ES384" {
+		t.Errorf("method = %s, want ES384", key.Method.Alg())
+	}
+
+	keys, err := NewKeySet(key)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	m, err := NewManagerWithKeys(testConfig(), keys)
+	if err != nil {
+		t.Fatalf("NewManagerWithKeys() error = %v", err)
+	}
+
+	token, _, err := m.GenerateAccessToken(7, "", "")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	if _, err := m.ValidateAccessToken(token); err != nil {
+		t.Errorf("ValidateAccessToken() error = %v", err)
+	}
+}
+
+func TestManager_VerificationKeys(t *testing.T) {
+	oldCfg := testConfig()
+	oldCfg.SecretKey = "old-secret"
+	old, err := NewManager(oldCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := old.GenerateAccessToken(1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.KeyID = "v2"
+	cfg.VerificationKeys = []string{":old-secret"}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if _, err := m.ValidateAccessToken(token); err != nil {
+		t.Errorf("token signed with previous secret rejected: %v", err)
+	}
+
+	cfg.VerificationKeys = []string{"no-separator"}
+	if _, err := NewManager(cfg); err == nil {
+		t.Error("expected error for malformed verification key")
+	}
+}
@@ -0,0 +1,187 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gitlab.com/xakpro/cg-shared-libs/grpc"
+)
+
+// OIDCConfig configures verification of tokens issued by an external
+// OpenID Connect provider, e.g. Keycloak
+type OIDCConfig struct {
+	// IssuerURL - provider issuer, e.g. https://sso.example.com/realms/cg;
+	// must match the iss claim and the discovered issuer exactly
+	IssuerURL string `yaml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	// Audience - accepted aud values (any of), usually client IDs
+	Audience []string `yaml:"audience" env:"OIDC_AUDIENCE"`
+	// Leeway - allowed clock skew with the provider
+	Leeway time.Duration `yaml:"leeway" env:"OIDC_LEEWAY" env-default:"30s"`
+	// UserIDClaim - numeric claim mapped to AuthInfo.UserID
+	UserIDClaim string `yaml:"user_id_claim" env:"OIDC_USER_ID_CLAIM" env-default:"user_id"`
+	// RolesClaims - claims with role lists, dot separated paths into nested objects
+	RolesClaims []string `yaml:"roles_claims" env:"OIDC_ROLES_CLAIMS" env-default:"realm_access.roles"`
+	// RefreshInterval - JWKS keys are refetched when older
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"OIDC_REFRESH_INTERVAL" env-default:"1h"`
+	Timeout         time.Duration `yaml:"timeout" env:"OIDC_TIMEOUT" env-default:"10s"`
+}
+
+// oidcDiscovery is the part of provider metadata we use
+type oidcDiscovery struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// OIDCVerifier validates provider access and ID tokens and maps their
+// claims to grpc.JWTClaims, so it can back grpc.AuthInterceptor next to
+// (or instead of) Manager:
+//
+//	verifier, err := jwt.NewOIDCVerifier(ctx, cfg.OIDC)
+//	server.Use(grpc.AuthInterceptor(verifier, authCfg))
+type OIDCVerifier struct {
+	cfg        OIDCConfig
+	jwks       *JWKSVerifier
+	parserOpts []jwt.ParserOption
+}
+
+var _ grpc.JWTValidator = (*OIDCVerifier)(nil)
+
+// NewOIDCVerifier discovers provider metadata, keys are fetched on first use
+func NewOIDCVerifier(ctx context.Context, cfg OIDCConfig) (*OIDCVerifier, error) {
+	if cfg.IssuerURL == "" {
+		return nil, errors.New("oidc issuer url is required")
+	}
+	if len(cfg.Audience) == 0 {
+		return nil, errors.New("oidc audience is required")
+	}
+
+	// Apply defaults if not set
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = 30 * time.Second
+	}
+	if cfg.UserIDClaim == "" {
+		cfg.UserIDClaim = "user_id"
+	}
+	if len(cfg.RolesClaims) == 0 {
+		cfg.RolesClaims = []string{"realm_access.roles"}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	var discovery oidcDiscovery
+	url := strings.TrimSuffix(cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, &http.Client{Timeout: cfg.Timeout}, url, &discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if discovery.Issuer != cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer %q doesn't match %q", discovery.Issuer, cfg.IssuerURL)
+	}
+
+	jwks, err := NewJWKSVerifier(JWKSConfig{
+		URL:             discovery.JWKSURI,
+		RefreshInterval: cfg.RefreshInterval,
+		Timeout:         cfg.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	return &OIDCVerifier{
+		cfg:  cfg,
+		jwks: jwks,
+		parserOpts: []jwt.ParserOption{
+			jwt.WithIssuer(cfg.IssuerURL),
+			jwt.WithAudience(cfg.Audience...),
+			jwt.WithLeeway(cfg.Leeway),
+			jwt.WithExpirationRequired(),
+			jwt.WithJSONNumber(),
+		},
+	}, nil
+}
+
+// Verify validates token signature, iss, aud and exp, returns raw claims
+func (v *OIDCVerifier) Verify(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if err := parseInto(tokenString, claims, v.jwks.Keyfunc, v.parserOpts...); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ValidateAccessToken validates token and maps its claims, implements grpc.JWTValidator
+func (v *OIDCVerifier) ValidateAccessToken(tokenString string) (*grpc.JWTClaims, error) {
+	claims, err := v.Verify(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return v.mapClaims(claims)
+}
+
+// mapClaims maps provider claims to grpc.JWTClaims
+func (v *OIDCVerifier) mapClaims(claims jwt.MapClaims) (*grpc.JWTClaims, error) {
+	userID, err := int64Claim(claims, v.cfg.UserIDClaim)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	result := &grpc.JWTClaims{UserID: userID}
+	result.Phone, _ = claims["phone_number"].(string)
+	result.DeviceID, _ = claims["device_id"].(string)
+	for _, path := range v.cfg.RolesClaims {
+		result.Roles = append(result.Roles, stringsClaim(claimPath(claims, path))...)
+	}
+	// scope is space-delimited (RFC 8693), some providers use scp array
+	if scope, ok := claims["scope"].(string); ok {
+		result.Scopes = strings.Fields(scope)
+	} else {
+		result.Scopes = stringsClaim(claims["scp"])
+	}
+	return result, nil
+}
+
+// claimPath returns value at dot separated path, e.g. realm_access.roles
+func claimPath(claims map[string]any, path string) any {
+	var value any = claims
+	for name := range strings.SplitSeq(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// int64Claim parses numeric or numeric string claim
+func int64Claim(claims jwt.MapClaims, name string) (int64, error) {
+	switch value := claims[name].(type) {
+	case json.Number:
+		return value.Int64()
+	case string:
+		return strconv.ParseInt(value, 10, 64)
+	case nil:
+		return 0, fmt.Errorf("%s claim is missing", name)
+	default:
+		return 0, fmt.Errorf("%s claim has unexpected type %T", name, value)
+	}
+}
+
+// stringsClaim returns string elements of array claim
+func stringsClaim(value any) []string {
+	values, _ := value.([]any)
+	var result []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestOIDCVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySet(NewRSAKey("kc-1", rsaKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	issuer := server.URL + "/realms/cg"
+	mux.HandleFunc("/realms/cg/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcDiscovery{Issuer: issuer, JWKSURI: server.URL + "/realms/cg/certs"})
+	})
+	mux.Handle("/realms/cg/certs", (&Manager{keys: keys}).JWKSHandler())
+
+	verifier, err := NewOIDCVerifier(context.Background(), OIDCConfig{IssuerURL: issuer, Audience: []string{"orders"}})
+	if err != nil {
+		t.Fatalf("NewOIDCVerifier() error = %v", err)
+	}
+
+	sign := func(t *testing.T, mutate func(jwt.MapClaims)) string {
+		t.Helper()
+		claims := jwt.MapClaims{
+			"iss":          issuer,
+			"aud":          []string{"orders", "account"},
+			"sub":          "0b7f6c1e-5d0a-4d5e-9a43-1f0c2b8e7a11",
+			"exp":          time.Now().Add(time.Minute).Unix(),
+			"user_id":      "9007199254740993",
+			"phone_number": "+79990000000",
+			"realm_access": map[string]any{"roles": []string{"admin", "support"}},
+			"scope":        "openid orders:read",
+		}
+		mutate(claims)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "kc-1"
+		signed, err := token.SignedString(rsaKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	claims, err := verifier.ValidateAccessToken(sign(t, func(jwt.MapClaims) {}))
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.UserID != 9007199254740993 || claims.Phone != "+79990000000" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if !slices.Equal(claims.Roles, []string{"admin", "support"}) {
+		t.Errorf("Roles = %v, want [admin support]", claims.Roles)
+	}
+	if !slices.Equal(claims.Scopes, []string{"openid", "orders:read"}) {
+		t.Errorf("Scopes = %v, want [openid orders:read]", claims.Scopes)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(jwt.MapClaims)
+		wantErr error
+	}{
+		{"numeric user id", func(c jwt.MapClaims) { c["user_id"] = 42 }, nil},
+		{"other audience", func(c jwt.MapClaims) { c["aud"] = "billing" }, jwt.ErrTokenInvalidAudience},
+		{"other issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, jwt.ErrTokenInvalidIssuer},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, ErrTokenExpired},
+		{"expired within leeway", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Second).Unix() }, nil},
+		{"no exp", func(c jwt.MapClaims) { delete(c, "exp") }, jwt.ErrTokenRequiredClaimMissing},
+		{"no user id", func(c jwt.MapClaims) { delete(c, "user_id") }, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.ValidateAccessToken(sign(t, tt.mutate))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ValidateAccessToken() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateAccessToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
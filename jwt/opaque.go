@@ -0,0 +1,232 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/grpc"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+)
+
+// OpaqueConfig holds opaque token configuration
+type OpaqueConfig struct {
+	// Prefix is prepended to all keys
+	Prefix          string        `yaml:"prefix" env:"JWT_OPAQUE_PREFIX" env-default:"token:"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL" env-default:"720h"` // 30 days
+}
+
+// OpaqueManager issues random tokens with claims kept in Redis, so every
+// token can be revoked server-side at once. It implements grpc.JWTValidator
+// and is a drop-in replacement for Manager behind grpc.AuthInterceptor.
+// Only token hashes are stored.
+type OpaqueManager struct {
+	client *redis.Client
+	cfg    OpaqueConfig
+}
+
+var _ grpc.JWTValidator = (*OpaqueManager)(nil)
+
+// NewOpaqueManager creates opaque token manager
+func NewOpaqueManager(client *redis.Client, cfg OpaqueConfig) *OpaqueManager {
+	// Apply defaults if not set
+	if cfg.Prefix == "" {
+		cfg.Prefix = "token:"
+	}
+	if cfg.AccessTokenTTL <= 0 {
+		cfg.AccessTokenTTL = 15 * time.Minute
+	}
+	if cfg.RefreshTokenTTL <= 0 {
+		cfg.RefreshTokenTTL = 720 * time.Hour
+	}
+
+	return &OpaqueManager{client: client, cfg: cfg}
+}
+
+// GenerateTokenPair generates access and refresh tokens
+func (m *OpaqueManager) GenerateTokenPair(ctx context.Context, userID int64, phone, deviceID string, opts ...TokenOption) (*TokenPair, error) {
+	accessToken, expiresAt, err := m.generateToken(ctx, userID, phone, deviceID, TokenTypeAccess, m.cfg.AccessTokenTTL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token: %w", err)
+	}
+
+	refreshToken, _, err := m.generateToken(ctx, userID, phone, deviceID, TokenTypeRefresh, m.cfg.RefreshTokenTTL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+func (m *OpaqueManager) generateToken(ctx context.Context, userID int64, phone, deviceID string, typ TokenType, ttl time.Duration, opts []TokenOption) (string, time.Time, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, fmt.Errorf("generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	hash := tokenHash(token)
+
+	now := time.Now()
+	claims := Claims{
+		UserID:   userID,
+		Phone:    phone,
+		DeviceID: deviceID,
+		Type:     typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hash,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	for _, opt := range opts {
+		opt(&claims)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("marshal claims: %w", err)
+	}
+
+	userKey := m.userKey(userID)
+	_, err = m.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Set(ctx, m.tokenKey(hash), payload, ttl)
+		pipe.SAdd(ctx, userKey, hash)
+		// Index lives as long as the longest-lived token
+		pipe.Expire(ctx, userKey, m.cfg.RefreshTokenTTL)
+		return nil
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("store token: %w", err)
+	}
+	return token, claims.ExpiresAt.Time, nil
+}
+
+// Validate looks up token claims checking token type
+func (m *OpaqueManager) Validate(ctx context.Context, token string, typ TokenType) (*Claims, error) {
+	payload, err := m.client.Get(ctx, m.tokenKey(tokenHash(token))).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get token: %w", err)
+	}
+	return decodeOpaqueClaims(payload, typ)
+}
+
+// ValidateAccessToken validates access token, implements grpc.JWTValidator
+func (m *OpaqueManager) ValidateAccessToken(token string) (*grpc.JWTClaims, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenStoreTimeout)
+	defer cancel()
+
+	claims, err := m.Validate(ctx, token, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.JWTClaims{
+		UserID:   claims.UserID,
+		Phone:    claims.Phone,
+		DeviceID: claims.DeviceID,
+		Roles:    claims.Roles,
+		Scopes:   claims.Scopes,
+	}, nil
+}
+
+// Refresh generates new token pair, refresh token is consumed atomically
+// so it can be used only once
+func (m *OpaqueManager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := m.Validate(ctx, refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one of concurrent refreshes with the same token deletes it
+	hash := tokenHash(refreshToken)
+	deleted, err := m.client.Del(ctx, m.tokenKey(hash)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("consume refresh token: %w", err)
+	}
+	if deleted == 0 {
+		return nil, ErrInvalidToken
+	}
+	// Stale index entries are harmless, so the error is ignored
+	m.client.SRem(ctx, m.userKey(claims.UserID), hash)
+
+	return m.GenerateTokenPair(ctx, claims.UserID, claims.Phone, claims.DeviceID, carryOver(claims)...)
+}
+
+// Revoke deletes token, e.g. on logout
+func (m *OpaqueManager) Revoke(ctx context.Context, token string) error {
+	hash := tokenHash(token)
+	payload, err := m.client.GetDel(ctx, m.tokenKey(hash)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err == nil {
+		m.client.SRem(ctx, m.userKey(claims.UserID), hash)
+	}
+	return nil
+}
+
+// RevokeAllForUser deletes all tokens of user, e.g. "log out all devices"
+func (m *OpaqueManager) RevokeAllForUser(ctx context.Context, userID int64) error {
+	userKey := m.userKey(userID)
+	hashes, err := m.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return fmt.Errorf("list user tokens: %w", err)
+	}
+
+	keys := make([]string, 0, len(hashes)+1)
+	for _, hash := range hashes {
+		keys = append(keys, m.tokenKey(hash))
+	}
+	keys = append(keys, userKey)
+	if err := m.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+// decodeOpaqueClaims unmarshals stored claims and checks token type
+func decodeOpaqueClaims(payload []byte, typ TokenType) (*Claims, error) {
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("unmarshal claims: %w", err)
+	}
+	// Opaque tokens always had a type
+	if err := checkType(&claims, typ, true); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (m *OpaqueManager) tokenKey(hash string) string {
+	return m.cfg.Prefix + "t:" + hash
+}
+
+func (m *OpaqueManager) userKey(userID int64) string {
+	return m.cfg.Prefix + "u:" + strconv.FormatInt(userID, 10)
+}
+
+// tokenHash returns hex SHA-256 of token, tokens are random so no salt is needed
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
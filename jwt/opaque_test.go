@@ -0,0 +1,70 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOpaqueManager(t *testing.T) {
+	ctx := context.Background()
+	_, store := newTestStore(t)
+	m := NewOpaqueManager(store.client, OpaqueConfig{})
+
+	pair, err := m.GenerateTokenPair(ctx, 42, "+79990000000", "device-1", WithRoles("admin"))
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	claims, err := m.ValidateAccessToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.UserID != 42 || claims.DeviceID != "device-1" || len(claims.Roles) != 1 {
+		t.Errorf("claims = %+v", claims)
+	}
+	if _, err := m.ValidateAccessToken(pair.RefreshToken); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("ValidateAccessToken(refresh) error = %v, want ErrInvalidTokenType", err)
+	}
+	if _, err := m.Refresh(ctx, pair.AccessToken); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("Refresh(access) error = %v, want ErrInvalidTokenType", err)
+	}
+	if _, err := m.ValidateAccessToken("unknown"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateAccessToken(unknown) error = %v, want ErrInvalidToken", err)
+	}
+
+	// Refresh tokens are single use
+	refreshed, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if _, err := m.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("second Refresh() error = %v, want ErrInvalidToken", err)
+	}
+	if claims, err := m.ValidateAccessToken(refreshed.AccessToken); err != nil || len(claims.Roles) != 1 {
+		t.Fatalf("refreshed: ValidateAccessToken() = %+v, %v", claims, err)
+	}
+
+	if err := m.Revoke(ctx, refreshed.AccessToken); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := m.ValidateAccessToken(refreshed.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("revoked: ValidateAccessToken() error = %v, want ErrInvalidToken", err)
+	}
+
+	other, err := m.GenerateTokenPair(ctx, 42, "", "device-2")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	if err := m.RevokeAllForUser(ctx, 42); err != nil {
+		t.Fatalf("RevokeAllForUser() error = %v", err)
+	}
+	for _, token := range []string{pair.AccessToken, other.AccessToken} {
+		if _, err := m.ValidateAccessToken(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("after RevokeAllForUser: ValidateAccessToken() error = %v, want ErrInvalidToken", err)
+		}
+	}
+	if _, err := m.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("after RevokeAllForUser: Refresh() error = %v, want ErrInvalidToken", err)
+	}
+}
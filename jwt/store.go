@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+)
+
+// tokenStoreTimeout bounds revocation checks during validation
+const tokenStoreTimeout = time.Second
+
+// TokenStore keeps revoked token IDs (jti) and per-user revocation times
+type TokenStore interface {
+	// Revoke revokes token until it expires
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeAllForUser revokes user tokens issued before revokedAt, ttl is
+	// the longest token lifetime
+	RevokeAllForUser(ctx context.Context, userID int64, revokedAt time.Time, ttl time.Duration) error
+	// IsRevoked reports whether token is revoked by ID or by user revocation
+	IsRevoked(ctx context.Context, jti string, userID int64, issuedAt time.Time) (bool, error)
+	// MarkUsed marks refresh token used until it expires, reports whether
+	// it is the first use
+	MarkUsed(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+}
+
+// RedisTokenStore is TokenStore backed by Redis, entries expire with the tokens
+type RedisTokenStore struct {
+	client *redis.Client
+	prefix string
+}
+
+var _ TokenStore = (*RedisTokenStore)(nil)
+
+// NewRedisTokenStore creates token store, prefix defaults to "jwt:"
+func NewRedisTokenStore(client *redis.Client, prefix string) *RedisTokenStore {
+	// Apply defaults if not set
+	if prefix == "" {
+		prefix = "jwt:"
+	}
+	return &RedisTokenStore{client: client, prefix: prefix}
+}
+
+func (s *RedisTokenStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.tokenKey(jti), 1, ttl).Err()
+}
+
+func (s *RedisTokenStore) RevokeAllForUser(ctx context.Context, userID int64, revokedAt time.Time, ttl time.Duration) error {
+	return s.client.Set(ctx, s.userKey(userID), revokedAt.Unix(), ttl).Err()
+}
+
+func (s *RedisTokenStore) IsRevoked(ctx context.Context, jti string, userID int64, issuedAt time.Time) (bool, error) {
+	var revoked *goredis.IntCmd
+	var revokedBefore *goredis.StringCmd
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		revoked = pipe.Exists(ctx, s.tokenKey(jti))
+		revokedBefore = pipe.Get(ctx, s.userKey(userID))
+		return nil
+	})
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return false, err
+	}
+
+	if revoked.Val() > 0 {
+		return true, nil
+	}
+	if before, err := strconv.ParseInt(revokedBefore.Val(), 10, 64); err == nil {
+		// iat has second precision, tokens issued in the revocation second are revoked too
+		return issuedAt.Unix() <= before, nil
+	}
+	return false, nil
+}
+
+func (s *RedisTokenStore) MarkUsed(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	// Keep the mark at least a second so near-expiry reuse is still detected
+	ttl := max(time.Until(expiresAt), time.Second)
+	return s.client.SetNX(ctx, s.prefix+"used:"+jti, 1, ttl).Result()
+}
+
+func (s *RedisTokenStore) tokenKey(jti string) string {
+	return s.prefix + "revoked:" + jti
+}
+
+func (s *RedisTokenStore) userKey(userID int64) string {
+	return s.prefix + "revoked_user:" + strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,174 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+)
+
+func newTestStore(t *testing.T) (*miniredis.Miniredis, *RedisTokenStore) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := &redis.Client{Client: goredis.NewClient(&goredis.Options{Addr: srv.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	return srv, NewRedisTokenStore(client, "")
+}
+
+func TestManager_Revoke(t *testing.T) {
+	ctx := context.Background()
+	_, store := newTestStore(t)
+	cfg := testConfig()
+	cfg.TokenStore = store
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	pair, err := m.GenerateTokenPair(42, "", "device-1")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	other, err := m.GenerateTokenPair(42, "", "device-2")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	if err := m.Revoke(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := m.ValidateRefreshToken(pair.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("ValidateRefreshToken() error = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := m.ValidateAccessToken(pair.AccessToken); err != nil {
+		t.Fatalf("access token of revoked refresh token: ValidateAccessToken() error = %v", err)
+	}
+	if _, err := m.ValidateRefreshToken(other.RefreshToken); err != nil {
+		t.Fatalf("other device: ValidateRefreshToken() error = %v", err)
+	}
+
+	if err := m.RevokeAllForUser(ctx, 42); err != nil {
+		t.Fatalf("RevokeAllForUser() error = %v", err)
+	}
+	for _, token := range []string{pair.AccessToken, other.AccessToken} {
+		if _, err := m.ValidateAccessToken(token); !errors.Is(err, ErrTokenRevoked) {
+			t.Fatalf("after RevokeAllForUser: ValidateAccessToken() error = %v, want ErrTokenRevoked", err)
+		}
+	}
+	if _, err := m.ValidateRefreshToken(other.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("after RevokeAllForUser: ValidateRefreshToken() error = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestManager_RevokeStoreDown(t *testing.T) {
+	srv, store := newTestStore(t)
+	cfg := testConfig()
+	cfg.TokenStore = store
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	token, _, err := m.GenerateAccessToken(42, "", "")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	srv.Close()
+	if _, err := m.ValidateAccessToken(token); err == nil {
+		t.Fatal("ValidateAccessToken() succeeded with store down, want error")
+	}
+}
+
+func TestRedisTokenStore_RevokeAllForUser(t *testing.T) {
+	ctx := context.Background()
+	srv, store := newTestStore(t)
+	revokedAt := time.Unix(1_700_000_000, 0)
+
+	if err := store.RevokeAllForUser(ctx, 7, revokedAt, time.Hour); err != nil {
+		t.Fatalf("RevokeAllForUser() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   int64
+		issuedAt time.Time
+		want     bool
+	}{
+		{"issued before", 7, revokedAt.Add(-time.Minute), true},
+		{"issued same second", 7, revokedAt, true},
+		{"issued after", 7, revokedAt.Add(time.Second), false},
+		{"other user", 8, revokedAt.Add(-time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.IsRevoked(ctx, "jti", tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Entry expires with the longest-lived token
+	srv.FastForward(time.Hour)
+	if got, _ := store.IsRevoked(ctx, "jti", 7, revokedAt.Add(-time.Minute)); got {
+		t.Error("IsRevoked() = true after ttl, want false")
+	}
+}
+
+func TestManager_TokenTypes(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	pair, err := m.GenerateTokenPair(42, "", "")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	if _, err := m.ValidateAccessToken(pair.RefreshToken); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("ValidateAccessToken(refresh) error = %v, want ErrInvalidTokenType", err)
+	}
+	if _, err := m.ValidateRefreshToken(pair.AccessToken); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("ValidateRefreshToken(access) error = %v, want ErrInvalidTokenType", err)
+	}
+	if _, err := m.Refresh(pair.AccessToken); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("Refresh(access) error = %v, want ErrInvalidTokenType", err)
+	}
+}
+
+func TestManager_RefreshReuse(t *testing.T) {
+	_, store := newTestStore(t)
+	cfg := testConfig()
+	cfg.TokenStore = store
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	pair, err := m.GenerateTokenPair(42, "", "")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	rotated, err := m.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if rotated.RefreshToken == pair.RefreshToken {
+		t.Fatal("Refresh() returned the same refresh token")
+	}
+
+	// Replaying the old refresh token revokes the whole session
+	if _, err := m.Refresh(pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("second Refresh() error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := m.Refresh(rotated.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("Refresh(rotated) after reuse error = %v, want ErrTokenRevoked", err)
+	}
+}
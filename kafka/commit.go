@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// Commit modes
+const (
+	// CommitAtLeastOnce commits after the handler succeeds; failed
+	// messages are redelivered
+	CommitAtLeastOnce = "at_least_once"
+	// CommitAtMostOnce commits before the handler runs; failed
+	// messages are dropped
+	CommitAtMostOnce = "at_most_once"
+	// CommitManual never commits automatically; the handler commits
+	// with the CommitFunc from CommitFromContext or ConsumeManual
+	CommitManual = "manual"
+)
+
+// CommitFunc commits the message being handled
+type CommitFunc func(ctx context.Context) error
+
+// ManualHandler handles messages in manual commit mode
+type ManualHandler func(ctx context.Context, msg kafka.Message, commit CommitFunc) error
+
+type commitContextKey struct{}
+
+// CommitFromContext returns commit func of the message being handled in
+// manual commit mode, or nil in other modes
+func CommitFromContext(ctx context.Context) CommitFunc {
+	commit, _ := ctx.Value(commitContextKey{}).(CommitFunc)
+	return commit
+}
+
+// ConsumeManual consumes messages passing commit func to handler.
+// Config.CommitMode must be manual.
+func (c *Consumer) ConsumeManual(ctx context.Context, handler ManualHandler) error {
+	return c.Consume(ctx, func(ctx context.Context, msg kafka.Message) error {
+		return handler(ctx, msg, CommitFromContext(ctx))
+	})
+}
+
+// commitMode returns normalized commit mode, at-least-once by default
+func (c *Consumer) commitMode() string {
+	switch mode := strings.ToLower(c.cfg.CommitMode); mode {
+	case CommitAtMostOnce, CommitManual:
+		return mode
+	default:
+		return CommitAtLeastOnce
+	}
+}
+
+// handle processes message committing it according to commit mode
+func (c *Consumer) handle(ctx context.Context, handler MessageHandler, msg kafka.Message) {
+	switch c.commitMode() {
+	case CommitAtMostOnce:
+		if err := c.commit(ctx, msg); err != nil {
+			logger.Error("commit message failed, skipping message",
+				zap.Error(err),
+				zap.String("topic", c.topic),
+				zap.Int64("offset", msg.Offset),
+			)
+			return
+		}
+		if err := c.process(ctx, handler, msg); err != nil {
+			logger.Error("handle message failed, message dropped",
+				zap.Error(err),
+				zap.String("topic", c.topic),
+				zap.Int64("offset", msg.Offset),
+			)
+		}
+
+	case CommitManual:
+		if err := c.process(c.withCommit(ctx, msg), handler, msg); err != nil {
+			logger.Error("handle message failed",
+				zap.Error(err),
+				zap.String("topic", c.topic),
+				zap.Int64("offset", msg.Offset),
+			)
+		}
+
+	default:
+		if err := c.process(ctx, handler, msg); err != nil {
+			logger.Error("handle message failed",
+				zap.Error(err),
+				zap.String("topic", c.topic),
+				zap.Int64("offset", msg.Offset),
+			)
+			// Don't commit on error - message will be reprocessed
+			return
+		}
+
+		if err := c.commit(ctx, msg); err != nil {
+			logger.Error("commit message failed", zap.Error(err))
+		}
+	}
+}
+
+// withCommit stores commit func for msg in context
+func (c *Consumer) withCommit(ctx context.Context, msg kafka.Message) context.Context {
+	return context.WithValue(ctx, commitContextKey{}, CommitFunc(func(ctx context.Context) error {
+		return c.commit(ctx, msg)
+	}))
+}
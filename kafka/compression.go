@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// Supported compression codecs
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+	CompressionLz4    = "lz4"
+	CompressionZstd   = "zstd"
+)
+
+// Supported required acks
+const (
+	AcksNone = "none"
+	AcksOne  = "one"
+	AcksAll  = "all"
+)
+
+// compression returns kafka-go compression codec
+func (c *Config) compression() (kafka.Compression, error) {
+	switch strings.ToLower(c.Compression) {
+	case "", CompressionNone:
+		return 0, nil
+	case CompressionGzip:
+		return kafka.Gzip, nil
+	case CompressionSnappy:
+		return kafka.Snappy, nil
+	case CompressionLz4:
+		return kafka.Lz4, nil
+	case CompressionZstd:
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported compression %q", c.Compression)
+	}
+}
+
+// requiredAcks returns kafka-go required acks
+func (c *Config) requiredAcks() (kafka.RequiredAcks, error) {
+	switch strings.ToLower(c.RequiredAcks) {
+	case "", AcksNone:
+		return kafka.RequireNone, nil
+	case AcksOne:
+		return kafka.RequireOne, nil
+	case AcksAll:
+		return kafka.RequireAll, nil
+	default:
+		return kafka.RequireNone, fmt.Errorf("unsupported required acks %q", c.RequiredAcks)
+	}
+}
+
+// configureWriter applies compression and required acks to writer.
+// Invalid values are logged and left at writer defaults.
+func (c *Config) configureWriter(w *kafka.Writer) {
+	compression, err := c.compression()
+	if err != nil {
+		logger.Warn("invalid Kafka compression, sending uncompressed", zap.Error(err))
+	}
+	w.Compression = compression
+
+	acks, err := c.requiredAcks()
+	if err != nil {
+		logger.Warn("invalid Kafka required acks, using none", zap.Error(err))
+	}
+	w.RequiredAcks = acks
+}
+
+// kgoCompression returns franz-go compression codec
+func (c *Config) kgoCompression() (kgo.CompressionCodec, error) {
+	switch strings.ToLower(c.Compression) {
+	case "", CompressionNone:
+		return kgo.NoCompression(), nil
+	case CompressionGzip:
+		return kgo.GzipCompression(), nil
+	case CompressionSnappy:
+		return kgo.SnappyCompression(), nil
+	case CompressionLz4:
+		return kgo.Lz4Compression(), nil
+	case CompressionZstd:
+		return kgo.ZstdCompression(), nil
+	default:
+		return kgo.NoCompression(), fmt.Errorf("unsupported compression %q", c.Compression)
+	}
+}
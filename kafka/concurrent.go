@@ -0,0 +1,358 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// OrderingMode defines which messages ConcurrentConsume processes sequentially
+type OrderingMode int
+
+const (
+	// OrderByPartition processes messages of the same partition in order
+	OrderByPartition OrderingMode = iota
+	// OrderByKey processes messages with the same key in order,
+	// so one partition can be processed by several workers
+	OrderByKey
+)
+
+// ConcurrentConfig configures ConcurrentConsume
+type ConcurrentConfig struct {
+	Workers  int
+	Ordering OrderingMode
+	// MaxAttempts - handler attempts per message in at-least-once mode (default 3).
+	// When all attempts fail ConcurrentConsume stops with the handler error.
+	MaxAttempts int
+	// RetryBackoff - delay before the second attempt, doubled for each next one (default 1s)
+	RetryBackoff time.Duration
+	// MaxInFlight - fetched but not yet committed messages per partition in
+	// at-least-once mode, fetching waits when reached (default 1000)
+	MaxInFlight int
+}
+
+// ConcurrentConsume consumes messages with a pool of workers.
+// Messages with the same partition (or key) are always handled by the same
+// worker in fetch order. In at-least-once mode offsets are committed only up
+// to the last message that was processed successfully together with all
+// messages before it. A failed message is retried in place up to MaxAttempts
+// times, after that ConcurrentConsume returns the handler error and the
+// message is redelivered after restart; wrap handler with RetryRouter.Wrap
+// to move such messages to retry topics instead. In at-most-once mode
+// messages are committed before dispatch. Shutdown drains queued messages
+// before the reader is closed.
+func (c *Consumer) ConcurrentConsume(ctx context.Context, cfg ConcurrentConfig, handler MessageHandler) error {
+	fetchCtx, done, err := c.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	// Apply defaults if not set
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Second
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 1000
+	}
+
+	// A worker that gives up on a message cancels fetching with its error
+	fetchCtx, fail := context.WithCancelCause(fetchCtx)
+	defer fail(nil)
+
+	mode := c.commitMode()
+	tracker := newOffsetTracker(cfg.MaxInFlight)
+	queues := make([]chan trackedMessage, cfg.Workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan trackedMessage, 1)
+		wg.Add(1)
+		go func(queue <-chan trackedMessage) {
+			defer wg.Done()
+			for tm := range queue {
+				if err := c.handleConcurrent(ctx, mode, cfg, tracker, handler, tm); err != nil {
+					fail(err)
+				}
+			}
+		}(queues[i])
+	}
+
+	logger.Info("Kafka concurrent consumer started",
+		zap.String("topic", c.topic),
+		zap.Int("workers", cfg.Workers),
+	)
+
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+
+	// stopErr returns the error ConcurrentConsume exits with once fetchCtx is done
+	stopErr := func() error {
+		if cause := context.Cause(fetchCtx); errors.Is(cause, errHandlerFailed) {
+			return cause
+		}
+		if c.isStopping() {
+			return nil
+		}
+		return ctx.Err()
+	}
+
+	for {
+		msg, err := c.reader.FetchMessage(fetchCtx)
+		if err != nil {
+			if fetchCtx.Err() != nil {
+				return stopErr()
+			}
+			logger.Error("fetch message failed", zap.Error(err))
+			continue
+		}
+
+		tm := trackedMessage{msg: msg}
+		switch mode {
+		case CommitAtMostOnce:
+			if err := c.commit(ctx, msg); err != nil {
+				logger.Error("commit message failed, skipping message",
+					zap.Error(err),
+					zap.Int("partition", msg.Partition),
+					zap.Int64("offset", msg.Offset),
+				)
+				continue
+			}
+		case CommitAtLeastOnce:
+			if tm.generation, err = tracker.add(fetchCtx, msg); err != nil {
+				return stopErr()
+			}
+		}
+
+		select {
+		case queues[workerIndex(msg, cfg.Ordering, cfg.Workers)] <- tm:
+		case <-fetchCtx.Done():
+			return stopErr()
+		}
+	}
+}
+
+// errHandlerFailed marks errors of messages that failed all attempts
+var errHandlerFailed = errors.New("kafka handler failed")
+
+// trackedMessage is a fetched message with its offsetTracker generation
+type trackedMessage struct {
+	msg        kafka.Message
+	generation uint64
+}
+
+// handleConcurrent runs handler and, in at-least-once mode, retries failed
+// message and commits offsets that became safe to commit. It returns error
+// only when the message failed all attempts.
+func (c *Consumer) handleConcurrent(ctx context.Context, mode string, cfg ConcurrentConfig, tracker *offsetTracker, handler MessageHandler, tm trackedMessage) error {
+	msg := tm.msg
+	if mode == CommitManual {
+		ctx = c.withCommit(ctx, msg)
+	}
+
+	attempts := 1
+	if mode == CommitAtLeastOnce {
+		attempts = cfg.MaxAttempts
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 && !sleepContext(ctx, cfg.RetryBackoff<<(attempt-2)) {
+			// Shutting down, the message is redelivered after restart
+			return nil
+		}
+		if err = c.process(ctx, handler, msg); err == nil {
+			break
+		}
+		logger.Error("handle message failed",
+			zap.Error(err),
+			zap.String("topic", c.topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.Int("attempt", attempt),
+		)
+	}
+	if mode != CommitAtLeastOnce {
+		return nil
+	}
+	if err != nil {
+		// Don't mark as done - the message is redelivered after restart
+		return fmt.Errorf("%w: partition %d offset %d: %w", errHandlerFailed, msg.Partition, msg.Offset, err)
+	}
+
+	commit, ok := tracker.done(tm)
+	if !ok {
+		return nil
+	}
+	err = tracker.commit(tm.generation, commit, func(msg kafka.Message) error {
+		return c.commit(ctx, msg)
+	})
+	if err != nil {
+		logger.Error("commit message failed",
+			zap.Error(err),
+			zap.Int("partition", commit.Partition),
+			zap.Int64("offset", commit.Offset),
+		)
+	}
+	return nil
+}
+
+// sleepContext waits for d, returns false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// workerIndex picks worker for message according to ordering mode
+func workerIndex(msg kafka.Message, ordering OrderingMode, workers int) int {
+	if ordering == OrderByKey && len(msg.Key) > 0 {
+		h := fnv.New32a()
+		_, _ = h.Write(msg.Key)
+		return int(h.Sum32() % uint32(workers))
+	}
+	return msg.Partition % workers
+}
+
+// offsetTracker tracks fetched and processed offsets per partition and reports
+// the highest offset below which all messages are processed
+type offsetTracker struct {
+	maxInFlight int
+
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+	changed    chan struct{} // closed and replaced when offsets are released
+}
+
+type partitionOffsets struct {
+	generation uint64
+	pending    []int64        // fetched offsets in fetch order
+	done       map[int64]bool // processed offsets not yet committable
+
+	// commitMu serializes commits, committed is the last offset committed
+	// in committedGeneration
+	commitMu            sync.Mutex
+	committedGeneration uint64
+	committed           int64
+}
+
+func newOffsetTracker(maxInFlight int) *offsetTracker {
+	return &offsetTracker{
+		maxInFlight: maxInFlight,
+		partitions:  make(map[int]*partitionOffsets),
+		changed:     make(chan struct{}),
+	}
+}
+
+// add registers fetched message and returns its partition generation.
+// It waits while the partition has maxInFlight pending offsets. An offset
+// not after the last fetched one means the partition was reassigned and the
+// reader rewound, so the partition state is reset and messages fetched
+// before are ignored by done.
+func (t *offsetTracker) add(ctx context.Context, msg kafka.Message) (uint64, error) {
+	for {
+		t.mu.Lock()
+		p, ok := t.partitions[msg.Partition]
+		if !ok {
+			p = &partitionOffsets{done: make(map[int64]bool), committed: -1}
+			t.partitions[msg.Partition] = p
+		}
+		if n := len(p.pending); n > 0 && msg.Offset <= p.pending[n-1] {
+			logger.Info("Kafka partition offset rewound, resetting tracked offsets",
+				zap.String("topic", msg.Topic),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset),
+			)
+			p.generation++
+			p.pending = nil
+			clear(p.done)
+		}
+		if len(p.pending) < t.maxInFlight {
+			p.pending = append(p.pending, msg.Offset)
+			generation := p.generation
+			t.mu.Unlock()
+			return generation, nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// done marks message processed and returns message to commit if the
+// committable offset advanced
+func (t *offsetTracker) done(tm trackedMessage) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	msg := tm.msg
+	p, ok := t.partitions[msg.Partition]
+	if !ok || p.generation != tm.generation {
+		return kafka.Message{}, false
+	}
+	p.done[msg.Offset] = true
+
+	committable := int64(-1)
+	for len(p.pending) > 0 && p.done[p.pending[0]] {
+		committable = p.pending[0]
+		delete(p.done, committable)
+		p.pending = p.pending[1:]
+	}
+	if committable < 0 {
+		return kafka.Message{}, false
+	}
+
+	close(t.changed)
+	t.changed = make(chan struct{})
+	return kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: committable}, true
+}
+
+// commit calls commitFn with message returned by done. Workers finish in any
+// order, so commits of a partition are serialized and an offset not above
+// the last committed one in the same generation is skipped, otherwise the
+// committed offset could go back.
+func (t *offsetTracker) commit(generation uint64, msg kafka.Message, commitFn func(kafka.Message) error) error {
+	t.mu.Lock()
+	p, ok := t.partitions[msg.Partition]
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	p.commitMu.Lock()
+	defer p.commitMu.Unlock()
+	if generation < p.committedGeneration ||
+		generation == p.committedGeneration && msg.Offset <= p.committed {
+		return nil
+	}
+	if err := commitFn(msg); err != nil {
+		return err
+	}
+	p.committedGeneration, p.committed = generation, msg.Offset
+	return nil
+}
@@ -0,0 +1,195 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// trackMessages adds offsets from..to of partition 0 to tracker
+func trackMessages(t *testing.T, tracker *offsetTracker, from, to int64) []trackedMessage {
+	t.Helper()
+	var msgs []trackedMessage
+	for offset := from; offset <= to; offset++ {
+		msg := kafka.Message{Topic: "events", Partition: 0, Offset: offset}
+		generation, err := tracker.add(context.Background(), msg)
+		if err != nil {
+			t.Fatalf("add offset %d: %v", offset, err)
+		}
+		msgs = append(msgs, trackedMessage{msg: msg, generation: generation})
+	}
+	return msgs
+}
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker(100)
+	msgs := trackMessages(t, tracker, 10, 13)
+
+	// Out of order completion must not advance past unprocessed offsets
+	if _, ok := tracker.done(msgs[1]); ok {
+		t.Fatal("offset 11 must not be committable before 10")
+	}
+	if _, ok := tracker.done(msgs[3]); ok {
+		t.Fatal("offset 13 must not be committable before 10 and 12")
+	}
+
+	commit, ok := tracker.done(msgs[0])
+	if !ok || commit.Offset != 11 {
+		t.Fatalf("commit = %d, %v; want 11", commit.Offset, ok)
+	}
+
+	commit, ok = tracker.done(msgs[2])
+	if !ok || commit.Offset != 13 {
+		t.Fatalf("commit = %d, %v; want 13", commit.Offset, ok)
+	}
+}
+
+func TestOffsetTracker_MaxInFlight(t *testing.T) {
+	tracker := newOffsetTracker(2)
+	msgs := trackMessages(t, tracker, 10, 11)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := tracker.add(ctx, kafka.Message{Partition: 0, Offset: 12}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("add over limit = %v, want deadline exceeded", err)
+	}
+
+	added := make(chan error, 1)
+	go func() {
+		_, err := tracker.add(context.Background(), kafka.Message{Partition: 0, Offset: 12})
+		added <- err
+	}()
+	if _, ok := tracker.done(msgs[0]); !ok {
+		t.Fatal("offset 10 must be committable")
+	}
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatalf("add after release: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("add must resume once an offset is committable")
+	}
+
+	// Other partitions are not limited
+	if _, err := tracker.add(ctx, kafka.Message{Partition: 1, Offset: 0}); err != nil {
+		t.Fatalf("add to other partition: %v", err)
+	}
+}
+
+func TestOffsetTracker_Rewind(t *testing.T) {
+	tracker := newOffsetTracker(100)
+	old := trackMessages(t, tracker, 10, 11)
+
+	// Reassigned partition is fetched again from the committed offset
+	fresh := trackMessages(t, tracker, 10, 10)
+	if fresh[0].generation == old[0].generation {
+		t.Fatal("rewind must start a new generation")
+	}
+	if _, ok := tracker.done(old[0]); ok {
+		t.Fatal("messages fetched before rewind must be ignored")
+	}
+	if commit, ok := tracker.done(fresh[0]); !ok || commit.Offset != 10 {
+		t.Fatalf("commit = %d, %v; want 10", commit.Offset, ok)
+	}
+}
+
+func TestOffsetTracker_Commit(t *testing.T) {
+	tracker := newOffsetTracker(100)
+	old := trackMessages(t, tracker, 10, 12)
+
+	var committed []int64
+	commitFn := func(msg kafka.Message) error {
+		committed = append(committed, msg.Offset)
+		return nil
+	}
+	commit := func(tm trackedMessage) {
+		t.Helper()
+		if err := tracker.commit(tm.generation, tm.msg, commitFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A worker that computed offset 11 commits after one that computed 12
+	commit(old[2])
+	commit(old[1])
+	if len(committed) != 1 || committed[0] != 12 {
+		t.Fatalf("committed = %v, want [12]", committed)
+	}
+
+	// Failed commit is not remembered, so the offset is committed later
+	next := trackedMessage{msg: kafka.Message{Partition: 0, Offset: 13}, generation: old[2].generation}
+	if err := tracker.commit(next.generation, next.msg, func(kafka.Message) error {
+		return errors.New("broker down")
+	}); err == nil {
+		t.Fatal("commit error must be returned")
+	}
+	commit(next)
+
+	// Lower offsets are committed again after the partition is rewound,
+	// commits of the previous generation are skipped
+	fresh := trackMessages(t, tracker, 5, 5)
+	commit(fresh[0])
+	commit(old[2])
+	if want := []int64{12, 13, 5}; !slices.Equal(committed, want) {
+		t.Errorf("committed = %v, want %v", committed, want)
+	}
+}
+
+func TestHandleConcurrent_FailureMidPartition(t *testing.T) {
+	c := &Consumer{topic: "events"}
+	cfg := ConcurrentConfig{MaxAttempts: 3, RetryBackoff: time.Millisecond}
+	tracker := newOffsetTracker(100)
+	msgs := trackMessages(t, tracker, 10, 13)
+
+	handleErr := errors.New("boom")
+	attempts := 0
+	handler := func(ctx context.Context, msg kafka.Message) error {
+		if msg.Offset == 12 {
+			attempts++
+			return handleErr
+		}
+		return nil
+	}
+
+	for _, tm := range msgs[1:] {
+		err := c.handleConcurrent(context.Background(), CommitAtLeastOnce, cfg, tracker, handler, tm)
+		if tm.msg.Offset == 12 {
+			if !errors.Is(err, errHandlerFailed) || !errors.Is(err, handleErr) {
+				t.Fatalf("handle offset 12 = %v, want handler failure", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("handle offset %d: %v", tm.msg.Offset, err)
+		}
+	}
+	if attempts != cfg.MaxAttempts {
+		t.Errorf("attempts = %d, want %d", attempts, cfg.MaxAttempts)
+	}
+
+	// Commits stop before the failed message
+	commit, ok := tracker.done(msgs[0])
+	if !ok || commit.Offset != 11 {
+		t.Fatalf("commit = %d, %v; want 11", commit.Offset, ok)
+	}
+	if pending := tracker.partitions[0].pending; len(pending) != 2 || pending[0] != 12 {
+		t.Errorf("pending = %v, want [12 13]", pending)
+	}
+}
+
+func TestWorkerIndex(t *testing.T) {
+	a := kafka.Message{Partition: 1, Key: []byte("user-1")}
+	b := kafka.Message{Partition: 2, Key: []byte("user-1")}
+
+	if workerIndex(a, OrderByKey, 8) != workerIndex(b, OrderByKey, 8) {
+		t.Error("same key must go to the same worker")
+	}
+	if got := workerIndex(b, OrderByPartition, 8); got != 2 {
+		t.Errorf("workerIndex by partition = %d, want 2", got)
+	}
+}
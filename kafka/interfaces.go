@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// EventPublisher publishes domain events
+type EventPublisher interface {
+	Publish(ctx context.Context, key string, event Event) error
+	PublishBatch(ctx context.Context, events []Event) error
+}
+
+// EventConsumer consumes domain events
+type EventConsumer interface {
+	ConsumeEvent(ctx context.Context, handler func(ctx context.Context, event Event) error) error
+}
+
+// Publisher publishes messages to a topic.
+// Implemented by Producer and kafkatest.Producer.
+type Publisher interface {
+	EventPublisher
+	PublishJSON(ctx context.Context, key string, data any) error
+	PublishMessages(ctx context.Context, msgs ...kafka.Message) error
+	Topic() string
+	Close() error
+}
+
+// Subscriber consumes messages from a topic.
+// Implemented by Consumer and kafkatest.Consumer.
+type Subscriber interface {
+	EventConsumer
+	Consume(ctx context.Context, handler MessageHandler) error
+	Close() error
+}
+
+var (
+	_ Publisher  = (*Producer)(nil)
+	_ Subscriber = (*Consumer)(nil)
+)
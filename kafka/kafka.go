@@ -3,13 +3,18 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 // FlexibleTime is a time.Time wrapper that can unmarshal from multiple formats:
@@ -71,6 +76,21 @@ type Config struct {
 	CommitTimeout time.Duration `yaml:"commit_timeout" env:"KAFKA_COMMIT_TIMEOUT" env-default:"5s"`
 	BatchSize     int           `yaml:"batch_size" env:"KAFKA_BATCH_SIZE" env-default:"100"`
 	BatchTimeout  time.Duration `yaml:"batch_timeout" env:"KAFKA_BATCH_TIMEOUT" env-default:"100ms"`
+	// Compression - none, gzip, snappy, lz4 or zstd
+	Compression string `yaml:"compression" env:"KAFKA_COMPRESSION" env-default:"none"`
+	// RequiredAcks - none, one or all
+	RequiredAcks string `yaml:"required_acks" env:"KAFKA_REQUIRED_ACKS" env-default:"none"`
+	// CommitMode - at_least_once, at_most_once or manual
+	CommitMode string `yaml:"commit_mode" env:"KAFKA_COMMIT_MODE" env-default:"at_least_once"`
+
+	SASL SASLConfig `yaml:"sasl"`
+	TLS  TLSConfig  `yaml:"tls"`
+
+	// SchemaRegistry - used by NewSchemaRegistry for Avro/Protobuf serializers
+	SchemaRegistry SchemaRegistryConfig `yaml:"schema_registry"`
+
+	// Metrics - optional, records consumer lag, throughput, commit failures and producer writes
+	Metrics *metrics.Metrics `yaml:"-"`
 }
 
 // Event represents a domain event
@@ -92,8 +112,11 @@ type Metadata struct {
 
 // Producer wraps kafka.Writer
 type Producer struct {
-	writer *kafka.Writer
-	topic  string
+	writer  *kafka.Writer
+	topic   string
+	metrics *metrics.Metrics
+
+	unregisterStats func()
 }
 
 // NewProducer creates a new Kafka producer
@@ -105,17 +128,35 @@ func NewProducer(cfg Config, topic string) *Producer {
 		BatchSize:    cfg.BatchSize,
 		BatchTimeout: cfg.BatchTimeout,
 		Async:        false,
+		Transport:    cfg.transport(),
 	}
+	cfg.configureWriter(writer)
 
 	logger.Info("Kafka producer created",
 		zap.Strings("brokers", cfg.Brokers),
 		zap.String("topic", topic),
+		zap.String("sasl_mechanism", cfg.SASL.Mechanism),
+		zap.Bool("tls", cfg.TLS.Enabled),
+		zap.String("compression", cfg.Compression),
 	)
 
-	return &Producer{
-		writer: writer,
-		topic:  topic,
+	p := &Producer{
+		writer:  writer,
+		topic:   topic,
+		metrics: cfg.Metrics,
+	}
+	if cfg.Metrics != nil {
+		var err error
+		if p.unregisterStats, err = cfg.Metrics.RegisterKafkaWriter(writer); err != nil {
+			logger.Warn("Kafka writer metrics registration failed", zap.String("topic", topic), zap.Error(err))
+		}
 	}
+	return p
+}
+
+// Topic returns producer topic
+func (p *Producer) Topic() string {
+	return p.topic
 }
 
 // Publish publishes an event to Kafka
@@ -131,7 +172,7 @@ func (p *Producer) Publish(ctx context.Context, key string, event Event) error {
 		Time:  time.Now(),
 	}
 
-	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+	if err := p.write(ctx, msg); err != nil {
 		return fmt.Errorf("write message: %w", err)
 	}
 
@@ -157,11 +198,66 @@ func (p *Producer) PublishJSON(ctx context.Context, key string, data any) error
 		Time:  time.Now(),
 	}
 
-	return p.writer.WriteMessages(ctx, msg)
+	return p.write(ctx, msg)
+}
+
+// PublishBatch publishes events in a single write, keyed by event ID.
+// Use PublishMessages to set custom keys or headers.
+func (p *Producer) PublishBatch(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	msgs := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event %s: %w", event.ID, err)
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(event.ID),
+			Value: data,
+			Time:  now,
+		})
+	}
+
+	return p.PublishMessages(ctx, msgs...)
+}
+
+// PublishMessages writes messages in a single write
+func (p *Producer) PublishMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	if err := p.write(ctx, msgs...); err != nil {
+		return fmt.Errorf("write messages: %w", err)
+	}
+
+	logger.Debug("Messages published",
+		zap.String("topic", p.topic),
+		zap.Int("count", len(msgs)),
+	)
+
+	return nil
+}
+
+// write writes messages and records producer metrics
+func (p *Producer) write(ctx context.Context, msgs ...kafka.Message) error {
+	start := time.Now()
+	err := p.writer.WriteMessages(ctx, msgs...)
+	if p.metrics != nil {
+		p.metrics.RecordKafkaWrite(p.topic, len(msgs), time.Since(start), err)
+	}
+	return err
 }
 
 // Close closes the producer
 func (p *Producer) Close() error {
+	if p.unregisterStats != nil {
+		p.unregisterStats()
+	}
 	if p.writer != nil {
 		logger.Info("Kafka producer closed", zap.String("topic", p.topic))
 		return p.writer.Close()
@@ -169,10 +265,21 @@ func (p *Producer) Close() error {
 	return nil
 }
 
+// ErrConsumerStopped is returned when consuming after Shutdown
+var ErrConsumerStopped = errors.New("kafka consumer stopped")
+
 // Consumer wraps kafka.Reader
 type Consumer struct {
 	reader *kafka.Reader
 	topic  string
+	cfg    Config
+
+	mu       sync.Mutex
+	stopped  bool
+	stopping chan struct{}  // closed by Shutdown
+	running  sync.WaitGroup // running Consume/ConcurrentConsume loops
+
+	unregisterStats func()
 }
 
 // NewConsumer creates a new Kafka consumer
@@ -184,6 +291,7 @@ func NewConsumer(cfg Config, topic string) *Consumer {
 		MinBytes: cfg.MinBytes,
 		MaxBytes: cfg.MaxBytes,
 		MaxWait:  cfg.MaxWait,
+		Dialer:   cfg.dialer(),
 	})
 
 	logger.Info("Kafka consumer created",
@@ -192,46 +300,117 @@ func NewConsumer(cfg Config, topic string) *Consumer {
 		zap.String("group_id", cfg.GroupID),
 	)
 
-	return &Consumer{
-		reader: reader,
-		topic:  topic,
+	c := &Consumer{
+		reader:   reader,
+		topic:    topic,
+		cfg:      cfg,
+		stopping: make(chan struct{}),
+	}
+	if mode := c.commitMode(); cfg.CommitMode != "" && mode != strings.ToLower(cfg.CommitMode) {
+		logger.Warn("unknown Kafka commit mode, using at_least_once", zap.String("commit_mode", cfg.CommitMode))
+	}
+	if cfg.Metrics != nil {
+		var err error
+		if c.unregisterStats, err = cfg.Metrics.RegisterKafkaReader(reader); err != nil {
+			logger.Warn("Kafka reader metrics registration failed", zap.String("topic", topic), zap.Error(err))
+		}
 	}
+	return c
 }
 
 // MessageHandler handles consumed messages
 type MessageHandler func(ctx context.Context, msg kafka.Message) error
 
-// Consume starts consuming messages
+// Consume starts consuming messages committing them according to
+// Config.CommitMode. It returns nil after Shutdown.
 func (c *Consumer) Consume(ctx context.Context, handler MessageHandler) error {
+	fetchCtx, done, err := c.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			msg, err := c.reader.FetchMessage(ctx)
-			if err != nil {
-				if ctx.Err() != nil {
-					return ctx.Err()
-				}
-				logger.Error("fetch message failed", zap.Error(err))
-				continue
+		msg, err := c.reader.FetchMessage(fetchCtx)
+		if err != nil {
+			if c.isStopping() {
+				return nil
 			}
-
-			if err := handler(ctx, msg); err != nil {
-				logger.Error("handle message failed",
-					zap.Error(err),
-					zap.String("topic", c.topic),
-					zap.Int64("offset", msg.Offset),
-				)
-				// Don't commit on error - message will be reprocessed
-				continue
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
+			logger.Error("fetch message failed", zap.Error(err))
+			continue
+		}
 
-			if err := c.reader.CommitMessages(ctx, msg); err != nil {
-				logger.Error("commit message failed", zap.Error(err))
-			}
+		c.handle(ctx, handler, msg)
+	}
+}
+
+// start registers running consume loop and returns context for fetching,
+// cancelled by Shutdown. Handlers keep the caller context so in-flight
+// messages can finish.
+func (c *Consumer) start(ctx context.Context) (context.Context, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return nil, nil, ErrConsumerStopped
+	}
+	c.running.Add(1)
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-c.stopping:
+			cancel()
+		case <-fetchCtx.Done():
+		}
+	}()
+
+	return fetchCtx, func() {
+		cancel()
+		c.running.Done()
+	}, nil
+}
+
+// isStopping reports whether Shutdown was called
+func (c *Consumer) isStopping() bool {
+	select {
+	case <-c.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// process runs handler and records consumer metrics
+func (c *Consumer) process(ctx context.Context, handler MessageHandler, msg kafka.Message) error {
+	start := time.Now()
+	err := handler(ctx, msg)
+	if c.cfg.Metrics != nil {
+		c.cfg.Metrics.RecordKafkaMessage(c.topic, err == nil, time.Since(start))
+		if msg.HighWaterMark > 0 {
+			c.cfg.Metrics.RecordKafkaLag(c.topic, msg.Partition, msg.HighWaterMark-msg.Offset-1)
 		}
 	}
+	return err
+}
+
+// commit commits message offset. Commit runs even if ctx is cancelled,
+// so processed messages are not redelivered on shutdown.
+func (c *Consumer) commit(ctx context.Context, msgs ...kafka.Message) error {
+	timeout := c.cfg.CommitTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	err := c.reader.CommitMessages(ctx, msgs...)
+	if err != nil && c.cfg.Metrics != nil {
+		c.cfg.Metrics.RecordKafkaCommitFailure(c.topic)
+	}
+	return err
 }
 
 // ConsumeEvent consumes and parses events
@@ -245,8 +424,44 @@ func (c *Consumer) ConsumeEvent(ctx context.Context, handler func(ctx context.Co
 	})
 }
 
+// Shutdown stops fetching new messages, waits for running handlers to finish
+// and commit their offsets, then closes the reader. If ctx expires first the
+// reader is closed anyway and uncommitted messages are redelivered later.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.stopped {
+		c.stopped = true
+		close(c.stopping)
+	}
+	c.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.running.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		logger.Warn("Kafka consumer shutdown timed out, closing with in-flight messages",
+			zap.String("topic", c.topic),
+		)
+		err = fmt.Errorf("wait for in-flight messages: %w", ctx.Err())
+	}
+
+	if closeErr := c.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("close consumer: %w", closeErr)
+	}
+	return err
+}
+
 // Close closes the consumer
 func (c *Consumer) Close() error {
+	if c.unregisterStats != nil {
+		c.unregisterStats()
+	}
 	if c.reader != nil {
 		logger.Info("Kafka consumer closed", zap.String("topic", c.topic))
 		return c.reader.Close()
@@ -254,45 +469,144 @@ func (c *Consumer) Close() error {
 	return nil
 }
 
-// MultiConsumer consumes from multiple topics
+// MultiConsumer consumes from multiple topics with per-topic handlers
 type MultiConsumer struct {
-	consumers []*Consumer
+	cfg Config
+
+	mu        sync.Mutex
+	topics    []string
+	consumers map[string]*Consumer
+	handlers  map[string]MessageHandler
+	cancel    context.CancelFunc
+	closed    bool
 }
 
 // NewMultiConsumer creates a consumer for multiple topics
 func NewMultiConsumer(cfg Config, topics []string) *MultiConsumer {
-	consumers := make([]*Consumer, 0, len(topics))
+	mc := &MultiConsumer{
+		cfg:       cfg,
+		consumers: make(map[string]*Consumer),
+		handlers:  make(map[string]MessageHandler),
+	}
 	for _, topic := range topics {
-		consumers = append(consumers, NewConsumer(cfg, topic))
+		mc.addTopic(topic)
 	}
-	return &MultiConsumer{consumers: consumers}
+	return mc
 }
 
-// ConsumeAll starts consuming from all topics
+// HandleTopic registers handler for topic, subscribing to the topic if needed.
+// It must be called before ConsumeAll.
+func (mc *MultiConsumer) HandleTopic(topic string, handler MessageHandler) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.addTopic(topic)
+	mc.handlers[topic] = handler
+}
+
+// addTopic creates consumer for topic unless it exists
+func (mc *MultiConsumer) addTopic(topic string) {
+	if _, ok := mc.consumers[topic]; ok {
+		return
+	}
+	mc.topics = append(mc.topics, topic)
+	mc.consumers[topic] = NewConsumer(mc.cfg, topic)
+}
+
+// ConsumeAll starts consuming from all topics. handler is used for topics
+// without a HandleTopic handler and may be nil if every topic has one.
+// The first consumer error stops all other consumers, and ConsumeAll returns
+// only after all of them exit. It returns nil after Shutdown or Close.
 func (mc *MultiConsumer) ConsumeAll(ctx context.Context, handler MessageHandler) error {
-	errCh := make(chan error, len(mc.consumers))
+	mc.mu.Lock()
+	if mc.closed {
+		mc.mu.Unlock()
+		return ErrConsumerStopped
+	}
 
-	for _, c := range mc.consumers {
-		go func(consumer *Consumer) {
-			errCh <- consumer.Consume(ctx, handler)
-		}(c)
+	handlers := make(map[string]MessageHandler, len(mc.topics))
+	for _, topic := range mc.topics {
+		h := mc.handlers[topic]
+		if h == nil {
+			h = handler
+		}
+		if h == nil {
+			mc.mu.Unlock()
+			return fmt.Errorf("no handler for topic %s", topic)
+		}
+		handlers[topic] = h
 	}
 
-	// Wait for first error or context cancellation
-	select {
-	case err := <-errCh:
-		return err
-	case <-ctx.Done():
-		return ctx.Err()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	mc.cancel = cancel
+
+	g, gctx := errgroup.WithContext(ctx)
+	for _, topic := range mc.topics {
+		consumer := mc.consumers[topic]
+		g.Go(func() error {
+			if err := consumer.Consume(gctx, handlers[topic]); err != nil {
+				return fmt.Errorf("consume %s: %w", topic, err)
+			}
+			return nil
+		})
+	}
+	mc.mu.Unlock()
+
+	err := g.Wait()
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.closed {
+		return nil
 	}
+	return err
 }
 
-// Close closes all consumers
+// Shutdown gracefully stops all consumers, see Consumer.Shutdown
+func (mc *MultiConsumer) Shutdown(ctx context.Context) error {
+	consumers, _ := mc.stop()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(consumers))
+	for i, c := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Close stops consuming and closes all consumers without waiting for
+// in-flight messages
 func (mc *MultiConsumer) Close() error {
-	for _, c := range mc.consumers {
+	consumers, cancel := mc.stop()
+	if cancel != nil {
+		cancel()
+	}
+
+	var errs []error
+	for _, c := range consumers {
 		if err := c.Close(); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
+}
+
+// stop marks MultiConsumer closed and returns its consumers and the
+// cancel func of running ConsumeAll
+func (mc *MultiConsumer) stop() ([]*Consumer, context.CancelFunc) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.closed = true
+
+	consumers := make([]*Consumer, 0, len(mc.topics))
+	for _, topic := range mc.topics {
+		consumers = append(consumers, mc.consumers[topic])
+	}
+	return consumers, mc.cancel
 }
@@ -0,0 +1,302 @@
+// Package kafkatest provides in-memory Kafka producer and consumer for unit tests
+package kafkatest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"gitlab.com/xakpro/cg-shared-libs/kafka"
+)
+
+// ErrClosed is returned when publishing to a closed producer
+var ErrClosed = errors.New("kafkatest: closed")
+
+var (
+	_ kafka.Publisher  = (*Producer)(nil)
+	_ kafka.Subscriber = (*Consumer)(nil)
+)
+
+// Broker stores published messages per topic. Producers and consumers
+// created from the same broker share topics; every consumer reads
+// a topic from the beginning, like a separate consumer group.
+type Broker struct {
+	mu       sync.Mutex
+	topics   map[string][]kafkago.Message
+	appended chan struct{} // closed and replaced on every publish
+}
+
+// NewBroker creates an empty in-memory broker
+func NewBroker() *Broker {
+	return &Broker{
+		topics:   make(map[string][]kafkago.Message),
+		appended: make(chan struct{}),
+	}
+}
+
+// Messages returns copy of messages published to topic
+func (b *Broker) Messages(topic string) []kafkago.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]kafkago.Message(nil), b.topics[topic]...)
+}
+
+// Events returns events published to topic
+func (b *Broker) Events(topic string) ([]kafka.Event, error) {
+	msgs := b.Messages(topic)
+	events := make([]kafka.Event, 0, len(msgs))
+	for _, msg := range msgs {
+		var event kafka.Event
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return nil, fmt.Errorf("unmarshal event at offset %d: %w", msg.Offset, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Reset removes all messages
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topics = make(map[string][]kafkago.Message)
+}
+
+// append stores messages assigning topic and offsets
+func (b *Broker) append(topic string, msgs ...kafkago.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for _, msg := range msgs {
+		msg.Topic = topic
+		msg.Offset = int64(len(b.topics[topic]))
+		msg.HighWaterMark = msg.Offset + 1
+		if msg.Time.IsZero() {
+			msg.Time = now
+		}
+		b.topics[topic] = append(b.topics[topic], msg)
+	}
+
+	close(b.appended)
+	b.appended = make(chan struct{})
+}
+
+// next returns message at offset or channel closed on the next publish
+func (b *Broker) next(topic string, offset int) (kafkago.Message, bool, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msgs := b.topics[topic]
+	if offset < len(msgs) {
+		msg := msgs[offset]
+		msg.HighWaterMark = int64(len(msgs))
+		return msg, true, nil
+	}
+	return kafkago.Message{}, false, b.appended
+}
+
+// Producer is an in-memory kafka.Publisher
+type Producer struct {
+	broker *Broker
+	topic  string
+
+	mu     sync.Mutex
+	closed bool
+	err    error
+}
+
+// Producer creates producer publishing to topic
+func (b *Broker) Producer(topic string) *Producer {
+	return &Producer{broker: b, topic: topic}
+}
+
+// Topic returns producer topic
+func (p *Producer) Topic() string {
+	return p.topic
+}
+
+// FailWith makes subsequent publishes return err; nil restores publishing
+func (p *Producer) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Messages returns messages published to producer topic
+func (p *Producer) Messages() []kafkago.Message {
+	return p.broker.Messages(p.topic)
+}
+
+// Publish publishes an event
+func (p *Producer) Publish(ctx context.Context, key string, event kafka.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return p.PublishMessages(ctx, kafkago.Message{Key: []byte(key), Value: data})
+}
+
+// PublishJSON publishes a JSON message
+func (p *Producer) PublishJSON(ctx context.Context, key string, data any) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal data: %w", err)
+	}
+	return p.PublishMessages(ctx, kafkago.Message{Key: []byte(key), Value: value})
+}
+
+// PublishBatch publishes events keyed by event ID
+func (p *Producer) PublishBatch(ctx context.Context, events []kafka.Event) error {
+	msgs := make([]kafkago.Message, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event %s: %w", event.ID, err)
+		}
+		msgs = append(msgs, kafkago.Message{Key: []byte(event.ID), Value: data})
+	}
+	return p.PublishMessages(ctx, msgs...)
+}
+
+// PublishMessages publishes messages
+func (p *Producer) PublishMessages(ctx context.Context, msgs ...kafkago.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	if p.err != nil {
+		return p.err
+	}
+
+	p.broker.append(p.topic, msgs...)
+	return nil
+}
+
+// Close closes the producer
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Consumer is an in-memory kafka.Subscriber. Failed messages are not
+// redelivered; they are available from Failed.
+type Consumer struct {
+	broker *Broker
+	topic  string
+	closed chan struct{}
+	once   sync.Once
+
+	mu     sync.Mutex
+	offset int
+	failed []kafkago.Message
+}
+
+// Consumer creates consumer reading topic from the beginning
+func (b *Broker) Consumer(topic string) *Consumer {
+	return &Consumer{broker: b, topic: topic, closed: make(chan struct{})}
+}
+
+// Consume handles messages until ctx is cancelled or the consumer is closed.
+// It returns nil after Close.
+func (c *Consumer) Consume(ctx context.Context, handler kafka.MessageHandler) error {
+	for {
+		select {
+		case <-c.closed:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		appended := c.handleNext(ctx, handler)
+		if appended == nil {
+			continue
+		}
+
+		select {
+		case <-appended:
+		case <-c.closed:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ConsumeEvent consumes and parses events
+func (c *Consumer) ConsumeEvent(ctx context.Context, handler func(ctx context.Context, event kafka.Event) error) error {
+	return c.Consume(ctx, func(ctx context.Context, msg kafkago.Message) error {
+		var event kafka.Event
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return fmt.Errorf("unmarshal event: %w", err)
+		}
+		return handler(ctx, event)
+	})
+}
+
+// Drain handles all messages published so far and returns.
+// It is a synchronous alternative to running Consume in a goroutine.
+func (c *Consumer) Drain(ctx context.Context, handler kafka.MessageHandler) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if appended := c.handleNext(ctx, handler); appended != nil {
+			return nil
+		}
+	}
+}
+
+// handleNext handles the next message. If there is none it returns
+// a channel closed on the next publish.
+func (c *Consumer) handleNext(ctx context.Context, handler kafka.MessageHandler) <-chan struct{} {
+	c.mu.Lock()
+	offset := c.offset
+	c.mu.Unlock()
+
+	msg, ok, appended := c.broker.next(c.topic, offset)
+	if !ok {
+		return appended
+	}
+
+	err := handler(ctx, msg)
+
+	c.mu.Lock()
+	c.offset++
+	if err != nil {
+		c.failed = append(c.failed, msg)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// Offset returns number of handled messages
+func (c *Consumer) Offset() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// Failed returns messages the handler returned an error for
+func (c *Consumer) Failed() []kafkago.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]kafkago.Message(nil), c.failed...)
+}
+
+// Close stops Consume
+func (c *Consumer) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
@@ -0,0 +1,85 @@
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"gitlab.com/xakpro/cg-shared-libs/kafka"
+)
+
+func TestProducerConsumer(t *testing.T) {
+	ctx := context.Background()
+	broker := NewBroker()
+	producer := broker.Producer("orders")
+
+	if err := producer.Publish(ctx, "1", kafka.Event{ID: "1", Type: "created"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.PublishJSON(ctx, "2", map[string]string{"id": "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	consumer := broker.Consumer("orders")
+	var keys []string
+	err := consumer.Drain(ctx, func(_ context.Context, msg kafkago.Message) error {
+		keys = append(keys, string(msg.Key))
+		if string(msg.Key) == "2" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || consumer.Offset() != 2 || len(consumer.Failed()) != 1 {
+		t.Errorf("keys = %v, offset = %d, failed = %d", keys, consumer.Offset(), len(consumer.Failed()))
+	}
+}
+
+func TestConsumeWaitsForMessages(t *testing.T) {
+	broker := NewBroker()
+	consumer := broker.Consumer("orders")
+
+	received := make(chan kafka.Event, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.ConsumeEvent(context.Background(), func(_ context.Context, event kafka.Event) error {
+			received <- event
+			return nil
+		})
+	}()
+
+	if err := broker.Producer("orders").PublishBatch(context.Background(), []kafka.Event{{ID: "42"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-received:
+		if event.ID != "42" {
+			t.Errorf("event ID = %q", event.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not consumed")
+	}
+
+	_ = consumer.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Consume after Close = %v", err)
+	}
+}
+
+func TestProducerFailWith(t *testing.T) {
+	producer := NewBroker().Producer("orders")
+	boom := errors.New("broker down")
+	producer.FailWith(boom)
+
+	if err := producer.PublishJSON(context.Background(), "k", 1); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want %v", err, boom)
+	}
+	if len(producer.Messages()) != 0 {
+		t.Error("message stored despite failure")
+	}
+}
@@ -0,0 +1,230 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// Headers set on messages republished to retry topics
+const (
+	HeaderRetryAttempt   = "x-retry-attempt"
+	HeaderRetryNotBefore = "x-retry-not-before" // unix milliseconds
+	HeaderOriginalTopic  = "x-original-topic"
+	HeaderRetryError     = "x-retry-error"
+)
+
+// RetryConfig holds retry topics configuration
+type RetryConfig struct {
+	// Delays - delay of each retry tier, a topic "<topic>.retry.<delay>" is used per tier
+	Delays []time.Duration `yaml:"delays"`
+	// DeadLetterTopic - topic for messages that failed all retries (default "<topic>.dlq")
+	DeadLetterTopic string `yaml:"dead_letter_topic"`
+}
+
+// defaultRetryDelays are used when RetryConfig.Delays is empty
+var defaultRetryDelays = []time.Duration{time.Minute, 10 * time.Minute, time.Hour}
+
+// RetryTopicName returns retry tier topic name, e.g. "orders.retry.10m"
+func RetryTopicName(topic string, delay time.Duration) string {
+	name := delay.String()
+	// "10m0s" -> "10m", "1h0m0s" -> "1h"
+	if strings.HasSuffix(name, "m0s") {
+		name = strings.TrimSuffix(name, "0s")
+	}
+	if strings.HasSuffix(name, "h0m") {
+		name = strings.TrimSuffix(name, "0m")
+	}
+	return fmt.Sprintf("%s.retry.%s", topic, name)
+}
+
+// RetryRouter republishes failed messages to retry topics with growing delays
+// and finally to the dead letter topic. Together with ConsumeRetries it gives
+// at-least-once processing without blocking the main topic on poison messages.
+type RetryRouter struct {
+	cfg        Config
+	topic      string
+	delays     []time.Duration
+	deadLetter string
+	writer     *kafka.Writer
+}
+
+// NewRetryRouter creates retry router for the main topic
+func NewRetryRouter(cfg Config, topic string, retry RetryConfig) *RetryRouter {
+	delays := retry.Delays
+	if len(delays) == 0 {
+		delays = defaultRetryDelays
+	}
+	deadLetter := retry.DeadLetterTopic
+	if deadLetter == "" {
+		deadLetter = topic + ".dlq"
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Balancer:     &kafka.LeastBytes{},
+		BatchSize:    cfg.BatchSize,
+		BatchTimeout: cfg.BatchTimeout,
+		Transport:    cfg.transport(),
+	}
+	cfg.configureWriter(writer)
+
+	return &RetryRouter{
+		cfg:        cfg,
+		topic:      topic,
+		delays:     delays,
+		deadLetter: deadLetter,
+		writer:     writer,
+	}
+}
+
+// Topics returns retry tier topics
+func (r *RetryRouter) Topics() []string {
+	topics := make([]string, len(r.delays))
+	for i, delay := range r.delays {
+		topics[i] = RetryTopicName(r.topic, delay)
+	}
+	return topics
+}
+
+// Wrap returns handler that republishes messages failed by handler to the next
+// retry tier. The message is considered handled (and committed) once republished.
+func (r *RetryRouter) Wrap(handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, msg kafka.Message) error {
+		err := handler(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		return r.republish(ctx, msg, err)
+	}
+}
+
+// republish sends failed message to the next retry tier or the dead letter topic
+func (r *RetryRouter) republish(ctx context.Context, msg kafka.Message, handleErr error) error {
+	out, attempt := r.retryMessage(msg, handleErr, time.Now())
+	if err := r.writer.WriteMessages(ctx, out); err != nil {
+		return fmt.Errorf("republish to %s: %w", out.Topic, err)
+	}
+
+	logger.Warn("Kafka message scheduled for retry",
+		zap.String("topic", msg.Topic),
+		zap.String("retry_topic", out.Topic),
+		zap.Int("attempt", attempt),
+		zap.Int64("offset", msg.Offset),
+		zap.Error(handleErr),
+	)
+	return nil
+}
+
+// retryMessage builds the message republished after a failed attempt and
+// returns it with the attempt number
+func (r *RetryRouter) retryMessage(msg kafka.Message, handleErr error, now time.Time) (kafka.Message, int) {
+	attempt, _ := strconv.Atoi(headerValue(msg, HeaderRetryAttempt))
+	originalTopic := headerValue(msg, HeaderOriginalTopic)
+	if originalTopic == "" {
+		originalTopic = msg.Topic
+	}
+
+	out := kafka.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+		Time:  now,
+	}
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case HeaderRetryAttempt, HeaderRetryNotBefore, HeaderOriginalTopic, HeaderRetryError:
+		default:
+			out.Headers = append(out.Headers, h)
+		}
+	}
+	out.Headers = append(out.Headers,
+		kafka.Header{Key: HeaderRetryAttempt, Value: []byte(strconv.Itoa(attempt + 1))},
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(originalTopic)},
+		kafka.Header{Key: HeaderRetryError, Value: []byte(handleErr.Error())},
+	)
+
+	if attempt < len(r.delays) {
+		delay := r.delays[attempt]
+		out.Topic = RetryTopicName(r.topic, delay)
+		out.Headers = append(out.Headers, kafka.Header{
+			Key:   HeaderRetryNotBefore,
+			Value: []byte(strconv.FormatInt(now.Add(delay).UnixMilli(), 10)),
+		})
+	} else {
+		out.Topic = r.deadLetter
+	}
+	return out, attempt + 1
+}
+
+// ConsumeRetries consumes all retry tier topics, waiting until each message's
+// not-before time before calling handler. Messages failing again move to the
+// next tier. Blocks until ctx is cancelled or a consumer fails.
+//
+// Waiting blocks the partition instead of pausing and seeking. That relies on
+// every tier topic having a single delay and its own consumer, as set up here:
+// messages of a tier are then due in offset order, so the head message is
+// always the one due first and short tiers are never held up by long ones.
+// Don't publish to retry topics with other delays or consume them together
+// with other topics.
+func (r *RetryRouter) ConsumeRetries(ctx context.Context, handler MessageHandler) error {
+	wrapped := r.Wrap(handler)
+	g, ctx := errgroup.WithContext(ctx)
+
+	for _, topic := range r.Topics() {
+		consumer := NewConsumer(r.cfg, topic)
+		g.Go(func() error {
+			defer consumer.Close()
+			return consumer.Consume(ctx, func(ctx context.Context, msg kafka.Message) error {
+				if err := waitNotBefore(ctx, msg); err != nil {
+					return err
+				}
+				return wrapped(ctx, msg)
+			})
+		})
+	}
+
+	return g.Wait()
+}
+
+// Close closes the retry writer
+func (r *RetryRouter) Close() error {
+	return r.writer.Close()
+}
+
+// waitNotBefore blocks until message not-before time
+func waitNotBefore(ctx context.Context, msg kafka.Message) error {
+	ms, err := strconv.ParseInt(headerValue(msg, HeaderRetryNotBefore), 10, 64)
+	if err != nil {
+		return nil
+	}
+	wait := time.Until(time.UnixMilli(ms))
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// headerValue returns the last value of message header
+func headerValue(msg kafka.Message, key string) string {
+	for i := len(msg.Headers) - 1; i >= 0; i-- {
+		if msg.Headers[i].Key == key {
+			return string(msg.Headers[i].Value)
+		}
+	}
+	return ""
+}
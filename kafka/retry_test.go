@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestRetryTopicName(t *testing.T) {
+	tests := []struct {
+		delay time.Duration
+		want  string
+	}{
+		{30 * time.Second, "orders.retry.30s"},
+		{time.Minute, "orders.retry.1m"},
+		{10 * time.Minute, "orders.retry.10m"},
+		{90 * time.Second, "orders.retry.1m30s"},
+		{time.Hour, "orders.retry.1h"},
+	}
+	for _, tt := range tests {
+		if got := RetryTopicName("orders", tt.delay); got != tt.want {
+			t.Errorf("RetryTopicName(%s) = %q, want %q", tt.delay, got, tt.want)
+		}
+	}
+}
+
+func TestRetryRouter_RetryMessage(t *testing.T) {
+	router := NewRetryRouter(Config{}, "orders", RetryConfig{Delays: []time.Duration{time.Minute, 10 * time.Minute}})
+	defer router.Close()
+	now := time.UnixMilli(1_700_000_000_000)
+	handleErr := errors.New("db down")
+
+	msg := kafka.Message{
+		Topic:   "orders",
+		Key:     []byte("order-1"),
+		Value:   []byte(`{"id":1}`),
+		Headers: []kafka.Header{{Key: HeaderEventType, Value: []byte("order.created")}},
+	}
+	tiers := []struct {
+		topic     string
+		notBefore time.Duration
+	}{
+		{"orders.retry.1m", time.Minute},
+		{"orders.retry.10m", 10 * time.Minute},
+		{"orders.dlq", 0},
+	}
+
+	for i, tier := range tiers {
+		out, attempt := router.retryMessage(msg, handleErr, now)
+		if attempt != i+1 || out.Topic != tier.topic {
+			t.Fatalf("attempt %d: republished as attempt %d to %s, want %s", i+1, attempt, out.Topic, tier.topic)
+		}
+		if string(out.Key) != "order-1" || string(out.Value) != `{"id":1}` {
+			t.Errorf("attempt %d: key/value = %s/%s", i+1, out.Key, out.Value)
+		}
+
+		counts := make(map[string]int)
+		for _, h := range out.Headers {
+			counts[h.Key]++
+		}
+		for _, key := range []string{HeaderEventType, HeaderRetryAttempt, HeaderOriginalTopic, HeaderRetryError} {
+			if counts[key] != 1 {
+				t.Errorf("attempt %d: header %s set %d times, want once", i+1, key, counts[key])
+			}
+		}
+		if got := headerValue(out, HeaderRetryAttempt); got != strconv.Itoa(i+1) {
+			t.Errorf("attempt %d: %s = %s", i+1, HeaderRetryAttempt, got)
+		}
+		if got := headerValue(out, HeaderOriginalTopic); got != "orders" {
+			t.Errorf("attempt %d: %s = %s, want orders", i+1, HeaderOriginalTopic, got)
+		}
+		if got := headerValue(out, HeaderRetryError); got != "db down" {
+			t.Errorf("attempt %d: %s = %s", i+1, HeaderRetryError, got)
+		}
+
+		notBefore := headerValue(out, HeaderRetryNotBefore)
+		if tier.notBefore == 0 && notBefore != "" {
+			t.Errorf("attempt %d: dead letter has %s = %s", i+1, HeaderRetryNotBefore, notBefore)
+		}
+		if want := strconv.FormatInt(now.Add(tier.notBefore).UnixMilli(), 10); tier.notBefore > 0 && notBefore != want {
+			t.Errorf("attempt %d: %s = %s, want %s", i+1, HeaderRetryNotBefore, notBefore, want)
+		}
+
+		// Consumed from the tier topic for the next attempt
+		out.Topic = tier.topic
+		msg = out
+	}
+}
+
+func TestWaitNotBefore(t *testing.T) {
+	notBefore := func(at time.Time) kafka.Message {
+		return kafka.Message{Headers: []kafka.Header{
+			{Key: HeaderRetryNotBefore, Value: []byte(strconv.FormatInt(at.UnixMilli(), 10))},
+		}}
+	}
+
+	for _, msg := range []kafka.Message{{}, notBefore(time.Now().Add(-time.Minute))} {
+		if err := waitNotBefore(context.Background(), msg); err != nil {
+			t.Errorf("waitNotBefore() = %v, want nil", err)
+		}
+	}
+
+	start := time.Now()
+	if err := waitNotBefore(context.Background(), notBefore(start.Add(50*time.Millisecond))); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("waited %s, want about 50ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := waitNotBefore(ctx, notBefore(time.Now().Add(time.Hour))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waitNotBefore() with cancelled context = %v", err)
+	}
+}
@@ -0,0 +1,463 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hamba/avro/v2"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Schema types supported by Schema Registry
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+)
+
+const (
+	wireMagicByte             = 0
+	wireHeaderSize            = 5
+	schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+)
+
+var (
+	// ErrInvalidWireFormat is returned for payloads without the Schema Registry header
+	ErrInvalidWireFormat = errors.New("invalid schema registry wire format")
+	// ErrProtobufImports is returned for messages whose file imports other
+	// files, registering imports as schema references is not supported
+	ErrProtobufImports = errors.New("protobuf schema references are not supported")
+)
+
+// SchemaRegistryConfig holds Confluent Schema Registry configuration
+type SchemaRegistryConfig struct {
+	URL      string        `yaml:"url" env:"KAFKA_SCHEMA_REGISTRY_URL"`
+	Username string        `yaml:"username" env:"KAFKA_SCHEMA_REGISTRY_USERNAME"`
+	Password string        `yaml:"password" env:"KAFKA_SCHEMA_REGISTRY_PASSWORD"`
+	Timeout  time.Duration `yaml:"timeout" env:"KAFKA_SCHEMA_REGISTRY_TIMEOUT" env-default:"10s"`
+}
+
+// Schema is a schema registered in Schema Registry
+type Schema struct {
+	ID     int
+	Type   string
+	Schema string
+}
+
+// SchemaRegistry is a caching Confluent Schema Registry client
+type SchemaRegistry struct {
+	cfg    SchemaRegistryConfig
+	client *http.Client
+
+	mu      sync.RWMutex
+	ids     map[string]int
+	schemas map[int]Schema
+}
+
+// NewSchemaRegistry creates a new Schema Registry client
+func NewSchemaRegistry(cfg SchemaRegistryConfig) *SchemaRegistry {
+	// Apply defaults if not set
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &SchemaRegistry{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		ids:     make(map[string]int),
+		schemas: make(map[int]Schema),
+	}
+}
+
+// SubjectName returns value subject name for topic (TopicNameStrategy)
+func SubjectName(topic string) string {
+	return topic + "-value"
+}
+
+// Register registers schema under subject and returns its ID.
+// Already registered schemas are served from cache.
+func (r *SchemaRegistry) Register(ctx context.Context, subject, schemaType, schema string) (int, error) {
+	cacheKey := subject + "\x00" + schemaType + "\x00" + schema
+
+	r.mu.RLock()
+	id, ok := r.ids[cacheKey]
+	r.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	req := struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType,omitempty"`
+	}{Schema: schema, SchemaType: schemaType}
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := r.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", req, &resp); err != nil {
+		return 0, fmt.Errorf("register schema for subject %s: %w", subject, err)
+	}
+
+	r.mu.Lock()
+	r.ids[cacheKey] = resp.ID
+	r.schemas[resp.ID] = Schema{ID: resp.ID, Type: schemaType, Schema: schema}
+	r.mu.Unlock()
+
+	return resp.ID, nil
+}
+
+// SchemaByID returns schema by ID, fetching it from registry on first use
+func (r *SchemaRegistry) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	r.mu.RLock()
+	schema, ok := r.schemas[id]
+	r.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var resp struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := r.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return Schema{}, fmt.Errorf("get schema %d: %w", id, err)
+	}
+
+	schema = Schema{ID: id, Type: resp.SchemaType, Schema: resp.Schema}
+	// Registry omits schemaType for Avro
+	if schema.Type == "" {
+		schema.Type = SchemaTypeAvro
+	}
+
+	r.mu.Lock()
+	r.schemas[id] = schema
+	r.mu.Unlock()
+
+	return schema, nil
+}
+
+// do sends request to registry and decodes JSON response into out
+func (r *SchemaRegistry) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(r.cfg.URL, "/")+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if in != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+	if r.cfg.Username != "" {
+		req.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var regErr struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&regErr)
+		return fmt.Errorf("schema registry returned %d: %s (code %d)", resp.StatusCode, regErr.Message, regErr.ErrorCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// appendWireHeader appends magic byte and big-endian schema ID
+func appendWireHeader(dst []byte, id int) []byte {
+	dst = append(dst, wireMagicByte)
+	return binary.BigEndian.AppendUint32(dst, uint32(id))
+}
+
+// parseWireHeader returns schema ID and payload following the header
+func parseWireHeader(data []byte) (int, []byte, error) {
+	if len(data) < wireHeaderSize || data[0] != wireMagicByte {
+		return 0, nil, ErrInvalidWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:wireHeaderSize])), data[wireHeaderSize:], nil
+}
+
+// Serializer encodes and decodes message values in Schema Registry wire format
+type Serializer interface {
+	Serialize(ctx context.Context, topic string, value any) ([]byte, error)
+	Deserialize(ctx context.Context, topic string, data []byte, target any) error
+}
+
+// AvroSerializer encodes values with an Avro schema (hamba/avro struct tags).
+// Values are decoded with the writer schema fetched by ID and resolved against
+// the serializer's schema, so fields added or removed by other producers
+// follow Avro schema evolution rules.
+type AvroSerializer struct {
+	registry *SchemaRegistry
+	schema   avro.Schema
+	compat   *avro.SchemaCompatibility
+
+	mu       sync.RWMutex
+	resolved map[int]avro.Schema
+}
+
+// NewAvroSerializer creates a new Avro serializer for schema
+func NewAvroSerializer(registry *SchemaRegistry, schema string) (*AvroSerializer, error) {
+	parsed, err := avro.Parse(schema)
+	if err != nil {
+		return nil, fmt.Errorf("parse avro schema: %w", err)
+	}
+
+	return &AvroSerializer{
+		registry: registry,
+		schema:   parsed,
+		compat:   avro.NewSchemaCompatibility(),
+		resolved: make(map[int]avro.Schema),
+	}, nil
+}
+
+// Serialize registers schema under the topic subject and encodes value
+func (s *AvroSerializer) Serialize(ctx context.Context, topic string, value any) ([]byte, error) {
+	id, err := s.registry.Register(ctx, SubjectName(topic), SchemaTypeAvro, s.schema.String())
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := avro.Marshal(s.schema, value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal avro: %w", err)
+	}
+
+	return append(appendWireHeader(make([]byte, 0, wireHeaderSize+len(payload)), id), payload...), nil
+}
+
+// Deserialize decodes data written with any compatible schema into target
+func (s *AvroSerializer) Deserialize(ctx context.Context, _ string, data []byte, target any) error {
+	id, payload, err := parseWireHeader(data)
+	if err != nil {
+		return err
+	}
+
+	schema, err := s.resolvedSchema(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := avro.Unmarshal(schema, payload, target); err != nil {
+		return fmt.Errorf("unmarshal avro: %w", err)
+	}
+	return nil
+}
+
+// resolvedSchema returns writer schema for ID resolved against the reader schema
+func (s *AvroSerializer) resolvedSchema(ctx context.Context, id int) (avro.Schema, error) {
+	s.mu.RLock()
+	resolved, ok := s.resolved[id]
+	s.mu.RUnlock()
+	if ok {
+		return resolved, nil
+	}
+
+	schema, err := s.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type != SchemaTypeAvro {
+		return nil, fmt.Errorf("schema %d is %s, not %s", id, schema.Type, SchemaTypeAvro)
+	}
+
+	// Own cache, so writer schemas don't replace named types of the reader
+	writer, err := avro.ParseWithCache(schema.Schema, "", &avro.SchemaCache{})
+	if err != nil {
+		return nil, fmt.Errorf("parse avro schema %d: %w", id, err)
+	}
+	resolved, err = s.compat.Resolve(s.schema, writer)
+	if err != nil {
+		return nil, fmt.Errorf("avro schema %d is incompatible with reader schema: %w", id, err)
+	}
+
+	s.mu.Lock()
+	s.resolved[id] = resolved
+	s.mu.Unlock()
+
+	return resolved, nil
+}
+
+// ProtobufSerializer encodes proto.Message values. The message file is
+// registered as a base64 encoded FileDescriptorProto. Schema references are
+// not supported, so messages from files with imports are rejected.
+type ProtobufSerializer struct {
+	registry *SchemaRegistry
+}
+
+// NewProtobufSerializer creates a new Protobuf serializer
+func NewProtobufSerializer(registry *SchemaRegistry) *ProtobufSerializer {
+	return &ProtobufSerializer{registry: registry}
+}
+
+// Serialize registers message file under the topic subject and encodes value
+func (s *ProtobufSerializer) Serialize(ctx context.Context, topic string, value any) ([]byte, error) {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("value %T is not a proto.Message", value)
+	}
+
+	desc := msg.ProtoReflect().Descriptor()
+	if imports := desc.ParentFile().Imports(); imports.Len() > 0 {
+		return nil, fmt.Errorf("%w: %s imports %s", ErrProtobufImports, desc.ParentFile().Path(), imports.Get(0).Path())
+	}
+	file, err := proto.Marshal(protodesc.ToFileDescriptorProto(desc.ParentFile()))
+	if err != nil {
+		return nil, fmt.Errorf("marshal file descriptor: %w", err)
+	}
+
+	id, err := s.registry.Register(ctx, SubjectName(topic), SchemaTypeProtobuf, base64.StdEncoding.EncodeToString(file))
+	if err != nil {
+		return nil, err
+	}
+
+	data := appendMessageIndexes(appendWireHeader(nil, id), desc)
+	data, err = proto.MarshalOptions{}.MarshalAppend(data, msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal protobuf: %w", err)
+	}
+	return data, nil
+}
+
+// Deserialize decodes data into target, which must be a proto.Message
+func (s *ProtobufSerializer) Deserialize(ctx context.Context, _ string, data []byte, target any) error {
+	msg, ok := target.(proto.Message)
+	if !ok {
+		return fmt.Errorf("target %T is not a proto.Message", target)
+	}
+
+	id, payload, err := parseWireHeader(data)
+	if err != nil {
+		return err
+	}
+
+	schema, err := s.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if schema.Type != SchemaTypeProtobuf {
+		return fmt.Errorf("schema %d is %s, not %s", id, schema.Type, SchemaTypeProtobuf)
+	}
+
+	payload, err = skipMessageIndexes(payload)
+	if err != nil {
+		return err
+	}
+
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return fmt.Errorf("unmarshal protobuf: %w", err)
+	}
+	return nil
+}
+
+// appendMessageIndexes appends zigzag varint path of the message within its
+// file; the first top-level message is encoded as a single 0 byte
+func appendMessageIndexes(dst []byte, desc protoreflect.MessageDescriptor) []byte {
+	var indexes []int
+	for d := protoreflect.Descriptor(desc); ; d = d.Parent() {
+		if _, ok := d.(protoreflect.FileDescriptor); ok {
+			break
+		}
+		indexes = append([]int{d.Index()}, indexes...)
+	}
+
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(dst, 0)
+	}
+
+	dst = binary.AppendVarint(dst, int64(len(indexes)))
+	for _, index := range indexes {
+		dst = binary.AppendVarint(dst, int64(index))
+	}
+	return dst
+}
+
+// skipMessageIndexes returns payload following message indexes
+func skipMessageIndexes(data []byte) ([]byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return nil, ErrInvalidWireFormat
+	}
+	data = data[n:]
+
+	for range count {
+		if _, n = binary.Varint(data); n <= 0 {
+			return nil, ErrInvalidWireFormat
+		}
+		data = data[n:]
+	}
+	return data, nil
+}
+
+// PublishSerialized publishes value encoded by serializer
+func PublishSerialized(ctx context.Context, p Publisher, s Serializer, key string, value any) error {
+	data, err := s.Serialize(ctx, p.Topic(), value)
+	if err != nil {
+		return fmt.Errorf("serialize event: %w", err)
+	}
+
+	return p.PublishMessages(ctx, kafka.Message{
+		Key:   []byte(key),
+		Value: data,
+		Time:  time.Now(),
+	})
+}
+
+// ConsumeSerialized consumes messages decoded by serializer into T
+func ConsumeSerialized[T any](ctx context.Context, c Subscriber, s Serializer, handler func(ctx context.Context, event T, msg kafka.Message) error) error {
+	return c.Consume(ctx, SerializedHandler(s, handler))
+}
+
+// SerializedHandler adapts typed handler to MessageHandler using serializer.
+// Pointer types (e.g. generated protobuf messages) are allocated before decoding.
+func SerializedHandler[T any](s Serializer, handler func(ctx context.Context, event T, msg kafka.Message) error) MessageHandler {
+	eventType := reflect.TypeFor[T]()
+
+	return func(ctx context.Context, msg kafka.Message) error {
+		var event T
+		target := any(&event)
+		if eventType.Kind() == reflect.Pointer {
+			event = reflect.New(eventType.Elem()).Interface().(T)
+			target = event
+		}
+
+		if err := s.Deserialize(ctx, msg.Topic, msg.Value, target); err != nil {
+			return fmt.Errorf("deserialize %s: %w", eventType, err)
+		}
+		if err := validate(&event); err != nil {
+			return fmt.Errorf("validate %s: %w", eventType, err)
+		}
+
+		return handler(ctx, event, msg)
+	}
+}
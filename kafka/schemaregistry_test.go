@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeRegistry is an in-memory Schema Registry
+func fakeRegistry(t *testing.T) *SchemaRegistry {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		schemas []map[string]string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/versions"):
+			var req map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			schemas = append(schemas, req)
+			_ = json.NewEncoder(w).Encode(map[string]int{"id": len(schemas)})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+			id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/schemas/ids/"))
+			if id < 1 || id > len(schemas) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(schemas[id-1])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewSchemaRegistry(SchemaRegistryConfig{URL: srv.URL})
+}
+
+type avroOrder struct {
+	ID     string `avro:"id"`
+	Amount int64  `avro:"amount"`
+}
+
+func TestAvroSerializerRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	registry := fakeRegistry(t)
+
+	s, err := NewAvroSerializer(registry, `{"type":"record","name":"Order","fields":[
+		{"name":"id","type":"string"},{"name":"amount","type":"long"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.Serialize(ctx, "orders", avroOrder{ID: "o-1", Amount: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != wireMagicByte {
+		t.Fatalf("magic byte = %d", data[0])
+	}
+
+	// Fresh client resolves the writer schema by ID
+	reader, _ := NewAvroSerializer(NewSchemaRegistry(registry.cfg), `{"type":"record","name":"Order","fields":[
+		{"name":"id","type":"string"},{"name":"amount","type":"long"}]}`)
+	var got avroOrder
+	if err := reader.Deserialize(ctx, "orders", data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (avroOrder{ID: "o-1", Amount: 42}) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestProtobufSerializerRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	registry := fakeRegistry(t)
+	s := NewProtobufSerializer(registry)
+
+	// StringValue is not the first message in wrappers.proto, so indexes are written in full
+	data, err := s.Serialize(ctx, "names", wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data[wireHeaderSize : wireHeaderSize+2]; got[0] != 2 || got[1] != 14 {
+		t.Errorf("message indexes = %v, want [2 14]", got)
+	}
+
+	handler := SerializedHandler(NewProtobufSerializer(NewSchemaRegistry(registry.cfg)),
+		func(_ context.Context, event *wrapperspb.StringValue, _ kafka.Message) error {
+			if event.GetValue() != "hello" {
+				t.Errorf("value = %q", event.GetValue())
+			}
+			return nil
+		})
+	if err := handler(ctx, kafka.Message{Topic: "names", Value: data}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type avroOrderV2 struct {
+	ID       string `avro:"id"`
+	Currency string `avro:"currency"`
+}
+
+func TestAvroSerializerSchemaEvolution(t *testing.T) {
+	ctx := context.Background()
+	registry := fakeRegistry(t)
+
+	writer, err := NewAvroSerializer(registry, `{"type":"record","name":"Order","fields":[
+		{"name":"id","type":"string"},{"name":"amount","type":"long"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := writer.Serialize(ctx, "orders", avroOrder{ID: "o-1", Amount: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reader dropped amount and added currency with a default
+	reader, err := NewAvroSerializer(NewSchemaRegistry(registry.cfg), `{"type":"record","name":"Order","fields":[
+		{"name":"id","type":"string"},{"name":"currency","type":"string","default":"USD"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got avroOrderV2
+	if err := reader.Deserialize(ctx, "orders", data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (avroOrderV2{ID: "o-1", Currency: "USD"}) {
+		t.Errorf("got %+v", got)
+	}
+
+	// New field without a default can't be read from old data
+	incompatible, err := NewAvroSerializer(NewSchemaRegistry(registry.cfg), `{"type":"record","name":"Order","fields":[
+		{"name":"id","type":"string"},{"name":"currency","type":"string"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := incompatible.Deserialize(ctx, "orders", data, &got); err == nil || !strings.Contains(err.Error(), "incompatible") {
+		t.Errorf("Deserialize() with incompatible reader = %v", err)
+	}
+}
+
+func TestProtobufSerializerRejectsImports(t *testing.T) {
+	s := NewProtobufSerializer(fakeRegistry(t))
+
+	// google/protobuf/api.proto imports source_context.proto and type.proto
+	if _, err := s.Serialize(context.Background(), "apis", &apipb.Api{Name: "orders"}); !errors.Is(err, ErrProtobufImports) {
+		t.Errorf("Serialize() error = %v, want ErrProtobufImports", err)
+	}
+}
+
+func TestParseWireHeader(t *testing.T) {
+	id, payload, err := parseWireHeader(appendWireHeader(nil, 300))
+	if err != nil || id != 300 || len(payload) != 0 {
+		t.Errorf("parseWireHeader = %d, %v, %v", id, payload, err)
+	}
+	if _, _, err := parseWireHeader([]byte(`{"id":1}`)); !errors.Is(err, ErrInvalidWireFormat) {
+		t.Errorf("err = %v, want ErrInvalidWireFormat", err)
+	}
+}
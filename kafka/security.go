@@ -0,0 +1,146 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// Supported SASL mechanisms
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// SASLConfig holds SASL authentication configuration
+type SASLConfig struct {
+	// Mechanism - PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, empty disables SASL
+	Mechanism string `yaml:"mechanism" env:"KAFKA_SASL_MECHANISM"`
+	Username  string `yaml:"username" env:"KAFKA_SASL_USERNAME"`
+	Password  string `yaml:"password" env:"KAFKA_SASL_PASSWORD"`
+}
+
+// TLSConfig holds TLS configuration
+type TLSConfig struct {
+	Enabled bool `yaml:"enabled" env:"KAFKA_TLS_ENABLED" env-default:"false"`
+	// CAFile - PEM bundle to verify brokers, system roots are used if empty
+	CAFile string `yaml:"ca_file" env:"KAFKA_TLS_CA_FILE"`
+	// CertFile/KeyFile - client certificate for mTLS
+	CertFile           string `yaml:"cert_file" env:"KAFKA_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"KAFKA_TLS_KEY_FILE"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"KAFKA_TLS_INSECURE_SKIP_VERIFY" env-default:"false"`
+}
+
+// mechanism returns SASL mechanism or nil if SASL is disabled
+func (c *SASLConfig) mechanism() (sasl.Mechanism, error) {
+	switch strings.ToUpper(c.Mechanism) {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{Username: c.Username, Password: c.Password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, c.Username, c.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, c.Username, c.Password)
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism %q", c.Mechanism)
+	}
+}
+
+// config returns TLS config or nil if TLS is disabled
+func (c *TLSConfig) config() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// security returns SASL mechanism and TLS config from Config
+func (c *Config) security() (sasl.Mechanism, *tls.Config, error) {
+	mechanism, err := c.SASL.mechanism()
+	if err != nil {
+		return nil, nil, fmt.Errorf("kafka sasl: %w", err)
+	}
+	tlsConfig, err := c.TLS.config()
+	if err != nil {
+		return nil, nil, fmt.Errorf("kafka tls: %w", err)
+	}
+	return mechanism, tlsConfig, nil
+}
+
+// failingDial reports invalid security configuration on every connection attempt,
+// so constructors keep their signatures and misconfiguration surfaces on first use
+func failingDial(err error) func(context.Context, string, string) (net.Conn, error) {
+	return func(context.Context, string, string) (net.Conn, error) {
+		return nil, err
+	}
+}
+
+// transport returns writer transport with SASL/TLS applied
+func (c *Config) transport() kafka.RoundTripper {
+	mechanism, tlsConfig, err := c.security()
+	if err != nil {
+		logger.Error("invalid kafka security config", zap.Error(err))
+		return &kafka.Transport{Dial: failingDial(err)}
+	}
+	if mechanism == nil && tlsConfig == nil {
+		return kafka.DefaultTransport
+	}
+	return &kafka.Transport{SASL: mechanism, TLS: tlsConfig}
+}
+
+// dialer returns reader dialer with SASL/TLS applied
+func (c *Config) dialer() *kafka.Dialer {
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+	mechanism, tlsConfig, err := c.security()
+	if err != nil {
+		logger.Error("invalid kafka security config", zap.Error(err))
+		dialer.DialFunc = failingDial(err)
+		return dialer
+	}
+	dialer.SASLMechanism = mechanism
+	dialer.TLS = tlsConfig
+	return dialer
+}
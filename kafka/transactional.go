@@ -0,0 +1,205 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// TransactionalProducer publishes messages to several topics atomically.
+// Writes are idempotent; consumers must read with isolation level
+// read_committed to skip aborted messages.
+// kafka-go has no transactions support, so franz-go is used underneath.
+type TransactionalProducer struct {
+	client          *kgo.Client
+	transactionalID string
+
+	// Only one transaction can be open per transactional ID
+	mu sync.Mutex
+}
+
+// NewTransactionalProducer creates transactional producer.
+// transactionalID must be unique per producer instance and stable across restarts.
+func NewTransactionalProducer(cfg Config, transactionalID string) (*TransactionalProducer, error) {
+	if transactionalID == "" {
+		return nil, fmt.Errorf("transactional id is required")
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.TransactionalID(transactionalID),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+	}
+	if cfg.BatchTimeout > 0 {
+		opts = append(opts, kgo.ProducerLinger(cfg.BatchTimeout))
+	}
+
+	compression, err := cfg.kgoCompression()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, kgo.ProducerBatchCompression(compression))
+
+	switch strings.ToUpper(cfg.SASL.Mechanism) {
+	case "":
+	case SASLPlain:
+		opts = append(opts, kgo.SASL(plain.Auth{User: cfg.SASL.Username, Pass: cfg.SASL.Password}.AsMechanism()))
+	case SASLScramSHA256:
+		opts = append(opts, kgo.SASL(scram.Auth{User: cfg.SASL.Username, Pass: cfg.SASL.Password}.AsSha256Mechanism()))
+	case SASLScramSHA512:
+		opts = append(opts, kgo.SASL(scram.Auth{User: cfg.SASL.Username, Pass: cfg.SASL.Password}.AsSha512Mechanism()))
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism %q", cfg.SASL.Mechanism)
+	}
+
+	tlsConfig, err := cfg.TLS.config()
+	if err != nil {
+		return nil, fmt.Errorf("kafka tls: %w", err)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create kafka client: %w", err)
+	}
+
+	logger.Info("Kafka transactional producer created",
+		zap.Strings("brokers", cfg.Brokers),
+		zap.String("transactional_id", transactionalID),
+	)
+
+	return &TransactionalProducer{
+		client:          client,
+		transactionalID: transactionalID,
+	}, nil
+}
+
+// Tx is an open Kafka transaction
+type Tx struct {
+	producer *TransactionalProducer
+	done     bool
+}
+
+// Begin starts a transaction. Only one transaction can be open at a time,
+// Begin blocks until the previous one is committed or aborted.
+func (p *TransactionalProducer) Begin(ctx context.Context) (*Tx, error) {
+	p.mu.Lock()
+	if err := p.client.BeginTransaction(); err != nil {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	return &Tx{producer: p}, nil
+}
+
+// Publish publishes event to topic within the transaction
+func (tx *Tx) Publish(ctx context.Context, topic, key string, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return tx.PublishMessages(ctx, kafka.Message{Topic: topic, Key: []byte(key), Value: data})
+}
+
+// PublishMessages publishes messages within the transaction, Topic must be set on each message
+func (tx *Tx) PublishMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if tx.done {
+		return fmt.Errorf("transaction is already finished")
+	}
+
+	records := make([]*kgo.Record, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Topic == "" {
+			return fmt.Errorf("message topic is required")
+		}
+		record := &kgo.Record{
+			Topic:     msg.Topic,
+			Key:       msg.Key,
+			Value:     msg.Value,
+			Timestamp: msg.Time,
+		}
+		if record.Timestamp.IsZero() {
+			record.Timestamp = time.Now()
+		}
+		for _, h := range msg.Headers {
+			record.Headers = append(record.Headers, kgo.RecordHeader{Key: h.Key, Value: h.Value})
+		}
+		records = append(records, record)
+	}
+
+	if err := tx.producer.client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		return fmt.Errorf("produce: %w", err)
+	}
+	return nil
+}
+
+// Commit commits the transaction, making published messages visible to consumers
+func (tx *Tx) Commit(ctx context.Context) error {
+	return tx.end(ctx, kgo.TryCommit)
+}
+
+// Abort aborts the transaction, discarding published messages
+func (tx *Tx) Abort(ctx context.Context) error {
+	if tx.done {
+		return nil
+	}
+	if err := tx.producer.client.AbortBufferedRecords(ctx); err != nil {
+		logger.Warn("abort buffered records failed", zap.Error(err))
+	}
+	return tx.end(ctx, kgo.TryAbort)
+}
+
+func (tx *Tx) end(ctx context.Context, try kgo.TransactionEndTry) error {
+	if tx.done {
+		return fmt.Errorf("transaction is already finished")
+	}
+	tx.done = true
+	defer tx.producer.mu.Unlock()
+
+	if try == kgo.TryCommit {
+		if err := tx.producer.client.Flush(ctx); err != nil {
+			_ = tx.producer.client.EndTransaction(ctx, kgo.TryAbort)
+			return fmt.Errorf("flush: %w", err)
+		}
+	}
+	if err := tx.producer.client.EndTransaction(ctx, try); err != nil {
+		return fmt.Errorf("end transaction: %w", err)
+	}
+	return nil
+}
+
+// Transaction runs fn within a transaction, committing if fn returns nil and aborting otherwise
+func (p *TransactionalProducer) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if abortErr := tx.Abort(ctx); abortErr != nil {
+			return errors.Join(err, fmt.Errorf("abort transaction: %w", abortErr))
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Close closes the producer
+func (p *TransactionalProducer) Close() error {
+	logger.Info("Kafka transactional producer closed", zap.String("transactional_id", p.transactionalID))
+	p.client.Close()
+	return nil
+}
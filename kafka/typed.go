@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// Headers set by PublishTyped
+const (
+	HeaderContentType = "content-type"
+	HeaderEventType   = "x-event-type"
+)
+
+const contentTypeJSON = "application/json"
+
+var errNilEvent = errors.New("event is nil")
+
+// EventTyper overrides event type name written to the x-event-type header
+// (the Go type name by default)
+type EventTyper interface {
+	EventType() string
+}
+
+// Validator is implemented by typed events that validate themselves.
+// Validate is called before publishing and after decoding.
+type Validator interface {
+	Validate() error
+}
+
+// EventTypeName returns event type name used in the x-event-type header
+func EventTypeName[T any]() string {
+	// Pointer events get EventType called on a new value, not on nil
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Pointer {
+		if typer, ok := reflect.New(t.Elem()).Interface().(EventTyper); ok {
+			return typer.EventType()
+		}
+		return t.String()
+	}
+
+	var zero T
+	if typer, ok := any(zero).(EventTyper); ok {
+		return typer.EventType()
+	}
+	if typer, ok := any(&zero).(EventTyper); ok {
+		return typer.EventType()
+	}
+	return reflect.TypeFor[T]().String()
+}
+
+// PublishTyped publishes value as JSON with content and event type headers
+func PublishTyped[T any](ctx context.Context, p Publisher, key string, value T) error {
+	if err := validate(&value); err != nil {
+		return fmt.Errorf("validate event: %w", err)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	return p.PublishMessages(ctx, kafka.Message{
+		Key:   []byte(key),
+		Value: data,
+		Time:  time.Now(),
+		Headers: []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(contentTypeJSON)},
+			{Key: HeaderEventType, Value: []byte(EventTypeName[T]())},
+		},
+	})
+}
+
+// ConsumeTyped consumes messages decoded into T. Messages with a different
+// x-event-type header are skipped, so several event types can share a topic.
+func ConsumeTyped[T any](ctx context.Context, c Subscriber, handler func(ctx context.Context, event T, msg kafka.Message) error) error {
+	return c.Consume(ctx, TypedHandler(handler))
+}
+
+// TypedHandler adapts typed handler to MessageHandler, e.g. for ConcurrentConsume
+func TypedHandler[T any](handler func(ctx context.Context, event T, msg kafka.Message) error) MessageHandler {
+	eventType := EventTypeName[T]()
+
+	return func(ctx context.Context, msg kafka.Message) error {
+		if got := headerValue(msg, HeaderEventType); got != "" && got != eventType {
+			logger.Debug("skipping Kafka message of unexpected event type",
+				zap.String("topic", msg.Topic),
+				zap.Int64("offset", msg.Offset),
+				zap.String("event_type", got),
+				zap.String("expected", eventType),
+			)
+			return nil
+		}
+
+		var event T
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return fmt.Errorf("unmarshal %s: %w", eventType, err)
+		}
+		if err := validate(&event); err != nil {
+			return fmt.Errorf("validate %s: %w", eventType, err)
+		}
+
+		return handler(ctx, event, msg)
+	}
+}
+
+// validate calls Validate if the event (or pointer to it) implements Validator.
+// Nil pointer events (e.g. decoded from JSON null) are rejected.
+func validate[T any](event *T) error {
+	if rv := reflect.ValueOf(*event); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return errNilEvent
+	}
+	if v, ok := any(*event).(Validator); ok {
+		return v.Validate()
+	}
+	if v, ok := any(event).(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
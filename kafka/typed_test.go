@@ -0,0 +1,174 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"gitlab.com/xakpro/cg-shared-libs/kafka"
+	"gitlab.com/xakpro/cg-shared-libs/kafka/kafkatest"
+)
+
+type orderCreated struct {
+	ID string `json:"id"`
+}
+
+// orderPaid implements EventTyper and Validator with value receivers
+type orderPaid struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+func (orderPaid) EventType() string { return "order.paid" }
+
+func (e orderPaid) Validate() error {
+	if e.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	return nil
+}
+
+// orderShipped implements EventTyper and Validator with pointer receivers
+// that dereference the event
+type orderShipped struct {
+	ID string `json:"id"`
+}
+
+func (e *orderShipped) EventType() string {
+	_ = e.ID
+	return "order.shipped"
+}
+
+func (e *orderShipped) Validate() error {
+	if e.ID == "" {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+func TestEventTypeName(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{kafka.EventTypeName[orderCreated](), "kafka_test.orderCreated"},
+		{kafka.EventTypeName[*orderCreated](), "*kafka_test.orderCreated"},
+		{kafka.EventTypeName[orderPaid](), "order.paid"},
+		{kafka.EventTypeName[*orderPaid](), "order.paid"},
+		{kafka.EventTypeName[orderShipped](), "order.shipped"},
+		{kafka.EventTypeName[*orderShipped](), "order.shipped"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("EventTypeName() = %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestPublishTyped(t *testing.T) {
+	ctx := context.Background()
+	producer := kafkatest.NewBroker().Producer("orders")
+
+	if err := kafka.PublishTyped(ctx, producer, "1", orderPaid{ID: "1", Amount: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := kafka.PublishTyped(ctx, producer, "2", orderPaid{ID: "2"}); err == nil {
+		t.Error("invalid value event must not be published")
+	}
+	if err := kafka.PublishTyped(ctx, producer, "3", &orderShipped{}); err == nil {
+		t.Error("invalid pointer event must not be published")
+	}
+	if err := kafka.PublishTyped[*orderShipped](ctx, producer, "4", nil); err == nil {
+		t.Error("nil event must not be published")
+	}
+
+	msgs := producer.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("published %d messages, want 1", len(msgs))
+	}
+	headers := make(map[string]string)
+	for _, h := range msgs[0].Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers[kafka.HeaderEventType] != "order.paid" || headers[kafka.HeaderContentType] != "application/json" {
+		t.Errorf("headers = %v", headers)
+	}
+}
+
+func TestTypedHandler(t *testing.T) {
+	message := func(eventType, value string) kafkago.Message {
+		msg := kafkago.Message{Topic: "orders", Value: []byte(value)}
+		if eventType != "" {
+			msg.Headers = []kafkago.Header{{Key: kafka.HeaderEventType, Value: []byte(eventType)}}
+		}
+		return msg
+	}
+
+	var handled []string
+	paid := kafka.TypedHandler(func(_ context.Context, event orderPaid, _ kafkago.Message) error {
+		handled = append(handled, event.ID)
+		return nil
+	})
+	shipped := kafka.TypedHandler(func(_ context.Context, event *orderShipped, _ kafkago.Message) error {
+		handled = append(handled, event.ID)
+		return nil
+	})
+
+	tests := []struct {
+		name    string
+		handler kafka.MessageHandler
+		msg     kafkago.Message
+		wantErr string
+	}{
+		{"matching type", paid, message("order.paid", `{"id":"1","amount":5}`), ""},
+		{"missing type header", paid, message("", `{"id":"2","amount":5}`), ""},
+		{"other type is skipped", paid, message("order.shipped", `not json`), ""},
+		{"invalid json", paid, message("order.paid", `{"id":`), "unmarshal order.paid"},
+		{"invalid value event", paid, message("order.paid", `{"id":"3"}`), "validate order.paid"},
+		{"pointer event", shipped, message("order.shipped", `{"id":"4"}`), ""},
+		{"invalid pointer event", shipped, message("order.shipped", `{}`), "validate order.shipped"},
+		{"null pointer event", shipped, message("order.shipped", `null`), "validate order.shipped"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.handler(context.Background(), tt.msg)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if strings.Join(handled, ",") != "1,2,4" {
+		t.Errorf("handled = %v, want 1,2,4", handled)
+	}
+}
+
+func TestTypedHandler_SharedTopic(t *testing.T) {
+	ctx := context.Background()
+	broker := kafkatest.NewBroker()
+	producer := broker.Producer("orders")
+
+	if err := kafka.PublishTyped(ctx, producer, "1", orderCreated{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := kafka.PublishTyped(ctx, producer, "2", orderPaid{ID: "2", Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	consumer := broker.Consumer("orders")
+	var received []string
+	handler := kafka.TypedHandler(func(_ context.Context, event orderPaid, _ kafkago.Message) error {
+		received = append(received, event.ID)
+		return nil
+	})
+	if err := consumer.Drain(ctx, handler); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0] != "2" || len(consumer.Failed()) != 0 {
+		t.Errorf("received = %v, failed = %d", received, len(consumer.Failed()))
+	}
+}
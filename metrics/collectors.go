@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+)
+
+// Resource collectors read pool stats on every scrape. Register functions
+// return unregister func to call when the resource is closed.
+
+// statsCollector is a collector backed by a stats snapshot function
+type statsCollector struct {
+	descs   []*prometheus.Desc
+	collect func(ch chan<- prometheus.Metric)
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch)
+}
+
+// RegisterPgxPool exports pgxpool stats: connections by state, max
+// connections, acquire counts and acquire wait time
+func (m *Metrics) RegisterPgxPool(name string, pool *pgxpool.Pool) (func(), error) {
+	labels := prometheus.Labels{"pool": name}
+	conns := m.desc("db_pool_connections", "Database pool connections by state (acquired, idle, constructing, total)", []string{"state"}, labels)
+	maxConns := m.desc("db_pool_max_connections", "Database pool max connections", nil, labels)
+	acquires := m.desc("db_pool_acquires_total", "Total number of database pool acquires", nil, labels)
+	emptyAcquires := m.desc("db_pool_empty_acquires_total", "Total number of acquires that waited for a connection", nil, labels)
+	canceledAcquires := m.desc("db_pool_canceled_acquires_total", "Total number of acquires canceled by context", nil, labels)
+	acquireWait := m.desc("db_pool_acquire_wait_seconds_total", "Total time spent waiting for a free connection", nil, labels)
+
+	return m.register(&statsCollector{
+		descs: []*prometheus.Desc{conns, maxConns, acquires, emptyAcquires, canceledAcquires, acquireWait},
+		collect: func(ch chan<- prometheus.Metric) {
+			stat := pool.Stat()
+			ch <- prometheus.MustNewConstMetric(conns, prometheus.GaugeValue, float64(stat.AcquiredConns()), "acquired")
+			ch <- prometheus.MustNewConstMetric(conns, prometheus.GaugeValue, float64(stat.IdleConns()), "idle")
+			ch <- prometheus.MustNewConstMetric(conns, prometheus.GaugeValue, float64(stat.ConstructingConns()), "constructing")
+			ch <- prometheus.MustNewConstMetric(conns, prometheus.GaugeValue, float64(stat.TotalConns()), "total")
+			ch <- prometheus.MustNewConstMetric(maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+			ch <- prometheus.MustNewConstMetric(acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+			ch <- prometheus.MustNewConstMetric(emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+			ch <- prometheus.MustNewConstMetric(canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+			ch <- prometheus.MustNewConstMetric(acquireWait, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds())
+		},
+	})
+}
+
+// RegisterRedisPool exports go-redis pool stats: connections by state and
+// hit, miss and timeout counts
+func (m *Metrics) RegisterRedisPool(name string, stats func() *redis.PoolStats) (func(), error) {
+	labels := prometheus.Labels{"pool": name}
+	conns := m.desc("redis_pool_connections", "Redis pool connections by state (total, idle, stale)", []string{"state"}, labels)
+	events := m.desc("redis_pool_events_total", "Redis pool events (hit, miss, timeout, wait)", []string{"event"}, labels)
+	wait := m.desc("redis_pool_wait_seconds_total", "Total time spent waiting for a free Redis connection", nil, labels)
+
+	return m.register(&statsCollector{
+		descs: []*prometheus.Desc{conns, events, wait},
+		collect: func(ch chan<- prometheus.Metric) {
+			s := stats()
+			ch <- prometheus.MustNewConstMetric(conns, prometheus.GaugeValue, float64(s.TotalConns), "total")
+			ch <- prometheus.MustNewConstMetric(conns, prometheus.GaugeValue, float64(s.IdleConns), "idle")
+			ch <- prometheus.MustNewConstMetric(conns, prometheus.GaugeValue, float64(s.StaleConns), "stale")
+			ch <- prometheus.MustNewConstMetric(events, prometheus.CounterValue, float64(s.Hits), "hit")
+			ch <- prometheus.MustNewConstMetric(events, prometheus.CounterValue, float64(s.Misses), "miss")
+			ch <- prometheus.MustNewConstMetric(events, prometheus.CounterValue, float64(s.Timeouts), "timeout")
+			ch <- prometheus.MustNewConstMetric(events, prometheus.CounterValue, float64(s.WaitCount), "wait")
+			ch <- prometheus.MustNewConstMetric(wait, prometheus.CounterValue, float64(s.WaitDurationNs)/1e9)
+		},
+	})
+}
+
+// RegisterKafkaReader exports kafka-go reader stats: lag, queue fill and
+// message, error and rebalance counts
+func (m *Metrics) RegisterKafkaReader(reader *kafka.Reader) (func(), error) {
+	labels := prometheus.Labels{"topic": reader.Config().Topic, "group": reader.Config().GroupID}
+	lag := m.desc("kafka_reader_lag", "Kafka reader lag in messages", nil, labels)
+	queue := m.desc("kafka_reader_queue", "Kafka reader internal queue (length, capacity)", []string{"state"}, labels)
+	counts := m.desc("kafka_reader_events_total", "Kafka reader events (message, error, timeout, rebalance)", []string{"event"}, labels)
+	bytes := m.desc("kafka_reader_bytes_total", "Total bytes read by Kafka reader", nil, labels)
+
+	// Reader.Stats resets counters on every call, keep running totals
+	var (
+		mu                                          sync.Mutex
+		messages, errs, timeouts, rebalances, total int64
+	)
+	return m.register(&statsCollector{
+		descs: []*prometheus.Desc{lag, queue, counts, bytes},
+		collect: func(ch chan<- prometheus.Metric) {
+			mu.Lock()
+			s := reader.Stats()
+			messages += s.Messages
+			errs += s.Errors
+			timeouts += s.Timeouts
+			rebalances += s.Rebalances
+			total += s.Bytes
+			ch <- prometheus.MustNewConstMetric(lag, prometheus.GaugeValue, float64(s.Lag))
+			ch <- prometheus.MustNewConstMetric(queue, prometheus.GaugeValue, float64(s.QueueLength), "length")
+			ch <- prometheus.MustNewConstMetric(queue, prometheus.GaugeValue, float64(s.QueueCapacity), "capacity")
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(messages), "message")
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(errs), "error")
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(timeouts), "timeout")
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(rebalances), "rebalance")
+			ch <- prometheus.MustNewConstMetric(bytes, prometheus.CounterValue, float64(total))
+			mu.Unlock()
+		},
+	})
+}
+
+// RegisterKafkaWriter exports kafka-go writer stats: write, message, error
+// and retry counts and average batch time
+func (m *Metrics) RegisterKafkaWriter(writer *kafka.Writer) (func(), error) {
+	labels := prometheus.Labels{"topic": writer.Topic}
+	counts := m.desc("kafka_writer_events_total", "Kafka writer events (write, message, error, retry)", []string{"event"}, labels)
+	bytes := m.desc("kafka_writer_bytes_total", "Total bytes written by Kafka writer", nil, labels)
+	batchTime := m.desc("kafka_writer_batch_seconds", "Average Kafka writer batch time since last scrape", nil, labels)
+
+	// Writer.Stats resets counters on every call, keep running totals
+	var (
+		mu                                     sync.Mutex
+		writes, messages, errs, retries, total int64
+	)
+	return m.register(&statsCollector{
+		descs: []*prometheus.Desc{counts, bytes, batchTime},
+		collect: func(ch chan<- prometheus.Metric) {
+			mu.Lock()
+			s := writer.Stats()
+			writes += s.Writes
+			messages += s.Messages
+			errs += s.Errors
+			retries += s.Retries
+			total += s.Bytes
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(writes), "write")
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(messages), "message")
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(errs), "error")
+			ch <- prometheus.MustNewConstMetric(counts, prometheus.CounterValue, float64(retries), "retry")
+			ch <- prometheus.MustNewConstMetric(bytes, prometheus.CounterValue, float64(total))
+			ch <- prometheus.MustNewConstMetric(batchTime, prometheus.GaugeValue, s.BatchTime.Avg.Seconds())
+			mu.Unlock()
+		},
+	})
+}
+
+// desc creates descriptor applying configured namespace and constant labels
+func (m *Metrics) desc(name, help string, variableLabels []string, labels prometheus.Labels) *prometheus.Desc {
+	labels["service"] = m.serviceName
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(m.factory.cfg.Namespace, m.factory.cfg.Subsystem, name),
+		help,
+		variableLabels,
+		m.factory.constLabels(labels),
+	)
+}
+
+// register registers collector and returns func unregistering it
+func (m *Metrics) register(c prometheus.Collector) (func(), error) {
+	if err := m.registerer.Register(c); err != nil {
+		return func() {}, fmt.Errorf("register collector: %w", err)
+	}
+	return func() { m.registerer.Unregister(c) }, nil
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRegisterRedisPool(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewWithConfig("orders", Config{Registry: registry, SkipRuntimeCollectors: true})
+
+	stats := &redis.PoolStats{Hits: 10, Misses: 2, TotalConns: 5, IdleConns: 3}
+	unregister, err := m.RegisterRedisPool("cache", func() *redis.PoolStats { return stats })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP redis_pool_connections Redis pool connections by state (total, idle, stale)
+# TYPE redis_pool_connections gauge
+redis_pool_connections{pool="cache",service="orders",state="idle"} 3
+redis_pool_connections{pool="cache",service="orders",state="stale"} 0
+redis_pool_connections{pool="cache",service="orders",state="total"} 5
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "redis_pool_connections"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := m.RegisterRedisPool("cache", func() *redis.PoolStats { return stats }); err == nil {
+		t.Error("duplicate registration succeeded")
+	}
+
+	unregister()
+	if n, err := testutil.GatherAndCount(registry, "redis_pool_connections"); err != nil || n != 0 {
+		t.Errorf("after unregister: %d series, err %v", n, err)
+	}
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"errors"
+	"maps"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultBuckets are duration histogram buckets used when Config.Buckets is not set
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// redisBuckets are finer default buckets for sub-millisecond Redis commands
+var redisBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// Config holds metrics configuration
+type Config struct {
+	// Namespace and Subsystem prefix all metric names: namespace_subsystem_name
+	Namespace string `yaml:"namespace" env:"METRICS_NAMESPACE"`
+	Subsystem string `yaml:"subsystem" env:"METRICS_SUBSYSTEM"`
+	// Buckets overrides duration histogram buckets (seconds) of all metrics
+	Buckets []float64 `yaml:"buckets"`
+	// ConstLabels are added to every metric, e.g. env and region
+	ConstLabels map[string]string `yaml:"const_labels"`
+	// SkipRuntimeCollectors - don't register Go runtime, process and build info collectors.
+	// The prometheus default registry has Go and process collectors of its own,
+	// set Registry to export without them.
+	SkipRuntimeCollectors bool `yaml:"skip_runtime_collectors" env:"METRICS_SKIP_RUNTIME_COLLECTORS" env-default:"false"`
+
+	// Registry to register metrics on, prometheus default registry if nil
+	Registry *prometheus.Registry `yaml:"-"`
+	// RouteResolver resolves HTTP endpoint label from router, see RouteResolver
+	RouteResolver RouteResolver `yaml:"-"`
+	// PathRules normalize paths without route template, DefaultPathRules if empty
+	PathRules []PathRule `yaml:"-"`
+
+	// Push - optional push mode for jobs that can't be scraped
+	Push PushConfig `yaml:"push"`
+}
+
+// registerer returns configured registry or the default one
+func (c *Config) registerer() prometheus.Registerer {
+	if c.Registry != nil {
+		return c.Registry
+	}
+	return prometheus.DefaultRegisterer
+}
+
+// gatherer returns configured registry or the default one
+func (c *Config) gatherer() prometheus.Gatherer {
+	if c.Registry != nil {
+		return c.Registry
+	}
+	return prometheus.DefaultGatherer
+}
+
+// registerRuntimeCollectors registers Go runtime, process and build info
+// collectors on the configured registry. The default registry already has
+// the first two, so duplicates are ignored. Collectors registered by others
+// are never removed.
+func registerRuntimeCollectors(cfg Config) {
+	if cfg.SkipRuntimeCollectors {
+		return
+	}
+
+	registerer := cfg.registerer()
+	runtime := []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewBuildInfoCollector(),
+	}
+
+	for _, c := range runtime {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if err := registerer.Register(c); err != nil && !errors.As(err, &alreadyRegistered) {
+			logger.Warn("failed to register runtime metrics collector", zap.Error(err))
+		}
+	}
+}
+
+// factory creates collectors applying Config
+type factory struct {
+	cfg Config
+}
+
+func (f factory) counterVec(opts prometheus.CounterOpts, labels []string) *prometheus.CounterVec {
+	opts.Namespace, opts.Subsystem, opts.ConstLabels = f.cfg.Namespace, f.cfg.Subsystem, f.constLabels(opts.ConstLabels)
+	return promauto.With(f.cfg.registerer()).NewCounterVec(opts, labels)
+}
+
+func (f factory) gaugeVec(opts prometheus.GaugeOpts, labels []string) *prometheus.GaugeVec {
+	opts.Namespace, opts.Subsystem, opts.ConstLabels = f.cfg.Namespace, f.cfg.Subsystem, f.constLabels(opts.ConstLabels)
+	return promauto.With(f.cfg.registerer()).NewGaugeVec(opts, labels)
+}
+
+func (f factory) histogramVec(opts prometheus.HistogramOpts, labels []string) *prometheus.HistogramVec {
+	opts.Namespace, opts.Subsystem, opts.ConstLabels = f.cfg.Namespace, f.cfg.Subsystem, f.constLabels(opts.ConstLabels)
+	return promauto.With(f.cfg.registerer()).NewHistogramVec(opts, labels)
+}
+
+// durationBuckets returns configured buckets or defaults
+func (f factory) durationBuckets(defaults []float64) []float64 {
+	if len(f.cfg.Buckets) > 0 {
+		return f.cfg.Buckets
+	}
+	return defaults
+}
+
+// constLabels merges configured constant labels with metric specific ones
+func (f factory) constLabels(labels prometheus.Labels) prometheus.Labels {
+	if len(f.cfg.ConstLabels) == 0 {
+		return labels
+	}
+	merged := make(prometheus.Labels, len(f.cfg.ConstLabels)+len(labels))
+	maps.Copy(merged, f.cfg.ConstLabels)
+	maps.Copy(merged, labels)
+	return merged
+}
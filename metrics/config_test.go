@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	m := NewWithConfig("orders", Config{
+		Namespace:   "test",
+		Subsystem:   "config",
+		Buckets:     []float64{0.1, 1},
+		ConstLabels: map[string]string{"env": "prod"},
+	})
+	m.RecordHTTPRequest("GET", "/orders", 200, 300*time.Millisecond)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_config_http_request_duration_seconds" {
+			continue
+		}
+		metric := family.GetMetric()[0]
+		if buckets := metric.GetHistogram().GetBucket(); len(buckets) != 2 {
+			t.Errorf("buckets = %d, want 2", len(buckets))
+		}
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "env" && label.GetValue() == "prod" {
+				return
+			}
+		}
+		t.Fatalf("const label env missing: %v", metric.GetLabel())
+	}
+	t.Fatal("test_config_http_request_duration_seconds not registered")
+}
+
+func TestRuntimeCollectors(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		registry := prometheus.NewRegistry()
+		NewWithConfig("orders", Config{Registry: registry, SkipRuntimeCollectors: skip})
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := map[string]bool{}
+		for _, family := range families {
+			found[family.GetName()] = true
+		}
+		for _, name := range []string{"go_goroutines", "go_build_info"} {
+			if found[name] == skip {
+				t.Errorf("skip=%v: %s registered = %v", skip, name, found[name])
+			}
+		}
+	}
+}
+
+func TestRuntimeCollectors_SkipKeepsForeignCollectors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector())
+	NewWithConfig("orders", Config{Registry: registry, SkipRuntimeCollectors: true})
+	NewWithConfig("orders", Config{Namespace: "test", Subsystem: "skip_runtime", SkipRuntimeCollectors: true})
+
+	for name, gatherer := range map[string]prometheus.Gatherer{"custom": registry, "default": prometheus.DefaultGatherer} {
+		if n, err := testutil.GatherAndCount(gatherer, "go_goroutines"); err != nil || n != 1 {
+			t.Errorf("%s registry: go_goroutines = %d, %v; want registered", name, n, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// domainMetrics caches business metrics created on demand
+type domainMetrics struct {
+	mu         sync.Mutex
+	collectors map[string]domainMetric
+}
+
+type domainMetric struct {
+	kind      string
+	labels    []string
+	collector any
+}
+
+// Counter returns counter vector for a business metric, e.g.
+// m.Counter("orders_created_total", "status").WithLabelValues("paid").Inc().
+// The service label is already set. Repeated calls return the same vector;
+// reusing a name with another type or labels panics.
+func (m *Metrics) Counter(name string, labels ...string) *prometheus.CounterVec {
+	return domainCollector(m, name, "counter", labels, func() *prometheus.CounterVec {
+		return m.factory.counterVec(prometheus.CounterOpts{Name: name, Help: "Business metric " + name}, m.domainLabels(labels))
+	}).MustCurryWith(prometheus.Labels{"service": m.serviceName})
+}
+
+// Gauge returns gauge vector for a business metric, see Counter
+func (m *Metrics) Gauge(name string, labels ...string) *prometheus.GaugeVec {
+	return domainCollector(m, name, "gauge", labels, func() *prometheus.GaugeVec {
+		return m.factory.gaugeVec(prometheus.GaugeOpts{Name: name, Help: "Business metric " + name}, m.domainLabels(labels))
+	}).MustCurryWith(prometheus.Labels{"service": m.serviceName})
+}
+
+// Histogram returns histogram vector for a business metric, see Counter.
+// Nil buckets use configured duration buckets.
+func (m *Metrics) Histogram(name string, buckets []float64, labels ...string) prometheus.ObserverVec {
+	return domainCollector(m, name, "histogram", labels, func() *prometheus.HistogramVec {
+		if buckets == nil {
+			buckets = m.factory.durationBuckets(DefaultBuckets)
+		}
+		return m.factory.histogramVec(prometheus.HistogramOpts{Name: name, Help: "Business metric " + name, Buckets: buckets}, m.domainLabels(labels))
+	}).MustCurryWith(prometheus.Labels{"service": m.serviceName})
+}
+
+// domainCollector returns cached collector or creates it
+func domainCollector[T any](m *Metrics, name, kind string, labels []string, create func() T) T {
+	m.domain.mu.Lock()
+	defer m.domain.mu.Unlock()
+
+	if existing, ok := m.domain.collectors[name]; ok {
+		if existing.kind != kind || !slices.Equal(existing.labels, labels) {
+			panic(fmt.Sprintf("metrics: %s already registered as %s with labels %v", name, existing.kind, existing.labels))
+		}
+		return existing.collector.(T)
+	}
+
+	collector := create()
+	if m.domain.collectors == nil {
+		m.domain.collectors = make(map[string]domainMetric)
+	}
+	m.domain.collectors[name] = domainMetric{kind: kind, labels: slices.Clone(labels), collector: collector}
+	return collector
+}
+
+// domainLabels prepends service label
+func (m *Metrics) domainLabels(labels []string) []string {
+	return append([]string{"service"}, labels...)
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gitlab.com/xakpro/cg-shared-libs/logger"
 	"go.uber.org/zap"
@@ -18,6 +17,14 @@ import (
 // Metrics holds all Prometheus metrics for a service
 type Metrics struct {
 	serviceName string
+	registerer  prometheus.Registerer
+	gatherer    prometheus.Gatherer
+	factory     factory
+	domain      domainMetrics
+	push        PushConfig
+
+	routeResolver RouteResolver
+	pathRules     []PathRule
 
 	// HTTP metrics
 	httpRequestsTotal   *prometheus.CounterVec
@@ -28,56 +35,247 @@ type Metrics struct {
 	grpcRequestsTotal   *prometheus.CounterVec
 	grpcRequestDuration *prometheus.HistogramVec
 	grpcErrorsTotal     *prometheus.CounterVec
+	grpcInFlight        *prometheus.GaugeVec
+	grpcStreamDuration  *prometheus.HistogramVec
+	grpcStreamMessages  *prometheus.CounterVec
+
+	// gRPC client metrics
+	grpcClientRequestsTotal      *prometheus.CounterVec
+	grpcClientRequestDuration    *prometheus.HistogramVec
+	grpcClientBreakerState       *prometheus.GaugeVec
+	grpcClientBreakerTransitions *prometheus.CounterVec
+	grpcClientDeadlineExceeded   *prometheus.CounterVec
+
+	// Kafka metrics
+	kafkaConsumedTotal       *prometheus.CounterVec
+	kafkaProcessingDuration  *prometheus.HistogramVec
+	kafkaConsumerLag         *prometheus.GaugeVec
+	kafkaCommitFailuresTotal *prometheus.CounterVec
+	kafkaWriteDuration       *prometheus.HistogramVec
+	kafkaWriteBatchSize      *prometheus.HistogramVec
+	kafkaWriteErrorsTotal    *prometheus.CounterVec
+
+	// Redis metrics
+	redisCommandDuration *prometheus.HistogramVec
+	redisErrorsTotal     *prometheus.CounterVec
+
+	// PostgreSQL metrics
+	postgresQueryDuration *prometheus.HistogramVec
+	postgresErrorsTotal   *prometheus.CounterVec
 }
 
-// New creates a new Metrics instance for a service
+// New creates a new Metrics instance for a service with default configuration
 func New(serviceName string) *Metrics {
+	return NewWithConfig(serviceName, Config{})
+}
+
+// NewWithConfig creates a new Metrics instance for a service
+func NewWithConfig(serviceName string, cfg Config) *Metrics {
+	// Apply defaults if not set
+	if cfg.Push.Interval <= 0 {
+		cfg.Push.Interval = 15 * time.Second
+	}
+	if cfg.Push.Timeout <= 0 {
+		cfg.Push.Timeout = 10 * time.Second
+	}
+
+	registerRuntimeCollectors(cfg)
+
+	f := factory{cfg: cfg}
 	return &Metrics{
 		serviceName: serviceName,
-		httpRequestsTotal: promauto.NewCounterVec(
+		registerer:  cfg.registerer(),
+		gatherer:    cfg.gatherer(),
+		factory:     f,
+		push:        cfg.Push,
+
+		routeResolver: cfg.RouteResolver,
+		pathRules:     cfg.PathRules,
+
+		httpRequestsTotal: f.counterVec(
 			prometheus.CounterOpts{
 				Name: "http_requests_total",
 				Help: "Total number of HTTP requests",
 			},
 			[]string{"service", "method", "endpoint", "status"},
 		),
-		httpRequestDuration: promauto.NewHistogramVec(
+		httpRequestDuration: f.histogramVec(
 			prometheus.HistogramOpts{
 				Name:    "http_request_duration_seconds",
 				Help:    "HTTP request duration in seconds",
-				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+				Buckets: f.durationBuckets(DefaultBuckets),
 			},
 			[]string{"service", "method", "endpoint"},
 		),
-		httpErrorsTotal: promauto.NewCounterVec(
+		httpErrorsTotal: f.counterVec(
 			prometheus.CounterOpts{
 				Name: "http_errors_total",
 				Help: "Total number of HTTP errors",
 			},
 			[]string{"service", "method", "endpoint", "error_type"},
 		),
-		grpcRequestsTotal: promauto.NewCounterVec(
+		grpcRequestsTotal: f.counterVec(
 			prometheus.CounterOpts{
 				Name: "grpc_requests_total",
 				Help: "Total number of gRPC requests",
 			},
 			[]string{"service", "method", "status"},
 		),
-		grpcRequestDuration: promauto.NewHistogramVec(
+		grpcRequestDuration: f.histogramVec(
 			prometheus.HistogramOpts{
 				Name:    "grpc_request_duration_seconds",
 				Help:    "gRPC request duration in seconds",
-				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+				Buckets: f.durationBuckets(DefaultBuckets),
 			},
 			[]string{"service", "method"},
 		),
-		grpcErrorsTotal: promauto.NewCounterVec(
+		grpcErrorsTotal: f.counterVec(
 			prometheus.CounterOpts{
 				Name: "grpc_errors_total",
 				Help: "Total number of gRPC errors",
 			},
 			[]string{"service", "method", "error_code"},
 		),
+		grpcInFlight: f.gaugeVec(
+			prometheus.GaugeOpts{
+				Name: "grpc_in_flight_requests",
+				Help: "Number of gRPC requests and streams currently being handled",
+			},
+			[]string{"service", "method", "type"},
+		),
+		grpcStreamDuration: f.histogramVec(
+			prometheus.HistogramOpts{
+				Name:    "grpc_stream_duration_seconds",
+				Help:    "gRPC stream duration in seconds",
+				Buckets: f.durationBuckets(DefaultBuckets),
+			},
+			[]string{"service", "method"},
+		),
+		grpcStreamMessages: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_stream_messages_total",
+				Help: "Total number of gRPC stream messages",
+			},
+			[]string{"service", "method", "direction"},
+		),
+		grpcClientRequestsTotal: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_client_requests_total",
+				Help: "Total number of gRPC client calls",
+			},
+			[]string{"service", "target", "method", "status"},
+		),
+		grpcClientRequestDuration: f.histogramVec(
+			prometheus.HistogramOpts{
+				Name:    "grpc_client_request_duration_seconds",
+				Help:    "gRPC client call duration in seconds",
+				Buckets: f.durationBuckets(DefaultBuckets),
+			},
+			[]string{"service", "target", "method"},
+		),
+		grpcClientBreakerState: f.gaugeVec(
+			prometheus.GaugeOpts{
+				Name: "grpc_client_circuit_breaker_state",
+				Help: "gRPC client circuit breaker state (0 - closed, 1 - half-open, 2 - open)",
+			},
+			[]string{"service", "target", "method"},
+		),
+		grpcClientBreakerTransitions: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_client_circuit_breaker_transitions_total",
+				Help: "Total number of gRPC client circuit breaker state changes",
+			},
+			[]string{"service", "target", "method", "state"},
+		),
+		grpcClientDeadlineExceeded: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_client_deadline_exceeded_total",
+				Help: "Total number of gRPC client calls that exceeded their deadline",
+			},
+			[]string{"service", "target", "method"},
+		),
+		kafkaConsumedTotal: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "kafka_consumer_messages_total",
+				Help: "Total number of consumed Kafka messages",
+			},
+			[]string{"service", "topic", "status"},
+		),
+		kafkaProcessingDuration: f.histogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kafka_consumer_processing_duration_seconds",
+				Help:    "Kafka message processing duration in seconds",
+				Buckets: f.durationBuckets(DefaultBuckets),
+			},
+			[]string{"service", "topic"},
+		),
+		kafkaConsumerLag: f.gaugeVec(
+			prometheus.GaugeOpts{
+				Name: "kafka_consumer_lag",
+				Help: "Kafka consumer lag in messages per partition",
+			},
+			[]string{"service", "topic", "partition"},
+		),
+		kafkaCommitFailuresTotal: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "kafka_consumer_commit_failures_total",
+				Help: "Total number of failed Kafka offset commits",
+			},
+			[]string{"service", "topic"},
+		),
+		kafkaWriteDuration: f.histogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kafka_producer_write_duration_seconds",
+				Help:    "Kafka producer write duration in seconds",
+				Buckets: f.durationBuckets(DefaultBuckets),
+			},
+			[]string{"service", "topic"},
+		),
+		kafkaWriteBatchSize: f.histogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kafka_producer_batch_size",
+				Help:    "Number of messages per Kafka producer write",
+				Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+			},
+			[]string{"service", "topic"},
+		),
+		kafkaWriteErrorsTotal: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "kafka_producer_errors_total",
+				Help: "Total number of failed Kafka producer writes",
+			},
+			[]string{"service", "topic"},
+		),
+		redisCommandDuration: f.histogramVec(
+			prometheus.HistogramOpts{
+				Name:    "redis_command_duration_seconds",
+				Help:    "Redis command duration in seconds",
+				Buckets: f.durationBuckets(redisBuckets),
+			},
+			[]string{"service", "command"},
+		),
+		redisErrorsTotal: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "redis_command_errors_total",
+				Help: "Total number of failed Redis commands",
+			},
+			[]string{"service", "command"},
+		),
+		postgresQueryDuration: f.histogramVec(
+			prometheus.HistogramOpts{
+				Name:    "postgres_query_duration_seconds",
+				Help:    "PostgreSQL query duration in seconds",
+				Buckets: f.durationBuckets(DefaultBuckets),
+			},
+			[]string{"service", "query"},
+		),
+		postgresErrorsTotal: f.counterVec(
+			prometheus.CounterOpts{
+				Name: "postgres_query_errors_total",
+				Help: "Total number of failed PostgreSQL queries",
+			},
+			[]string{"service", "query", "code"},
+		),
 	}
 }
 
@@ -106,6 +304,86 @@ func (m *Metrics) RecordGRPCRequest(method, status string, duration time.Duratio
 	}
 }
 
+// RecordGRPCStream records finished gRPC stream
+func (m *Metrics) RecordGRPCStream(method, status string, duration time.Duration) {
+	m.grpcRequestsTotal.WithLabelValues(m.serviceName, method, status).Inc()
+	m.grpcStreamDuration.WithLabelValues(m.serviceName, method).Observe(duration.Seconds())
+
+	if status != "OK" {
+		m.grpcErrorsTotal.WithLabelValues(m.serviceName, method, status).Inc()
+	}
+}
+
+// RecordGRPCClientRequest records gRPC client call metrics
+func (m *Metrics) RecordGRPCClientRequest(target, method, status string, duration time.Duration) {
+	m.grpcClientRequestsTotal.WithLabelValues(m.serviceName, target, method, status).Inc()
+	m.grpcClientRequestDuration.WithLabelValues(m.serviceName, target, method).Observe(duration.Seconds())
+}
+
+// RecordKafkaMessage records consumed Kafka message processing
+func (m *Metrics) RecordKafkaMessage(topic string, success bool, duration time.Duration) {
+	status := "success"
+	if !success {
+		status = "error"
+	}
+	m.kafkaConsumedTotal.WithLabelValues(m.serviceName, topic, status).Inc()
+	m.kafkaProcessingDuration.WithLabelValues(m.serviceName, topic).Observe(duration.Seconds())
+}
+
+// RecordKafkaLag records consumer lag of a partition
+func (m *Metrics) RecordKafkaLag(topic string, partition int, lag int64) {
+	m.kafkaConsumerLag.WithLabelValues(m.serviceName, topic, strconv.Itoa(partition)).Set(float64(lag))
+}
+
+// RecordKafkaCommitFailure records failed offset commit
+func (m *Metrics) RecordKafkaCommitFailure(topic string) {
+	m.kafkaCommitFailuresTotal.WithLabelValues(m.serviceName, topic).Inc()
+}
+
+// RecordKafkaWrite records Kafka producer write
+func (m *Metrics) RecordKafkaWrite(topic string, messages int, duration time.Duration, err error) {
+	m.kafkaWriteDuration.WithLabelValues(m.serviceName, topic).Observe(duration.Seconds())
+	m.kafkaWriteBatchSize.WithLabelValues(m.serviceName, topic).Observe(float64(messages))
+	if err != nil {
+		m.kafkaWriteErrorsTotal.WithLabelValues(m.serviceName, topic).Inc()
+	}
+}
+
+// RecordRedisCommand records Redis command or pipeline execution
+func (m *Metrics) RecordRedisCommand(command string, duration time.Duration, err error) {
+	m.redisCommandDuration.WithLabelValues(m.serviceName, command).Observe(duration.Seconds())
+	if err != nil {
+		m.redisErrorsTotal.WithLabelValues(m.serviceName, command).Inc()
+	}
+}
+
+// RecordPostgresQuery records PostgreSQL query execution, code is SQLSTATE
+// of the failure ("" for success, "unknown" for non-PostgreSQL errors)
+func (m *Metrics) RecordPostgresQuery(query string, duration time.Duration, code string) {
+	m.postgresQueryDuration.WithLabelValues(m.serviceName, query).Observe(duration.Seconds())
+	if code != "" {
+		m.postgresErrorsTotal.WithLabelValues(m.serviceName, query, code).Inc()
+	}
+}
+
+// circuitBreakerStates maps breaker state names to gauge values
+var circuitBreakerStates = map[string]float64{
+	"closed":    0,
+	"half_open": 1,
+	"open":      2,
+}
+
+// RecordCircuitBreakerState records gRPC client circuit breaker state change
+func (m *Metrics) RecordCircuitBreakerState(target, method, state string) {
+	m.grpcClientBreakerState.WithLabelValues(m.serviceName, target, method).Set(circuitBreakerStates[state])
+	m.grpcClientBreakerTransitions.WithLabelValues(m.serviceName, target, method, state).Inc()
+}
+
+// RecordDeadlineExceeded records gRPC client call that exceeded its deadline
+func (m *Metrics) RecordDeadlineExceeded(target, method string) {
+	m.grpcClientDeadlineExceeded.WithLabelValues(m.serviceName, target, method).Inc()
+}
+
 // HTTPMetricsMiddleware wraps HTTP handler with metrics collection
 func (m *Metrics) HTTPMetricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -117,7 +395,8 @@ func (m *Metrics) HTTPMetricsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(rw, r)
 
 		duration := time.Since(start)
-		endpoint := r.URL.Path
+		// Raw path would create a series per ID
+		endpoint := m.endpoint(r)
 		method := r.Method
 
 		m.RecordHTTPRequest(method, endpoint, rw.statusCode, duration)
@@ -151,6 +430,10 @@ func (m *Metrics) GRPCMetricsInterceptor() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
+		inFlight := m.grpcInFlight.WithLabelValues(m.serviceName, info.FullMethod, "unary")
+		inFlight.Inc()
+		defer inFlight.Dec()
+
 		start := time.Now()
 
 		resp, err := handler(ctx, req)
@@ -180,7 +463,87 @@ func (m *Metrics) GRPCMetricsInterceptor() grpc.UnaryServerInterceptor {
 	}
 }
 
+// GRPCStreamMetricsInterceptor creates a gRPC stream interceptor for metrics
+func (m *Metrics) GRPCStreamMetricsInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		method := info.FullMethod
+		inFlight := m.grpcInFlight.WithLabelValues(m.serviceName, method, "stream")
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+
+		err := handler(srv, &metricsServerStream{
+			ServerStream: ss,
+			sent:         m.grpcStreamMessages.WithLabelValues(m.serviceName, method, "sent"),
+			received:     m.grpcStreamMessages.WithLabelValues(m.serviceName, method, "received"),
+		})
+
+		m.RecordGRPCStream(method, status.Code(err).String(), time.Since(start))
+
+		return err
+	}
+}
+
+// metricsServerStream counts stream messages
+type metricsServerStream struct {
+	grpc.ServerStream
+	sent     prometheus.Counter
+	received prometheus.Counter
+}
+
+func (s *metricsServerStream) SendMsg(msg any) error {
+	err := s.ServerStream.SendMsg(msg)
+	if err == nil {
+		s.sent.Inc()
+	}
+	return err
+}
+
+func (s *metricsServerStream) RecvMsg(msg any) error {
+	err := s.ServerStream.RecvMsg(msg)
+	if err == nil {
+		s.received.Inc()
+	}
+	return err
+}
+
+// GRPCClientMetricsInterceptor creates a gRPC client interceptor for metrics
+func (m *Metrics) GRPCClientMetricsInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		m.RecordGRPCClientRequest(cc.Target(), method, status.Code(err).String(), time.Since(start))
+
+		return err
+	}
+}
+
+// ServiceName returns service name metrics are labeled with
+func (m *Metrics) ServiceName() string {
+	return m.serviceName
+}
+
 // Handler returns the Prometheus metrics handler for /metrics endpoint
 func Handler() http.Handler {
 	return promhttp.Handler()
 }
+
+// Handler returns the Prometheus metrics handler serving metrics registry
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	recv int
+}
+
+func (s *fakeServerStream) Context() context.Context { return context.Background() }
+func (s *fakeServerStream) SendMsg(any) error        { return nil }
+func (s *fakeServerStream) RecvMsg(any) error {
+	if s.recv == 0 {
+		return errors.New("EOF")
+	}
+	s.recv--
+	return nil
+}
+
+func TestGRPCStreamMetricsInterceptor(t *testing.T) {
+	m := NewWithConfig("orders", Config{Registry: prometheus.NewRegistry(), SkipRuntimeCollectors: true})
+	interceptor := m.GRPCStreamMetricsInterceptor()
+	method := "/orders.v1.Orders/Watch"
+
+	err := interceptor(nil, &fakeServerStream{recv: 2}, &grpc.StreamServerInfo{FullMethod: method},
+		func(_ any, ss grpc.ServerStream) error {
+			if got := testutil.ToFloat64(m.grpcInFlight.WithLabelValues("orders", method, "stream")); got != 1 {
+				t.Errorf("in flight = %v, want 1", got)
+			}
+			for ss.RecvMsg(nil) == nil {
+				if err := ss.SendMsg(nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for direction, want := range map[string]float64{"sent": 2, "received": 2} {
+		if got := testutil.ToFloat64(m.grpcStreamMessages.WithLabelValues("orders", method, direction)); got != want {
+			t.Errorf("%s = %v, want %v", direction, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(m.grpcInFlight.WithLabelValues("orders", method, "stream")); got != 0 {
+		t.Errorf("in flight after stream = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(m.grpcRequestsTotal.WithLabelValues("orders", method, "OK")); got != 1 {
+		t.Errorf("requests = %v, want 1", got)
+	}
+}
+
+func TestDomainMetrics(t *testing.T) {
+	m := NewWithConfig("orders", Config{Registry: prometheus.NewRegistry(), SkipRuntimeCollectors: true})
+
+	m.Counter("orders_created_total", "status").WithLabelValues("paid").Inc()
+	m.Counter("orders_created_total", "status").WithLabelValues("paid").Inc()
+	if got := testutil.ToFloat64(m.Counter("orders_created_total", "status").WithLabelValues("paid")); got != 2 {
+		t.Errorf("orders_created_total = %v, want 2", got)
+	}
+
+	m.Histogram("order_amount", []float64{10, 100}).WithLabelValues().Observe(50)
+	m.Gauge("orders_pending").WithLabelValues().Set(3)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("reusing name with another type did not panic")
+		}
+	}()
+	m.Gauge("orders_created_total", "status")
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/tracing"
+	prombridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.28.0"
+	"go.uber.org/zap"
+)
+
+// Push modes
+const (
+	PushModeNone        = ""
+	PushModePushgateway = "pushgateway"
+	PushModeOTLP        = "otlp"
+)
+
+// PushConfig configures pushing metrics for jobs that can't be scraped
+type PushConfig struct {
+	// Mode - "" (scrape only), "pushgateway" or "otlp"
+	Mode     string        `yaml:"mode" env:"METRICS_PUSH_MODE"`
+	Interval time.Duration `yaml:"interval" env:"METRICS_PUSH_INTERVAL" env-default:"15s"`
+	Timeout  time.Duration `yaml:"timeout" env:"METRICS_PUSH_TIMEOUT" env-default:"10s"`
+
+	// PushgatewayURL, e.g. http://pushgateway:9091
+	PushgatewayURL string `yaml:"pushgateway_url" env:"METRICS_PUSHGATEWAY_URL"`
+	// Job defaults to service name, Instance to hostname
+	Job      string `yaml:"job" env:"METRICS_PUSH_JOB"`
+	Instance string `yaml:"instance" env:"METRICS_PUSH_INSTANCE"`
+
+	// OTLPEndpoint - OTLP gRPC collector, usually the one from tracing.Config
+	OTLPEndpoint string `yaml:"otlp_endpoint" env:"METRICS_OTLP_ENDPOINT" env-default:"localhost:4317"`
+	OTLPInsecure bool   `yaml:"otlp_insecure" env:"METRICS_OTLP_INSECURE" env-default:"true"`
+}
+
+// pusher pushes gathered metrics to a remote backend
+type pusher interface {
+	push(ctx context.Context) error
+	close(ctx context.Context) error
+}
+
+// Push pushes metrics once, e.g. at the end of a batch job
+func (m *Metrics) Push(ctx context.Context) error {
+	p, err := m.newPusher(ctx)
+	if err != nil {
+		return err
+	}
+
+	pushCtx, cancel := context.WithTimeout(ctx, m.push.Timeout)
+	defer cancel()
+	err = p.push(pushCtx)
+	if closeErr := p.close(pushCtx); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// StartPush pushes metrics every PushConfig.Interval until the returned
+// stop function is called. Stop pushes the final values.
+func (m *Metrics) StartPush(ctx context.Context) (func(), error) {
+	p, err := m.newPusher(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(m.push.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.pushOnce(ctx, p)
+			}
+		}
+	}()
+
+	logger.Info("Metrics push started",
+		zap.String("mode", m.push.Mode),
+		zap.Duration("interval", m.push.Interval),
+	)
+
+	return func() {
+		cancel()
+		<-done
+
+		// Final push so short jobs don't lose their last values
+		finalCtx, finalCancel := context.WithTimeout(context.Background(), m.push.Timeout)
+		defer finalCancel()
+		m.pushOnce(finalCtx, p)
+		if err := p.close(finalCtx); err != nil {
+			logger.Warn("failed to close metrics pusher", zap.Error(err))
+		}
+	}, nil
+}
+
+// pushOnce pushes with timeout logging failures
+func (m *Metrics) pushOnce(ctx context.Context, p pusher) {
+	ctx, cancel := context.WithTimeout(ctx, m.push.Timeout)
+	defer cancel()
+	if err := p.push(ctx); err != nil {
+		logger.Warn("metrics push failed", zap.String("mode", m.push.Mode), zap.Error(err))
+	}
+}
+
+// newPusher creates pusher for configured mode
+func (m *Metrics) newPusher(ctx context.Context) (pusher, error) {
+	switch strings.ToLower(m.push.Mode) {
+	case PushModePushgateway:
+		return m.newPushgatewayPusher()
+	case PushModeOTLP:
+		return m.newOTLPPusher(ctx)
+	default:
+		return nil, fmt.Errorf("metrics push mode %q is not supported", m.push.Mode)
+	}
+}
+
+// pushgatewayPusher replaces the job/instance group on every push
+type pushgatewayPusher struct {
+	pusher *push.Pusher
+}
+
+func (m *Metrics) newPushgatewayPusher() (pusher, error) {
+	if m.push.PushgatewayURL == "" {
+		return nil, fmt.Errorf("pushgateway url is required")
+	}
+
+	job := m.push.Job
+	if job == "" {
+		job = m.serviceName
+	}
+	instance := m.push.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	p := push.New(m.push.PushgatewayURL, job).Gatherer(m.gatherer)
+	if instance != "" {
+		p = p.Grouping("instance", instance)
+	}
+	return &pushgatewayPusher{pusher: p}, nil
+}
+
+func (p *pushgatewayPusher) push(ctx context.Context) error {
+	return p.pusher.PushContext(ctx)
+}
+
+func (p *pushgatewayPusher) close(context.Context) error {
+	return nil
+}
+
+// otlpPusher exports registry contents via OTLP
+type otlpPusher struct {
+	exporter *otlpmetricgrpc.Exporter
+	reader   *sdkmetric.ManualReader
+	provider *sdkmetric.MeterProvider
+}
+
+func (m *Metrics) newOTLPPusher(ctx context.Context) (pusher, error) {
+	endpoint := m.push.OTLPEndpoint
+	if endpoint == "" {
+		endpoint = tracing.DefaultOTLPEndpoint
+	}
+
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(endpoint)}
+	if m.push.OTLPInsecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	exporter, err := otlpmetricgrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP metrics exporter: %w", err)
+	}
+
+	// Bridge converts everything registered on the Prometheus registry
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(prombridge.NewMetricProducer(prombridge.WithGatherer(m.gatherer))))
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(m.serviceName))),
+	)
+	return &otlpPusher{exporter: exporter, reader: reader, provider: provider}, nil
+}
+
+func (p *otlpPusher) push(ctx context.Context) error {
+	var rm metricdata.ResourceMetrics
+	if err := p.reader.Collect(ctx, &rm); err != nil {
+		return fmt.Errorf("collect metrics: %w", err)
+	}
+	return p.exporter.Export(ctx, &rm)
+}
+
+func (p *otlpPusher) close(ctx context.Context) error {
+	if err := p.provider.Shutdown(ctx); err != nil {
+		return err
+	}
+	return p.exporter.Shutdown(ctx)
+}
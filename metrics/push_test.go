@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPushgateway(t *testing.T) {
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	m := NewWithConfig("report-job", Config{
+		Registry:              prometheus.NewRegistry(),
+		SkipRuntimeCollectors: true,
+		Push:                  PushConfig{Mode: PushModePushgateway, PushgatewayURL: server.URL, Instance: "worker-1"},
+	})
+	m.Counter("reports_generated_total").WithLabelValues().Inc()
+
+	if err := m.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/report-job/instance/worker-1" {
+		t.Errorf("got %s %s", method, path)
+	}
+}
+
+func TestPushUnsupportedMode(t *testing.T) {
+	m := NewWithConfig("report-job", Config{Registry: prometheus.NewRegistry(), SkipRuntimeCollectors: true})
+	if _, err := m.StartPush(context.Background()); err == nil {
+		t.Error("StartPush without mode succeeded")
+	}
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// RouteResolver returns route template of a served request, e.g. /users/{id}.
+// It is called after the handler, so router state is available:
+//
+//	chi:     chi.RouteContext(r.Context()).RoutePattern()
+//	gorilla: mux.CurrentRoute(r).GetPathTemplate()
+//
+// Empty result falls back to http.ServeMux pattern and then NormalizePath.
+type RouteResolver func(r *http.Request) string
+
+// PathRule replaces path segments matching Pattern with Placeholder
+type PathRule struct {
+	Pattern     *regexp.Regexp
+	Placeholder string
+}
+
+// DefaultPathRules collapse numeric IDs, UUIDs and long hex tokens
+var DefaultPathRules = []PathRule{
+	{Pattern: regexp.MustCompile(`^\d+$`), Placeholder: "{id}"},
+	{Pattern: regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`), Placeholder: "{uuid}"},
+	{Pattern: regexp.MustCompile(`^[0-9a-fA-F]{16,}$`), Placeholder: "{hash}"},
+}
+
+// NormalizePath replaces ID-like segments using rules, DefaultPathRules if
+// none given: /users/12345/orders -> /users/{id}/orders
+func NormalizePath(path string, rules ...PathRule) string {
+	if len(rules) == 0 {
+		rules = DefaultPathRules
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		for _, rule := range rules {
+			if segment != "" && rule.Pattern.MatchString(segment) {
+				segments[i] = rule.Placeholder
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// endpoint returns bounded endpoint label for request
+func (m *Metrics) endpoint(r *http.Request) string {
+	if m.routeResolver != nil {
+		if route := m.routeResolver(r); route != "" {
+			return route
+		}
+	}
+	// Set by http.ServeMux, "GET /users/{id}" - method has its own label
+	if r.Pattern != "" {
+		if _, route, ok := strings.Cut(r.Pattern, " "); ok {
+			return route
+		}
+		return r.Pattern
+	}
+	return NormalizePath(r.URL.Path, m.pathRules...)
+}
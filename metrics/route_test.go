@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/users/12345":          "/users/{id}",
+		"/users/12345/orders/7": "/users/{id}/orders/{id}",
+		"/files/3f2504e0-4f89-11d3-9a0c-0305e82c3301": "/files/{uuid}",
+		"/blobs/9f86d081884c7d659a2feaa0c55ad015":     "/blobs/{hash}",
+		"/v1/health": "/v1/health",
+		"/":          "/",
+	} {
+		if got := NormalizePath(path); got != want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	m := &Metrics{}
+
+	mux := http.NewServeMux()
+	var got string
+	mux.HandleFunc("GET /users/{id}", func(_ http.ResponseWriter, r *http.Request) {
+		got = m.endpoint(r)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if got != "/users/{id}" {
+		t.Errorf("ServeMux endpoint = %q, want /users/{id}", got)
+	}
+
+	m.routeResolver = func(*http.Request) string { return "/custom/{id}" }
+	if got := m.endpoint(httptest.NewRequest(http.MethodGet, "/custom/1", nil)); got != "/custom/{id}" {
+		t.Errorf("resolver endpoint = %q, want /custom/{id}", got)
+	}
+
+	m.routeResolver = func(*http.Request) string { return "" }
+	if got := m.endpoint(httptest.NewRequest(http.MethodGet, "/orders/99", nil)); got != "/orders/{id}" {
+		t.Errorf("fallback endpoint = %q, want /orders/{id}", got)
+	}
+}
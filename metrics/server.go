@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// MetricsServerConfig holds standalone metrics HTTP server configuration
+type MetricsServerConfig struct {
+	Port int    `yaml:"port" env:"METRICS_PORT" env-default:"9090"`
+	Path string `yaml:"path" env:"METRICS_PATH" env-default:"/metrics"`
+	// EnablePprof - serve net/http/pprof handlers on /debug/pprof/
+	EnablePprof     bool          `yaml:"enable_pprof" env:"METRICS_ENABLE_PPROF" env-default:"false"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"METRICS_SHUTDOWN_TIMEOUT" env-default:"5s"`
+
+	// Metrics - serve this instance registry instead of the default one
+	Metrics *Metrics `yaml:"-"`
+}
+
+// Addr returns listen address
+func (c *MetricsServerConfig) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// Serve serves /metrics and /healthz on addr until ctx is cancelled
+func Serve(ctx context.Context, addr string) error {
+	return serve(ctx, addr, MetricsServerConfig{})
+}
+
+// ServeConfig serves metrics server configured by cfg until ctx is cancelled
+func ServeConfig(ctx context.Context, cfg MetricsServerConfig) error {
+	return serve(ctx, cfg.Addr(), cfg)
+}
+
+// ServerHandler returns handler with /metrics, /healthz and optional pprof routes
+func ServerHandler(cfg MetricsServerConfig) http.Handler {
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+
+	metricsHandler := Handler()
+	if cfg.Metrics != nil {
+		metricsHandler = cfg.Metrics.Handler()
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+cfg.Path, metricsHandler)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	if cfg.EnablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+func serve(ctx context.Context, addr string, cfg MetricsServerConfig) error {
+	// Apply defaults if not set
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 5 * time.Second
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", addr, err)
+	}
+
+	server := &http.Server{
+		Handler:           ServerHandler(cfg),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+	logger.Info("Metrics server started",
+		zap.String("addr", listener.Addr().String()),
+		zap.Bool("pprof", cfg.EnablePprof),
+	)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("metrics server: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown metrics server: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server: %w", err)
+	}
+	logger.Info("Metrics server stopped")
+	return nil
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Objective is a service level objective, e.g. 99.9% of RPCs succeed
+// within 300ms. Burn rate is 1 - good/total over a window divided by
+// 1 - Target.
+type Objective struct {
+	Name string
+	// Target - required share of good events, e.g. 0.999
+	Target float64
+	// Latency - events slower than this are bad, 0 checks errors only
+	Latency time.Duration
+	// IsError reports whether err burns the error budget, any error by default
+	IsError func(err error) bool
+}
+
+// good reports whether event meets the objective
+func (o *Objective) good(duration time.Duration, err error) bool {
+	if o.Latency > 0 && duration > o.Latency {
+		return false
+	}
+	if err == nil {
+		return true
+	}
+	if o.IsError != nil {
+		return !o.IsError(err)
+	}
+	return false
+}
+
+// SLO records good and total events per objective:
+// slo_events_total, slo_good_events_total and slo_objective_target
+type SLO struct {
+	objectives map[string]Objective
+	total      *prometheus.CounterVec
+	good       *prometheus.CounterVec
+}
+
+// NewSLO creates SLO recorder for objectives
+func (m *Metrics) NewSLO(objectives ...Objective) *SLO {
+	s := &SLO{
+		objectives: make(map[string]Objective, len(objectives)),
+		total: domainCollector(m, "slo_events_total", "counter", []string{"objective"}, func() *prometheus.CounterVec {
+			return m.factory.counterVec(prometheus.CounterOpts{
+				Name: "slo_events_total",
+				Help: "Total number of events covered by service level objective",
+			}, []string{"service", "objective"})
+		}).MustCurryWith(prometheus.Labels{"service": m.serviceName}),
+		good: domainCollector(m, "slo_good_events_total", "counter", []string{"objective"}, func() *prometheus.CounterVec {
+			return m.factory.counterVec(prometheus.CounterOpts{
+				Name: "slo_good_events_total",
+				Help: "Total number of events meeting service level objective",
+			}, []string{"service", "objective"})
+		}).MustCurryWith(prometheus.Labels{"service": m.serviceName}),
+	}
+	target := domainCollector(m, "slo_objective_target", "gauge", []string{"objective", "latency"}, func() *prometheus.GaugeVec {
+		return m.factory.gaugeVec(prometheus.GaugeOpts{
+			Name: "slo_objective_target",
+			Help: "Service level objective target ratio",
+		}, []string{"service", "objective", "latency"})
+	}).MustCurryWith(prometheus.Labels{"service": m.serviceName})
+
+	for _, o := range objectives {
+		s.objectives[o.Name] = o
+		target.WithLabelValues(o.Name, o.Latency.String()).Set(o.Target)
+		// Export zero series so ratios are defined before the first event
+		s.total.WithLabelValues(o.Name)
+		s.good.WithLabelValues(o.Name)
+	}
+	return s
+}
+
+// Record records event against objective
+func (s *SLO) Record(objective string, duration time.Duration, err error) {
+	o, ok := s.objectives[objective]
+	if !ok {
+		logger.Warn("unknown SLO objective", zap.String("objective", objective))
+		return
+	}
+
+	s.total.WithLabelValues(objective).Inc()
+	if o.good(duration, err) {
+		s.good.WithLabelValues(objective).Inc()
+	}
+}
+
+// RecordAll records event against every objective
+func (s *SLO) RecordAll(duration time.Duration, err error) {
+	for name := range s.objectives {
+		s.Record(name, duration, err)
+	}
+}
+
+// GRPCInterceptor records every unary RPC against all objectives. Client
+// errors (InvalidArgument, NotFound, ...) don't burn the error budget unless
+// the objective sets IsError.
+func (s *SLO) GRPCInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		duration := time.Since(start)
+
+		for name, o := range s.objectives {
+			objectiveErr := err
+			if o.IsError == nil && !IsServerError(err) {
+				objectiveErr = nil
+			}
+			s.Record(name, duration, objectiveErr)
+		}
+		return resp, err
+	}
+}
+
+// IsServerError reports whether gRPC error is caused by the server
+func IsServerError(err error) bool {
+	switch status.Code(err) {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.FailedPrecondition, codes.OutOfRange, codes.Unauthenticated:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSLO(t *testing.T) {
+	m := NewWithConfig("orders", Config{Registry: prometheus.NewRegistry(), SkipRuntimeCollectors: true})
+	slo := m.NewSLO(
+		Objective{Name: "availability", Target: 0.999},
+		Objective{Name: "latency", Target: 0.99, Latency: 300 * time.Millisecond},
+	)
+
+	slo.RecordAll(100*time.Millisecond, nil)
+	slo.RecordAll(500*time.Millisecond, nil)
+	slo.RecordAll(100*time.Millisecond, errors.New("boom"))
+
+	for objective, want := range map[string]float64{"availability": 2, "latency": 1} {
+		if got := testutil.ToFloat64(slo.good.WithLabelValues(objective)); got != want {
+			t.Errorf("%s good = %v, want %v", objective, got, want)
+		}
+		if got := testutil.ToFloat64(slo.total.WithLabelValues(objective)); got != 3 {
+			t.Errorf("%s total = %v, want 3", objective, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultBulkBatchSize - rows per COPY or INSERT statement
+const DefaultBulkBatchSize = 5000
+
+// maxQueryParams is PostgreSQL limit of bind parameters per statement
+const maxQueryParams = 65535
+
+// BulkQuerier is a Querier supporting COPY, e.g. *Pool or pgx.Tx
+type BulkQuerier interface {
+	Querier
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// BulkInsert loads rows via COPY in batches of DefaultBulkBatchSize and
+// returns number of inserted rows. COPY fails on any constraint violation,
+// use BulkUpsert for tables with conflicts. Run it within a transaction
+// (pass pgx.Tx) if a failed batch must undo the previous ones.
+func BulkInsert(ctx context.Context, db BulkQuerier, table string, columns []string, rows [][]any) (int64, error) {
+	ident := tableIdentifier(table)
+
+	var total int64
+	for batch := range slices.Chunk(rows, DefaultBulkBatchSize) {
+		n, err := db.CopyFrom(ctx, ident, columns, pgx.CopyFromRows(batch))
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("copy into %s: %w", table, err)
+		}
+	}
+	return total, nil
+}
+
+// BulkUpsert inserts rows in batches with INSERT ... ON CONFLICT
+// (conflictColumns) DO UPDATE, setting the remaining columns from the new
+// row. If all columns are conflict columns, conflicting rows are skipped.
+// Returns number of inserted or updated rows.
+func BulkUpsert(ctx context.Context, db Querier, table string, columns, conflictColumns []string, rows [][]any) (int64, error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("no columns")
+	}
+	if len(conflictColumns) == 0 {
+		return 0, fmt.Errorf("no conflict columns")
+	}
+
+	batchSize := min(DefaultBulkBatchSize, maxQueryParams/len(columns))
+
+	var total int64
+	for batch := range slices.Chunk(rows, batchSize) {
+		args := make([]any, 0, len(batch)*len(columns))
+		for i, row := range batch {
+			if len(row) != len(columns) {
+				return total, fmt.Errorf("row %d has %d values, want %d", i, len(row), len(columns))
+			}
+			args = append(args, row...)
+		}
+
+		tag, err := db.Exec(ctx, upsertSQL(table, columns, conflictColumns, len(batch)), args...)
+		if err != nil {
+			return total, fmt.Errorf("upsert into %s: %w", table, err)
+		}
+		total += tag.RowsAffected()
+	}
+	return total, nil
+}
+
+// upsertSQL builds INSERT ... ON CONFLICT statement for n rows
+func upsertSQL(table string, columns, conflictColumns []string, n int) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(tableIdentifier(table).Sanitize())
+	b.WriteString(" (")
+	b.WriteString(joinIdentifiers(columns))
+	b.WriteString(") VALUES ")
+
+	param := 1
+	for i := range n {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range columns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(param))
+			param++
+		}
+		b.WriteByte(')')
+	}
+
+	b.WriteString(" ON CONFLICT (")
+	b.WriteString(joinIdentifiers(conflictColumns))
+	b.WriteString(") DO ")
+
+	var updates []string
+	for _, column := range columns {
+		if slices.Contains(conflictColumns, column) {
+			continue
+		}
+		ident := pgx.Identifier{column}.Sanitize()
+		updates = append(updates, ident+" = EXCLUDED."+ident)
+	}
+	if len(updates) == 0 {
+		b.WriteString("NOTHING")
+	} else {
+		b.WriteString("UPDATE SET ")
+		b.WriteString(strings.Join(updates, ", "))
+	}
+	return b.String()
+}
+
+// tableIdentifier splits optional schema, e.g. "billing.payments"
+func tableIdentifier(table string) pgx.Identifier {
+	return pgx.Identifier(strings.Split(table, "."))
+}
+
+// joinIdentifiers quotes and joins column names
+func joinIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = pgx.Identifier{name}.Sanitize()
+	}
+	return strings.Join(quoted, ", ")
+}
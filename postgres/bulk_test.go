@@ -0,0 +1,40 @@
+package postgres
+
+import "testing"
+
+func TestUpsertSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    string
+		columns  []string
+		conflict []string
+		rows     int
+		want     string
+	}{
+		{
+			name:     "update non-conflict columns",
+			table:    "billing.payments",
+			columns:  []string{"id", "amount", "status"},
+			conflict: []string{"id"},
+			rows:     2,
+			want: `INSERT INTO "billing"."payments" ("id", "amount", "status") VALUES ($1, $2, $3), ($4, $5, $6)` +
+				` ON CONFLICT ("id") DO UPDATE SET "amount" = EXCLUDED."amount", "status" = EXCLUDED."status"`,
+		},
+		{
+			name:     "skip conflicts when all columns are keys",
+			table:    "tags",
+			columns:  []string{"post_id", "tag"},
+			conflict: []string{"post_id", "tag"},
+			rows:     1,
+			want:     `INSERT INTO "tags" ("post_id", "tag") VALUES ($1, $2) ON CONFLICT ("post_id", "tag") DO NOTHING`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upsertSQL(tt.table, tt.columns, tt.conflict, tt.rows); got != tt.want {
+				t.Errorf("upsertSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestCluster(t *testing.T, replicas int) *Cluster {
+	t.Helper()
+
+	cfg := Config{Host: "127.0.0.1", Port: 1, User: "test", Password: "test", Database: "test", SSLMode: "disable", MaxConns: 1}
+	primary, err := newPool(context.Background(), cfg, cfg.DSN(), "primary")
+	if err != nil {
+		t.Fatalf("create primary: %v", err)
+	}
+
+	c := &Cluster{primary: primary}
+	for range replicas {
+		pool, err := newPool(context.Background(), cfg, cfg.DSN(), "replica")
+		if err != nil {
+			t.Fatalf("create replica: %v", err)
+		}
+		c.replicas = append(c.replicas, &replica{pool: pool, name: "replica"})
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestCluster_ReadPoolFallsBackToPrimary(t *testing.T) {
+	c := newTestCluster(t, 2)
+
+	if got := c.ReadPool(); got != c.Primary() {
+		t.Error("expected primary when no replica is healthy")
+	}
+}
+
+func TestCluster_ReadPoolRoundRobin(t *testing.T) {
+	c := newTestCluster(t, 3)
+	c.replicas[0].healthy.Store(true)
+	c.replicas[2].healthy.Store(true)
+
+	seen := make(map[*Pool]int)
+	for range 10 {
+		seen[c.ReadPool()]++
+	}
+
+	if seen[c.Primary()] != 0 || seen[c.replicas[1].pool] != 0 {
+		t.Errorf("expected only healthy replicas, got %v", seen)
+	}
+	if seen[c.replicas[0].pool] != 5 || seen[c.replicas[2].pool] != 5 {
+		t.Errorf("expected reads spread evenly, got %v", seen)
+	}
+}
+
+func TestCluster_CheckReplicasMarksUnreachableUnhealthy(t *testing.T) {
+	c := newTestCluster(t, 1)
+	c.replicas[0].healthy.Store(true)
+
+	c.checkReplicas(context.Background(), time.Second)
+
+	if c.replicas[0].healthy.Load() {
+		t.Error("expected unreachable replica to be unhealthy")
+	}
+	if got := c.ReadPool(); got != c.Primary() {
+		t.Error("expected reads to fall back to primary")
+	}
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	codeNotNullViolation     = "23502"
+	codeForeignKeyViolation  = "23503"
+	codeUniqueViolation      = "23505"
+	codeCheckViolation       = "23514"
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// detailKeyPattern extracts columns from "Key (email)=(a@b.c) already exists."
+var detailKeyPattern = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// ConstraintViolation describes violated constraint, e.g. to map
+// "users_email_key" to a field error
+type ConstraintViolation struct {
+	// Code - SQLSTATE, e.g. 23505 for unique violation
+	Code       string
+	Table      string
+	Constraint string
+	// Columns - violating columns, from the error or its detail message
+	Columns []string
+}
+
+// IsNotFound checks if error is "no rows" error
+func IsNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// IsDuplicate checks if error is duplicate key error
+func IsDuplicate(err error) bool {
+	return hasCode(err, codeUniqueViolation)
+}
+
+// IsForeignKeyViolation checks if error is foreign key violation
+func IsForeignKeyViolation(err error) bool {
+	return hasCode(err, codeForeignKeyViolation)
+}
+
+// IsCheckViolation checks if error is check constraint violation
+func IsCheckViolation(err error) bool {
+	return hasCode(err, codeCheckViolation)
+}
+
+// IsNotNullViolation checks if error is not null violation
+func IsNotNullViolation(err error) bool {
+	return hasCode(err, codeNotNullViolation)
+}
+
+// IsSerializationFailure checks if error is serialization failure
+func IsSerializationFailure(err error) bool {
+	return hasCode(err, codeSerializationFailure)
+}
+
+// IsDeadlock checks if error is deadlock
+func IsDeadlock(err error) bool {
+	return hasCode(err, codeDeadlockDetected)
+}
+
+// IsRetryable checks if transaction failed with error that is safe to retry
+func IsRetryable(err error) bool {
+	return IsSerializationFailure(err) || IsDeadlock(err)
+}
+
+// ParseConstraint returns violated constraint if err is an integrity
+// constraint violation (SQLSTATE class 23)
+func ParseConstraint(err error) (*ConstraintViolation, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || !strings.HasPrefix(pgErr.Code, "23") {
+		return nil, false
+	}
+
+	v := &ConstraintViolation{
+		Code:       pgErr.Code,
+		Table:      pgErr.TableName,
+		Constraint: pgErr.ConstraintName,
+	}
+	if pgErr.ColumnName != "" {
+		v.Columns = []string{pgErr.ColumnName}
+	} else if match := detailKeyPattern.FindStringSubmatch(pgErr.Detail); match != nil {
+		for _, column := range strings.Split(match[1], ",") {
+			v.Columns = append(v.Columns, strings.Trim(strings.TrimSpace(column), `"`))
+		}
+	}
+	return v, true
+}
+
+// hasCode checks if err wraps PostgreSQL error with code
+func hasCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestErrorHelpers_Wrapped(t *testing.T) {
+	wrap := func(code string) error {
+		return fmt.Errorf("create user: %w", &pgconn.PgError{Code: code})
+	}
+
+	if !IsDuplicate(wrap("23505")) {
+		t.Error("IsDuplicate() = false for wrapped unique violation")
+	}
+	if !IsCheckViolation(wrap("23514")) {
+		t.Error("IsCheckViolation() = false for wrapped check violation")
+	}
+	if !IsNotNullViolation(wrap("23502")) {
+		t.Error("IsNotNullViolation() = false for wrapped not null violation")
+	}
+	if IsForeignKeyViolation(wrap("23505")) {
+		t.Error("IsForeignKeyViolation() = true for unique violation")
+	}
+	if !IsNotFound(fmt.Errorf("get user: %w", pgx.ErrNoRows)) {
+		t.Error("IsNotFound() = false for wrapped ErrNoRows")
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *ConstraintViolation
+	}{
+		{
+			name: "unique violation columns from detail",
+			err: &pgconn.PgError{
+				Code:           "23505",
+				TableName:      "users",
+				ConstraintName: "users_email_key",
+				Detail:         "Key (email)=(a@example.com) already exists.",
+			},
+			want: &ConstraintViolation{Code: "23505", Table: "users", Constraint: "users_email_key", Columns: []string{"email"}},
+		},
+		{
+			name: "composite key",
+			err: &pgconn.PgError{
+				Code:           "23505",
+				ConstraintName: "members_team_id_user_id_key",
+				Detail:         "Key (team_id, user_id)=(1, 2) already exists.",
+			},
+			want: &ConstraintViolation{Code: "23505", Constraint: "members_team_id_user_id_key", Columns: []string{"team_id", "user_id"}},
+		},
+		{
+			name: "not null column",
+			err:  &pgconn.PgError{Code: "23502", TableName: "users", ColumnName: "name"},
+			want: &ConstraintViolation{Code: "23502", Table: "users", Columns: []string{"name"}},
+		},
+		{
+			name: "not a constraint violation",
+			err:  &pgconn.PgError{Code: "40001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseConstraint(tt.err)
+			if ok != (tt.want != nil) {
+				t.Fatalf("ParseConstraint() ok = %v, want %v", ok, tt.want != nil)
+			}
+			if tt.want == nil {
+				return
+			}
+			if got.Code != tt.want.Code || got.Table != tt.want.Table || got.Constraint != tt.want.Constraint ||
+				!slices.Equal(got.Columns, tt.want.Columns) {
+				t.Errorf("ParseConstraint() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// healthCheckTimeout bounds Healthy unless ctx has a shorter deadline
+const healthCheckTimeout = 2 * time.Second
+
+// Stats is a stable snapshot of pool statistics, see pgxpool.Stat
+type Stats struct {
+	MaxConns          int32
+	TotalConns        int32
+	IdleConns         int32
+	AcquiredConns     int32
+	ConstructingConns int32
+
+	// Cumulative counters since the pool was created
+	AcquireCount            int64
+	CanceledAcquireCount    int64
+	EmptyAcquireCount       int64
+	NewConnsCount           int64
+	MaxLifetimeDestroyCount int64
+	MaxIdleDestroyCount     int64
+	AcquireDuration         time.Duration
+	EmptyAcquireWaitTime    time.Duration
+}
+
+// Healthy checks that the database accepts queries: pings a connection and
+// runs SELECT 1. Suitable for readiness probes.
+func (p *Pool) Healthy(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	if err := p.Ping(ctx); err != nil {
+		return fmt.Errorf("ping database: %w", err)
+	}
+
+	var one int
+	if err := p.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("select 1: %w", err)
+	}
+	return nil
+}
+
+// Stats returns pool statistics
+func (p *Pool) Stats() Stats {
+	stat := p.Stat()
+	return Stats{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		IdleConns:               stat.IdleConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+		AcquireDuration:         stat.AcquireDuration(),
+		EmptyAcquireWaitTime:    stat.EmptyAcquireWaitTime(),
+	}
+}
+
+// Healthy checks the primary; unhealthy replicas only degrade reads to the primary
+func (c *Cluster) Healthy(ctx context.Context) error {
+	return c.primary.Healthy(ctx)
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPool_HealthyUnreachable(t *testing.T) {
+	cfg := Config{Host: "127.0.0.1", Port: 1, User: "test", Password: "test", Database: "test", SSLMode: "disable", MaxConns: 2}
+	p, err := newPool(context.Background(), cfg, cfg.DSN(), "test")
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(p.Close)
+
+	if err := p.Healthy(context.Background()); err == nil {
+		t.Error("expected error for unreachable database")
+	}
+
+	stats := p.Stats()
+	if stats.MaxConns != 2 {
+		t.Errorf("MaxConns = %d, want 2", stats.MaxConns)
+	}
+	if stats.AcquiredConns != 0 {
+		t.Errorf("AcquiredConns = %d, want 0", stats.AcquiredConns)
+	}
+}
@@ -0,0 +1,82 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5"
+
+	"gitlab.com/xakpro/cg-shared-libs/postgres"
+	"gitlab.com/xakpro/cg-shared-libs/postgres/postgrestest"
+)
+
+// Runs against a container or POSTGRES_TEST_DSN, skipped if neither is available
+
+var testMigrations = fstest.MapFS{
+	"migrations/000001_users.up.sql":   {Data: []byte("CREATE TABLE users (id bigserial PRIMARY KEY, email text NOT NULL UNIQUE);")},
+	"migrations/000001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+}
+
+type user struct {
+	ID    int64  `db:"id"`
+	Email string `db:"email"`
+}
+
+func TestIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test")
+	}
+	db := postgrestest.New(t, postgrestest.Options{MigrationsFS: testMigrations, MigrationsDir: "migrations"})
+	runner := postgres.NewRunner(db.Pool)
+
+	t.Run("migration status", func(t *testing.T) {
+		mg, err := postgres.NewMigratorFS(db.Config, testMigrations, "migrations")
+		if err != nil {
+			t.Fatalf("NewMigratorFS() error = %v", err)
+		}
+		defer mg.Close()
+
+		status, err := mg.Status(context.Background())
+		if err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+		if status.Version != 1 || status.Dirty || len(status.Pending) != 0 {
+			t.Errorf("Status() = %+v, want version 1 without pending", status)
+		}
+	})
+
+	t.Run("insert within test transaction", func(t *testing.T) {
+		ctx, _ := db.Tx(t)
+		if _, err := runner.Exec(ctx, "INSERT INTO users (email) VALUES ($1)", "a@example.com"); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+
+		// Savepoint failure doesn't abort the test transaction
+		err := db.Pool.WithNestedTx(ctx, func(ctx context.Context, _ pgx.Tx) error {
+			_, err := runner.Exec(ctx, "INSERT INTO users (email) VALUES ($1)", "a@example.com")
+			return err
+		})
+		if !postgres.IsDuplicate(err) {
+			t.Fatalf("expected duplicate error, got %v", err)
+		}
+
+		got, err := postgres.QueryOne[user](ctx, runner, "SELECT id, email FROM users WHERE email = $1", "a@example.com")
+		if err != nil {
+			t.Fatalf("QueryOne() error = %v", err)
+		}
+		if got.Email != "a@example.com" {
+			t.Errorf("email = %q, want a@example.com", got.Email)
+		}
+	})
+
+	t.Run("previous test rolled back", func(t *testing.T) {
+		users, err := postgres.QueryAll[user](context.Background(), runner, "SELECT id, email FROM users")
+		if err != nil {
+			t.Fatalf("QueryAll() error = %v", err)
+		}
+		if len(users) != 0 {
+			t.Errorf("users = %d, want 0", len(users))
+		}
+	})
+}
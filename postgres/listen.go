@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// Listen reconnect backoff bounds
+const (
+	listenInitialBackoff = 100 * time.Millisecond
+	listenMaxBackoff     = 30 * time.Second
+)
+
+// NotificationHandler handles notification received by Listen
+type NotificationHandler func(ctx context.Context, n *pgconn.Notification) error
+
+// Listen subscribes to channel on a dedicated connection and calls handler
+// for every notification until ctx is done. Lost connections are re-established
+// with backoff; notifications sent while disconnected are lost, so consumers
+// like caches should resync after a reconnect. Handler errors are logged.
+func (p *Pool) Listen(ctx context.Context, channel string, handler NotificationHandler) error {
+	backoff := listenInitialBackoff
+	for {
+		connected, err := p.listen(ctx, channel, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = listenInitialBackoff
+		}
+
+		logger.Warn("PostgreSQL listen connection lost, reconnecting",
+			zap.String("channel", channel),
+			zap.Duration("wait_time", backoff),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, listenMaxBackoff)
+	}
+}
+
+// ListenJSON is Listen decoding JSON payloads into T
+func ListenJSON[T any](ctx context.Context, p *Pool, channel string, handler func(ctx context.Context, payload T) error) error {
+	return p.Listen(ctx, channel, jsonHandler(handler))
+}
+
+// Notify sends notification to channel within q, e.g. inside a transaction
+// it is delivered on commit. Payload other than string or []byte is encoded
+// as JSON; PostgreSQL limits payload to 8000 bytes.
+func Notify(ctx context.Context, q Querier, channel string, payload any) error {
+	data, err := notifyPayload(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := q.Exec(ctx, "SELECT pg_notify($1, $2)", channel, data); err != nil {
+		return fmt.Errorf("notify %s: %w", channel, err)
+	}
+	return nil
+}
+
+// listen connects and dispatches notifications until connection fails or
+// ctx is done, connected reports whether LISTEN succeeded
+func (p *Pool) listen(ctx context.Context, channel string, handler NotificationHandler) (connected bool, err error) {
+	// Dedicated connection outside the pool, LISTEN state must not leak to pooled ones
+	conn, err := pgx.ConnectConfig(ctx, p.Config().ConnConfig.Copy())
+	if err != nil {
+		return false, fmt.Errorf("connect: %w", err)
+	}
+	defer func() {
+		_ = conn.Close(context.WithoutCancel(ctx))
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return false, fmt.Errorf("listen %s: %w", channel, err)
+	}
+	logger.Info("PostgreSQL listening", zap.String("channel", channel))
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("wait for notification: %w", err)
+		}
+		if err := handler(ctx, n); err != nil {
+			logger.WithContext(ctx).Error("notification handler failed",
+				zap.String("channel", n.Channel),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// jsonHandler adapts typed handler to NotificationHandler
+func jsonHandler[T any](handler func(ctx context.Context, payload T) error) NotificationHandler {
+	return func(ctx context.Context, n *pgconn.Notification) error {
+		var payload T
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		return handler(ctx, payload)
+	}
+}
+
+// notifyPayload encodes payload for pg_notify
+func notifyPayload(payload any) (string, error) {
+	switch v := payload.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("encode payload: %w", err)
+		}
+		return string(data), nil
+	}
+}
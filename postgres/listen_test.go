@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type invalidation struct {
+	Key string `json:"key"`
+}
+
+func TestJSONHandler(t *testing.T) {
+	var got invalidation
+	handler := jsonHandler(func(_ context.Context, payload invalidation) error {
+		got = payload
+		return nil
+	})
+
+	payload, err := notifyPayload(invalidation{Key: "user:42"})
+	if err != nil {
+		t.Fatalf("notifyPayload() error = %v", err)
+	}
+	if err := handler(context.Background(), &pgconn.Notification{Channel: "cache", Payload: payload}); err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if got.Key != "user:42" {
+		t.Errorf("decoded key = %q, want user:42", got.Key)
+	}
+
+	if err := handler(context.Background(), &pgconn.Notification{Payload: "not json"}); err == nil {
+		t.Error("expected error for invalid payload")
+	}
+}
+
+func TestNotifyPayload_String(t *testing.T) {
+	got, err := notifyPayload("user:42")
+	if err != nil || got != "user:42" {
+		t.Errorf("notifyPayload(string) = %q, %v, want raw string", got, err)
+	}
+}
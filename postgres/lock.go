@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// ErrLockNotAcquired is returned by Try* lock functions when the lock is held elsewhere
+var ErrLockNotAcquired = errors.New("postgres: lock not acquired")
+
+// LockKey derives advisory lock key from name, e.g. LockKey("billing:close-day")
+func LockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// AdvisoryLock is a session-level advisory lock holding a pool connection
+// until Unlock or cancellation of the context it was acquired with
+type AdvisoryLock struct {
+	conn *pgxpool.Conn
+	key  int64
+	stop func() bool
+
+	once sync.Once
+	err  error
+}
+
+// AdvisoryLock waits for session advisory lock on key. The lock is released
+// by Unlock or automatically when ctx is done.
+func (p *Pool) AdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	return p.advisoryLock(ctx, key, "SELECT true FROM pg_advisory_lock($1)")
+}
+
+// TryAdvisoryLock acquires session advisory lock on key without waiting,
+// returns ErrLockNotAcquired if it is held elsewhere
+func (p *Pool) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	return p.advisoryLock(ctx, key, "SELECT pg_try_advisory_lock($1)")
+}
+
+func (p *Pool) advisoryLock(ctx context.Context, key int64, sql string) (*AdvisoryLock, error) {
+	// Session locks belong to a connection, so it stays acquired while locked
+	conn, err := p.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, sql, key).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("advisory lock %d: %w", key, err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, ErrLockNotAcquired
+	}
+
+	l := &AdvisoryLock{conn: conn, key: key}
+	l.stop = context.AfterFunc(ctx, func() {
+		if err := l.unlock(context.WithoutCancel(ctx)); err != nil {
+			logger.Warn("failed to release advisory lock", zap.Int64("key", key), zap.Error(err))
+		}
+	})
+	return l, nil
+}
+
+// Key returns lock key
+func (l *AdvisoryLock) Key() int64 {
+	return l.key
+}
+
+// Unlock releases the lock and returns connection to the pool. Safe to
+// call more than once.
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	l.stop()
+	return l.unlock(ctx)
+}
+
+func (l *AdvisoryLock) unlock(ctx context.Context) error {
+	l.once.Do(func() {
+		if _, err := l.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+			// Closing the connection releases all its session locks
+			_ = l.conn.Conn().Close(ctx)
+			l.err = fmt.Errorf("advisory unlock %d: %w", l.key, err)
+		}
+		l.conn.Release()
+	})
+	return l.err
+}
+
+// TxAdvisoryLock waits for transaction advisory lock on key, released at
+// the end of the transaction q belongs to
+func TxAdvisoryLock(ctx context.Context, q Querier, key int64) error {
+	if _, err := q.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", key); err != nil {
+		return fmt.Errorf("advisory lock %d: %w", key, err)
+	}
+	return nil
+}
+
+// TryTxAdvisoryLock acquires transaction advisory lock on key without
+// waiting, returns ErrLockNotAcquired if it is held elsewhere
+func TryTxAdvisoryLock(ctx context.Context, q Querier, key int64) error {
+	var acquired bool
+	if err := q.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", key).Scan(&acquired); err != nil {
+		return fmt.Errorf("advisory lock %d: %w", key, err)
+	}
+	if !acquired {
+		return ErrLockNotAcquired
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package postgres
+
+import "testing"
+
+func TestLockKey(t *testing.T) {
+	if LockKey("billing:close-day") != LockKey("billing:close-day") {
+		t.Error("expected stable key for the same name")
+	}
+	if LockKey("billing:close-day") == LockKey("billing:open-day") {
+		t.Error("expected different keys for different names")
+	}
+}
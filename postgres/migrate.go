@@ -2,18 +2,23 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"io/fs"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
 	"gitlab.com/xakpro/cg-shared-libs/logger"
 	"go.uber.org/zap"
 )
 
-// RunMigrations applies database migrations with automatic dirty state recovery.
+// ErrDirtyMigration is returned when the last migration failed halfway
+var ErrDirtyMigration = errors.New("migration state is dirty")
+
+// RunMigrations applies database migrations. Concurrent callers (replicas
+// starting at once) are serialized with a PostgreSQL advisory lock, so only
+// one instance applies migrations while others wait.
 // It supports context cancellation and has a default timeout of 30 seconds.
 //
 // Parameters:
@@ -22,7 +27,8 @@ import (
 //   - migrationsPath: Path to migrations directory (relative or absolute)
 //
 // The function will:
-//   - Automatically fix dirty migration state by forcing version and rolling back
+//   - Fail on dirty migration state, or fix it by forcing version and rolling
+//     back if Config.MigrationsAutoFixDirty is set
 //   - Apply all pending migrations
 //   - Log detailed information about the migration process
 func RunMigrations(ctx context.Context, cfg Config, migrationsPath string) error {
@@ -30,30 +36,79 @@ func RunMigrations(ctx context.Context, cfg Config, migrationsPath string) error
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(migrationsPath)
+	mg, err := NewMigrator(cfg, migrationsPath)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	return withMigrationLock(ctx, cfg, func() error {
+		return runMigrations(ctx, mg.m, cfg.MigrationsAutoFixDirty)
+	})
+}
+
+// RunMigrationsFS applies migrations from dir of fsys, usually embed.FS,
+// so services don't have to ship the migrations directory:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	err := postgres.RunMigrationsFS(ctx, cfg, migrations, "migrations")
+//
+// Behaves like RunMigrations otherwise.
+func RunMigrationsFS(ctx context.Context, cfg Config, fsys fs.FS, dir string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	mg, err := NewMigratorFS(cfg, fsys, dir)
 	if err != nil {
-		return fmt.Errorf("resolve migrations path: %w", err)
+		return err
 	}
+	defer mg.Close()
 
-	// Create migrator
-	m, err := migrate.New(
-		fmt.Sprintf("file://%s", absPath),
-		cfg.DSN(),
-	)
+	return withMigrationLock(ctx, cfg, func() error {
+		return runMigrations(ctx, mg.m, cfg.MigrationsAutoFixDirty)
+	})
+}
+
+// withMigrationLock runs fn holding session advisory lock for cfg.Database.
+// The lock is released when the dedicated connection closes, even if
+// unlock fails.
+func withMigrationLock(ctx context.Context, cfg Config, fn func() error) error {
+	conn, err := pgx.Connect(ctx, cfg.DSN())
 	if err != nil {
-		return fmt.Errorf("init migrator: %w", err)
+		return fmt.Errorf("connect for migration lock: %w", err)
 	}
 	defer func() {
-		sourceErr, dbErr := m.Close()
-		if sourceErr != nil {
-			logger.Warn("failed to close migration source", zap.Error(sourceErr))
+		if err := conn.Close(context.WithoutCancel(ctx)); err != nil {
+			logger.Warn("failed to close migration lock connection", zap.Error(err))
 		}
-		if dbErr != nil {
-			logger.Warn("failed to close migration database", zap.Error(dbErr))
+	}()
+
+	key := migrationLockKey(cfg.Database)
+	start := time.Now()
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	logger.Info("migration lock acquired", zap.Duration("wait_time", time.Since(start)))
+
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			logger.Warn("failed to release migration lock", zap.Error(err))
 		}
 	}()
 
+	return fn()
+}
+
+// migrationLockKey derives advisory lock key for database. It differs from
+// the key golang-migrate locks internally, otherwise Up would wait for us.
+func migrationLockKey(database string) int64 {
+	return LockKey("cg-shared-libs/migrations:" + database)
+}
+
+// runMigrations checks dirty state and applies pending migrations
+func runMigrations(ctx context.Context, m *migrate.Migrate, autoFixDirty bool) error {
 	// Check for context cancellation
 	select {
 	case <-ctx.Done():
@@ -61,10 +116,14 @@ func RunMigrations(ctx context.Context, cfg Config, migrationsPath string) error
 	default:
 	}
 
-	// Auto-fix dirty state
+	// Auto-fix dirty state if enabled: force+rollback may run down migration
+	// of a partially applied one, so it is opt-in
 	var wasDirty bool
 	var dirtyVersion uint
 	if version, dirty, err := m.Version(); err == nil && dirty {
+		if !autoFixDirty {
+			return fmt.Errorf("%w: version %d, fix it manually and use Migrator.Force", ErrDirtyMigration, version)
+		}
 		logger.Warn("migration state is dirty, forcing current version",
 			zap.Uint("version", version),
 		)
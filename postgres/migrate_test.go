@@ -2,8 +2,12 @@ package postgres
 
 import (
 	"context"
+	"slices"
 	"testing"
+	"testing/fstest"
 	"time"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
 func TestRunMigrations_ContextCancellation(t *testing.T) {
@@ -96,3 +100,61 @@ func TestRunMigrations_DefaultTimeout(t *testing.T) {
 	// The important thing is that context handling works
 	_ = err // Error is expected in test environment
 }
+
+func TestRunMigrationsFS_InvalidDir(t *testing.T) {
+	cfg := Config{
+		Host:     "localhost",
+		Port:     5432,
+		User:     "test",
+		Password: "test",
+		Database: "test",
+		SSLMode:  "disable",
+	}
+
+	fsys := fstest.MapFS{
+		"migrations/000001_init.up.sql":   {Data: []byte("CREATE TABLE t (id int);")},
+		"migrations/000001_init.down.sql": {Data: []byte("DROP TABLE t;")},
+	}
+
+	err := RunMigrationsFS(context.Background(), cfg, fsys, "nonexistent")
+	if err == nil {
+		t.Fatal("expected error for missing directory, got nil")
+	}
+}
+
+func TestPendingVersions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/000001_init.up.sql":     {Data: []byte("CREATE TABLE t (id int);")},
+		"migrations/000002_users.up.sql":    {Data: []byte("CREATE TABLE users (id int);")},
+		"migrations/000003_orders.up.sql":   {Data: []byte("CREATE TABLE orders (id int);")},
+		"migrations/000003_orders.down.sql": {Data: []byte("DROP TABLE orders;")},
+	}
+	src, err := iofs.New(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("open source: %v", err)
+	}
+	defer src.Close()
+
+	tests := []struct {
+		name    string
+		current uint
+		applied bool
+		want    []uint
+	}{
+		{"nothing applied", 0, false, []uint{1, 2, 3}},
+		{"partially applied", 1, true, []uint{2, 3}},
+		{"up to date", 3, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pendingVersions(src, tt.current, tt.applied)
+			if err != nil {
+				t.Fatalf("pendingVersions() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pendingVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
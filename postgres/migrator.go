@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// MigrationStatus describes migration state of the database
+type MigrationStatus struct {
+	// Version - last applied migration, 0 if none (see Applied)
+	Version uint
+	Applied bool
+	// Dirty - last migration failed halfway and needs MigrateTo or Force
+	Dirty bool
+	// Pending - versions not applied yet in ascending order
+	Pending []uint
+}
+
+// Migrator runs migrations programmatically, e.g. to roll back or inspect
+// state from ops tooling. RunMigrations covers the usual startup case.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// NewMigrator creates migrator for migrations directory
+func NewMigrator(cfg Config, migrationsPath string) (*Migrator, error) {
+	// Resolve absolute path
+	absPath, err := filepath.Abs(migrationsPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve migrations path: %w", err)
+	}
+
+	src, err := source.Open(fmt.Sprintf("file://%s", absPath))
+	if err != nil {
+		return nil, fmt.Errorf("init migrator: %w", err)
+	}
+	return newMigrator(cfg, "file", src)
+}
+
+// NewMigratorFS creates migrator for dir of fsys, usually embed.FS
+func NewMigratorFS(cfg Config, fsys fs.FS, dir string) (*Migrator, error) {
+	src, err := iofs.New(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("open migrations source: %w", err)
+	}
+	return newMigrator(cfg, "iofs", src)
+}
+
+func newMigrator(cfg Config, sourceName string, src source.Driver) (*Migrator, error) {
+	m, err := migrate.NewWithSourceInstance(sourceName, src, cfg.DSN())
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("init migrator: %w", err)
+	}
+	return &Migrator{m: m, source: src}, nil
+}
+
+// MigrateUp applies all pending migrations
+func (mg *Migrator) MigrateUp(ctx context.Context) error {
+	return mg.run(ctx, "up", func(m *migrate.Migrate) error { return m.Up() })
+}
+
+// MigrateDown rolls back all migrations
+func (mg *Migrator) MigrateDown(ctx context.Context) error {
+	return mg.run(ctx, "down", func(m *migrate.Migrate) error { return m.Down() })
+}
+
+// MigrateSteps applies n migrations up, or rolls back -n migrations if n is negative
+func (mg *Migrator) MigrateSteps(ctx context.Context, n int) error {
+	return mg.run(ctx, "steps", func(m *migrate.Migrate) error { return m.Steps(n) })
+}
+
+// MigrateTo migrates up or down to version
+func (mg *Migrator) MigrateTo(ctx context.Context, version uint) error {
+	return mg.run(ctx, "migrate", func(m *migrate.Migrate) error { return m.Migrate(version) })
+}
+
+// Force sets version without running migrations and clears dirty flag.
+// Use it after fixing a failed migration manually.
+func (mg *Migrator) Force(ctx context.Context, version int) error {
+	return mg.run(ctx, "force", func(m *migrate.Migrate) error { return m.Force(version) })
+}
+
+// Status returns current version, dirty flag and pending migrations
+func (mg *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return MigrationStatus{}, err
+	}
+
+	var status MigrationStatus
+	version, dirty, err := mg.m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+	case err != nil:
+		return status, fmt.Errorf("read migration version: %w", err)
+	default:
+		status.Version, status.Dirty, status.Applied = version, dirty, true
+	}
+
+	status.Pending, err = pendingVersions(mg.source, status.Version, status.Applied)
+	if err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// Close closes migration source and database connection
+func (mg *Migrator) Close() {
+	sourceErr, dbErr := mg.m.Close()
+	if sourceErr != nil {
+		logger.Warn("failed to close migration source", zap.Error(sourceErr))
+	}
+	if dbErr != nil {
+		logger.Warn("failed to close migration database", zap.Error(dbErr))
+	}
+}
+
+// run executes migration operation, stopping gracefully after the current
+// migration when ctx is done
+func (mg *Migrator) run(ctx context.Context, op string, fn func(m *migrate.Migrate) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("migration cancelled: %w", err)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		select {
+		case mg.m.GracefulStop <- true:
+		default:
+		}
+	})
+	defer stop()
+
+	err := fn(mg.m)
+	if errors.Is(err, migrate.ErrNoChange) {
+		logger.Info("PostgreSQL migrations are up to date", zap.String("operation", op))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("migration %s: %w", op, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("migration cancelled: %w", err)
+	}
+
+	version, dirty, _ := mg.m.Version()
+	logger.Info("PostgreSQL migrations applied",
+		zap.String("operation", op),
+		zap.Uint("version", version),
+		zap.Bool("dirty", dirty),
+	)
+	return nil
+}
+
+// pendingVersions lists source versions after current
+func pendingVersions(src source.Driver, current uint, applied bool) ([]uint, error) {
+	var pending []uint
+	version, err := src.First()
+	for err == nil {
+		if !applied || version > current {
+			pending = append(pending, version)
+		}
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	return pending, nil
+}
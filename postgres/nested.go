@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// txContextKey stores active transaction in context
+type txContextKey struct{}
+
+// WithTxContext returns context carrying tx, see TxFromContext
+func WithTxContext(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns transaction active in ctx
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// Transactor runs functions within a transaction, joining the transaction
+// already active in ctx via savepoint. Repository methods accept it to
+// compose inside a service-level transaction.
+type Transactor interface {
+	WithNestedTx(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error
+}
+
+var (
+	_ Transactor = (*Pool)(nil)
+	_ Transactor = (*Cluster)(nil)
+)
+
+// WithNestedTx executes function within transaction. If ctx already carries
+// a transaction, a SAVEPOINT is created instead: an error rolls back to the
+// savepoint only and the outer transaction decides whether to commit.
+// The ctx passed to fn carries the transaction for further nesting.
+func (p *Pool) WithNestedTx(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	if outer, ok := TxFromContext(ctx); ok {
+		return nestedTx(ctx, outer, fn)
+	}
+
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	return runTx(ctx, tx, func(tx pgx.Tx) error {
+		return fn(WithTxContext(ctx, tx), tx)
+	})
+}
+
+// WithNestedTx executes function within transaction on the primary, see Pool.WithNestedTx
+func (c *Cluster) WithNestedTx(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	return c.primary.WithNestedTx(ctx, fn)
+}
+
+// nestedTx executes function within savepoint of outer transaction
+func nestedTx(ctx context.Context, outer pgx.Tx, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	// Begin on pgx.Tx creates a savepoint, Commit releases it and Rollback rolls back to it
+	sp, err := outer.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create savepoint: %w", err)
+	}
+	return runTx(ctx, sp, func(tx pgx.Tx) error {
+		return fn(WithTxContext(ctx, tx), tx)
+	})
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeTx records savepoint operations
+type fakeTx struct {
+	pgx.Tx
+	log   *[]string
+	depth int
+}
+
+func (f *fakeTx) Begin(context.Context) (pgx.Tx, error) {
+	*f.log = append(*f.log, "savepoint")
+	return &fakeTx{log: f.log, depth: f.depth + 1}, nil
+}
+
+func (f *fakeTx) Commit(context.Context) error {
+	*f.log = append(*f.log, "release")
+	return nil
+}
+
+func (f *fakeTx) Rollback(context.Context) error {
+	*f.log = append(*f.log, "rollback to savepoint")
+	return nil
+}
+
+func TestWithNestedTx_UsesSavepointOfOuterTx(t *testing.T) {
+	var log []string
+	outer := &fakeTx{log: &log}
+	ctx := WithTxContext(context.Background(), outer)
+	p := &Pool{}
+
+	err := p.WithNestedTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if tx.(*fakeTx).depth != 1 {
+			t.Errorf("expected savepoint tx, got depth %d", tx.(*fakeTx).depth)
+		}
+		return p.WithNestedTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			if tx.(*fakeTx).depth != 2 {
+				t.Errorf("expected nested savepoint tx, got depth %d", tx.(*fakeTx).depth)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithNestedTx() error = %v", err)
+	}
+
+	want := []string{"savepoint", "savepoint", "release", "release"}
+	if !slices.Equal(log, want) {
+		t.Errorf("operations = %v, want %v", log, want)
+	}
+}
+
+func TestWithNestedTx_ErrorRollsBackToSavepoint(t *testing.T) {
+	var log []string
+	ctx := WithTxContext(context.Background(), &fakeTx{log: &log})
+	wantErr := errors.New("insufficient funds")
+
+	err := (&Pool{}).WithNestedTx(ctx, func(context.Context, pgx.Tx) error {
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("WithNestedTx() error = %v, want %v", err, wantErr)
+	}
+
+	want := []string{"savepoint", "rollback to savepoint"}
+	if !slices.Equal(log, want) {
+		t.Errorf("operations = %v, want %v", log, want)
+	}
+}
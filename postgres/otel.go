@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "gitlab.com/xakpro/cg-shared-libs/postgres"
+
+// maxStatementLength truncates db.statement span attribute
+const maxStatementLength = 1024
+
+// tablePattern finds the first table a statement touches
+var tablePattern = regexp.MustCompile(`(?i)\b(?:from|into|update|join)\s+("?[\w.]+"?)`)
+
+// otelTracer creates a span per query, batch, COPY and connect. Spans are
+// children of the span in ctx, e.g. the one started by the gRPC server.
+type otelTracer struct {
+	tracer   trace.Tracer
+	database string
+}
+
+func newOTelTracer(database string) *otelTracer {
+	return &otelTracer{tracer: otel.Tracer(tracerName), database: database}
+}
+
+func (t *otelTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "postgres."+queryName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.statementAttributes(data.SQL)...),
+	)
+	return ctx
+}
+
+func (t *otelTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	endSpan(span, data.Err)
+}
+
+func (t *otelTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	size := 0
+	if data.Batch != nil {
+		size = data.Batch.Len()
+	}
+	ctx, _ = t.tracer.Start(ctx, "postgres.batch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.name", t.database),
+			attribute.Int("db.batch.size", size),
+		),
+	)
+	return ctx
+}
+
+func (t *otelTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	attrs := t.statementAttributes(data.SQL)
+	if data.Err != nil {
+		attrs = append(attrs, attribute.String("error", data.Err.Error()))
+	}
+	trace.SpanFromContext(ctx).AddEvent("query", trace.WithAttributes(attrs...))
+}
+
+func (t *otelTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err)
+}
+
+func (t *otelTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "postgres.copy",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.name", t.database),
+			attribute.String("db.operation", "copy"),
+			attribute.String("db.sql.table", data.TableName.Sanitize()),
+		),
+	)
+	return ctx
+}
+
+func (t *otelTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	endSpan(span, data.Err)
+}
+
+func (t *otelTracer) TraceConnectStart(ctx context.Context, data pgx.TraceConnectStartData) context.Context {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.name", t.database),
+	}
+	if data.ConnConfig != nil {
+		attrs = append(attrs,
+			attribute.String("server.address", data.ConnConfig.Host),
+			attribute.Int("server.port", int(data.ConnConfig.Port)),
+		)
+	}
+	ctx, _ = t.tracer.Start(ctx, "postgres.connect",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx
+}
+
+func (t *otelTracer) TraceConnectEnd(ctx context.Context, data pgx.TraceConnectEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err)
+}
+
+// statementAttributes returns span attributes describing sql
+func (t *otelTracer) statementAttributes(sql string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.name", t.database),
+		attribute.String("db.operation", queryName(sql)),
+		attribute.String("db.statement", truncateStatement(sql)),
+	}
+	if table := statementTable(sql); table != "" {
+		attrs = append(attrs, attribute.String("db.sql.table", table))
+	}
+	return attrs
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// statementTable returns the first table referenced by sql, if any
+func statementTable(sql string) string {
+	match := tablePattern.FindStringSubmatch(sql)
+	if match == nil {
+		return ""
+	}
+	return strings.Trim(match[1], `"`)
+}
+
+// truncateStatement limits sql length keeping valid UTF-8
+func truncateStatement(sql string) string {
+	if len(sql) <= maxStatementLength {
+		return sql
+	}
+	cut := maxStatementLength
+	for cut > 0 && !utf8.RuneStart(sql[cut]) {
+		cut--
+	}
+	return sql[:cut] + "..."
+}
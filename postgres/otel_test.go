@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOTelTracer_Query(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := &otelTracer{tracer: provider.Tracer(tracerName), database: "orders"}
+
+	parentCtx, parent := provider.Tracer("test").Start(context.Background(), "grpc.request")
+	sql := "-- name: GetOrder :one\nSELECT * FROM orders WHERE id = $1"
+	ctx := tracer.TraceQueryStart(parentCtx, nil, pgx.TraceQueryStartData{SQL: sql})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	ctx = tracer.TraceQueryStart(parentCtx, nil, pgx.TraceQueryStartData{SQL: "INSERT INTO orders (id) VALUES ($1)"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("duplicate key")})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("spans = %d, want 3", len(spans))
+	}
+
+	query := spans[0]
+	if query.Name() != "postgres.GetOrder" {
+		t.Errorf("span name = %q, want postgres.GetOrder", query.Name())
+	}
+	if query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("expected query span to be child of request span")
+	}
+	attrs := attribute.NewSet(query.Attributes()...)
+	if v, _ := attrs.Value("db.sql.table"); v.AsString() != "orders" {
+		t.Errorf("db.sql.table = %q, want orders", v.AsString())
+	}
+	if v, _ := attrs.Value("db.statement"); v.AsString() != sql {
+		t.Errorf("db.statement = %q, want %q", v.AsString(), sql)
+	}
+
+	if status := spans[1].Status().Code; status != codes.Error {
+		t.Errorf("failed query span status = %v, want error", status)
+	}
+}
+
+func TestTruncateStatement(t *testing.T) {
+	long := "SELECT '" + strings.Repeat("ж", maxStatementLength) + "'"
+	got := truncateStatement(long)
+
+	if len(got) > maxStatementLength+3 {
+		t.Errorf("truncated length = %d, want <= %d", len(got), maxStatementLength+3)
+	}
+	if !strings.HasSuffix(got, "...") {
+		t.Error("expected truncated statement to end with ...")
+	}
+	if truncateStatement("SELECT 1") != "SELECT 1" {
+		t.Error("expected short statement unchanged")
+	}
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Page size bounds applied by PageLimit
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor is returned for malformed or tampered cursors
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points after the last item of a page: its sort key and id
+// (the tie-breaker for equal keys). Clients get it as an opaque string.
+type Cursor[K, ID any] struct {
+	Key K  `json:"k"`
+	ID  ID `json:"i"`
+}
+
+// Encode returns opaque URL-safe cursor
+func (c Cursor[K, ID]) Encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes cursor returned by Cursor.Encode
+func DecodeCursor[K, ID any](s string) (Cursor[K, ID], error) {
+	var c Cursor[K, ID]
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// PageInfo describes position of a page in the list
+type PageInfo struct {
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Keyset builds WHERE and ORDER BY fragments for keyset pagination over
+// (SortColumn, IDColumn). It needs an index on both columns in that order.
+//
+//	ks := postgres.Keyset{SortColumn: "created_at", IDColumn: "id", Desc: true}
+//	sql, args := "SELECT id, created_at FROM orders WHERE user_id = $1", []any{userID}
+//	if token != "" {
+//		c, err := postgres.DecodeCursor[time.Time, int64](token)
+//		...
+//		sql += " AND " + ks.Where(len(args)+1)
+//		args = append(args, c.Key, c.ID)
+//	}
+//	sql += " ORDER BY " + ks.OrderBy() + " " + ks.Limit(limit)
+type Keyset struct {
+	SortColumn string
+	IDColumn   string
+	// Desc - newest first
+	Desc bool
+}
+
+// Where returns condition selecting rows after cursor, cursor key and id
+// are bound to $param and $param+1
+func (k Keyset) Where(param int) string {
+	op := ">"
+	if k.Desc {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s, %s) %s ($%d, $%d)",
+		pgx.Identifier{k.SortColumn}.Sanitize(), pgx.Identifier{k.IDColumn}.Sanitize(), op, param, param+1)
+}
+
+// OrderBy returns ORDER BY expression matching Where
+func (k Keyset) OrderBy() string {
+	dir := "ASC"
+	if k.Desc {
+		dir = "DESC"
+	}
+	return pgx.Identifier{k.SortColumn}.Sanitize() + " " + dir + ", " + pgx.Identifier{k.IDColumn}.Sanitize() + " " + dir
+}
+
+// Limit returns LIMIT clause fetching one extra row to detect the next page
+func (k Keyset) Limit(limit int) string {
+	return "LIMIT " + strconv.Itoa(limit+1)
+}
+
+// PageLimit clamps requested page size to [1, MaxPageLimit], 0 means DefaultPageLimit
+func PageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	return min(limit, MaxPageLimit)
+}
+
+// Paginate trims items fetched with Keyset.Limit to limit and builds page
+// info, cursor returns cursor of an item
+func Paginate[T, K, ID any](items []T, limit int, cursor func(item T) Cursor[K, ID]) ([]T, PageInfo, error) {
+	if len(items) <= limit {
+		return items, PageInfo{}, nil
+	}
+
+	items = items[:limit]
+	next, err := cursor(items[len(items)-1]).Encode()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	return items, PageInfo{HasNext: true, NextCursor: next}, nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type order struct {
+	ID        int64
+	CreatedAt time.Time
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	token, err := Cursor[time.Time, int64]{Key: created, ID: 42}.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	got, err := DecodeCursor[time.Time, int64](token)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !got.Key.Equal(created) || got.ID != 42 {
+		t.Errorf("DecodeCursor() = %+v, want key %v id 42", got, created)
+	}
+
+	if _, err := DecodeCursor[time.Time, int64]("not a cursor!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("DecodeCursor(invalid) error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	ks := Keyset{SortColumn: "created_at", IDColumn: "id", Desc: true}
+
+	if got, want := ks.Where(2), `("created_at", "id") < ($2, $3)`; got != want {
+		t.Errorf("Where() = %s, want %s", got, want)
+	}
+	if got, want := ks.OrderBy(), `"created_at" DESC, "id" DESC`; got != want {
+		t.Errorf("OrderBy() = %s, want %s", got, want)
+	}
+	if got, want := ks.Limit(20), "LIMIT 21"; got != want {
+		t.Errorf("Limit() = %s, want %s", got, want)
+	}
+
+	asc := Keyset{SortColumn: "name", IDColumn: "id"}
+	if got, want := asc.Where(1), `("name", "id") > ($1, $2)`; got != want {
+		t.Errorf("Where() = %s, want %s", got, want)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []order{{ID: 3}, {ID: 2}, {ID: 1}}
+	cursor := func(o order) Cursor[time.Time, int64] {
+		return Cursor[time.Time, int64]{Key: o.CreatedAt, ID: o.ID}
+	}
+
+	page, info, err := Paginate(items, 2, cursor)
+	if err != nil {
+		t.Fatalf("Paginate() error = %v", err)
+	}
+	if len(page) != 2 || !info.HasNext {
+		t.Fatalf("Paginate() = %d items, has next %v, want 2 items with next", len(page), info.HasNext)
+	}
+	next, err := DecodeCursor[time.Time, int64](info.NextCursor)
+	if err != nil || next.ID != 2 {
+		t.Errorf("next cursor = %+v, %v, want id 2", next, err)
+	}
+
+	page, info, _ = Paginate(items, 3, cursor)
+	if len(page) != 3 || info.HasNext || info.NextCursor != "" {
+		t.Errorf("last page = %d items, info %+v, want 3 items without next", len(page), info)
+	}
+}
+
+func TestPageLimit(t *testing.T) {
+	for limit, want := range map[int]int{0: DefaultPageLimit, -5: DefaultPageLimit, 10: 10, 1000: MaxPageLimit} {
+		if got := PageLimit(limit); got != want {
+			t.Errorf("PageLimit(%d) = %d, want %d", limit, got, want)
+		}
+	}
+}
@@ -3,12 +3,18 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
 	"go.uber.org/zap"
 )
 
@@ -24,24 +30,106 @@ type Config struct {
 	MinConns        int32         `yaml:"min_conns" env:"POSTGRES_MIN_CONNS" env-default:"5"`
 	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime" env:"POSTGRES_MAX_CONN_LIFETIME" env-default:"1h"`
 	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time" env:"POSTGRES_MAX_CONN_IDLE_TIME" env-default:"30m"`
+
+	// ApplicationName - shown in pg_stat_activity, defaults to Metrics service name or binary name
+	ApplicationName string `yaml:"application_name" env:"POSTGRES_APPLICATION_NAME"`
+	// SearchPath - comma-separated schemas, e.g. "billing,public"
+	SearchPath string `yaml:"search_path" env:"POSTGRES_SEARCH_PATH"`
+	// StatementTimeout - server-side limit for any statement, 0 disables
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"POSTGRES_STATEMENT_TIMEOUT"`
+	// ConnectTimeout - limit for establishing a connection, 0 disables
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"POSTGRES_CONNECT_TIMEOUT" env-default:"5s"`
+	// Options - extra connection parameters appended to the DSN, explicit fields take precedence
+	Options map[string]string `yaml:"options"`
+
+	// SlowQueryThreshold - queries running longer are logged as slow
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env:"POSTGRES_SLOW_QUERY_THRESHOLD" env-default:"100ms"`
+	// LogQueryArgs - log sanitized arguments of slow and failed queries
+	LogQueryArgs bool `yaml:"log_query_args" env:"POSTGRES_LOG_QUERY_ARGS" env-default:"false"`
+	// EnableTracing - create OpenTelemetry spans per query, batch, COPY and connect
+	EnableTracing bool `yaml:"enable_tracing" env:"POSTGRES_ENABLE_TRACING" env-default:"false"`
+
+	// MigrationsAutoFixDirty - on dirty state RunMigrations forces the version,
+	// rolls back and reapplies the failed migration instead of failing
+	MigrationsAutoFixDirty bool `yaml:"migrations_auto_fix_dirty" env:"POSTGRES_MIGRATIONS_AUTO_FIX_DIRTY" env-default:"false"`
+
+	// Metrics - optional, exports pool stats on every scrape
+	Metrics *metrics.Metrics `yaml:"-"`
 }
 
 // DSN returns PostgreSQL connection string
 func (c *Config) DSN() string {
 	return fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		c.User, c.Password, c.Host, c.Port, c.Database, c.SSLMode,
+		"postgres://%s:%s@%s:%d/%s?%s",
+		c.User, c.Password, c.Host, c.Port, c.Database, c.params().Encode(),
 	)
 }
 
+// params returns DSN query parameters
+func (c *Config) params() url.Values {
+	params := url.Values{}
+	for key, value := range c.Options {
+		params.Set(key, value)
+	}
+
+	params.Set("sslmode", c.SSLMode)
+	params.Set("application_name", c.applicationName())
+	if c.SearchPath != "" {
+		params.Set("search_path", c.SearchPath)
+	}
+	if c.StatementTimeout > 0 {
+		params.Set("statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10))
+	}
+	if c.ConnectTimeout > 0 {
+		// connect_timeout is in seconds, round up so sub-second values don't disable it
+		params.Set("connect_timeout", strconv.FormatInt(int64((c.ConnectTimeout+time.Second-1)/time.Second), 10))
+	}
+	return params
+}
+
+// applicationName returns configured name, service name or binary name
+func (c *Config) applicationName() string {
+	if c.ApplicationName != "" {
+		return c.ApplicationName
+	}
+	if c.Metrics != nil {
+		return c.Metrics.ServiceName()
+	}
+	return filepath.Base(os.Args[0])
+}
+
 // Pool wraps pgxpool.Pool with additional functionality
 type Pool struct {
 	*pgxpool.Pool
+
+	unregisterStats func()
 }
 
 // New creates a new PostgreSQL connection pool
 func New(ctx context.Context, cfg Config) (*Pool, error) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
+	p, err := newPool(ctx, cfg, cfg.DSN(), cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test connection
+	if err := p.Ping(ctx); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+
+	logger.Info("PostgreSQL connected",
+		zap.String("host", cfg.Host),
+		zap.Int("port", cfg.Port),
+		zap.String("database", cfg.Database),
+	)
+
+	return p, nil
+}
+
+// newPool creates pool for dsn using pool settings from cfg, name labels pool metrics
+func newPool(ctx context.Context, cfg Config, dsn, name string) (*Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
@@ -51,30 +139,33 @@ func New(ctx context.Context, cfg Config) (*Pool, error) {
 	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
 	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
 
-	// Add query logger in development
-	poolConfig.ConnConfig.Tracer = &queryTracer{}
+	// Log slow and failed queries, record query metrics
+	if cfg.EnableTracing {
+		poolConfig.ConnConfig.Tracer = multitracer.New(newQueryTracer(cfg), newOTelTracer(poolConfig.ConnConfig.Database))
+	} else {
+		poolConfig.ConnConfig.Tracer = newQueryTracer(cfg)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("create pool: %w", err)
 	}
 
-	// Test connection
-	if err := pool.Ping(ctx); err != nil {
-		return nil, fmt.Errorf("ping database: %w", err)
+	p := &Pool{Pool: pool}
+	if cfg.Metrics != nil {
+		if p.unregisterStats, err = cfg.Metrics.RegisterPgxPool(name, pool); err != nil {
+			logger.Warn("PostgreSQL pool metrics registration failed", zap.Error(err))
+		}
 	}
-
-	logger.Info("PostgreSQL connected",
-		zap.String("host", cfg.Host),
-		zap.Int("port", cfg.Port),
-		zap.String("database", cfg.Database),
-	)
-
-	return &Pool{Pool: pool}, nil
+	return p, nil
 }
 
 // Close closes the connection pool
 func (p *Pool) Close() {
+	if p.unregisterStats != nil {
+		p.unregisterStats()
+		p.unregisterStats = nil
+	}
 	if p.Pool != nil {
 		p.Pool.Close()
 		logger.Info("PostgreSQL connection closed")
@@ -90,11 +181,20 @@ type Querier interface {
 
 // WithTx executes function within transaction
 func (p *Pool) WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
-	tx, err := p.Begin(ctx)
+	return p.withTx(ctx, pgx.TxOptions{}, fn)
+}
+
+// withTx executes function within transaction started with opts
+func (p *Pool) withTx(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := p.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
+	return runTx(ctx, tx, fn)
+}
 
+// runTx executes function within tx, committing on success and rolling back on error or panic
+func runTx(ctx context.Context, tx pgx.Tx, fn func(tx pgx.Tx) error) error {
 	defer func() {
 		if p := recover(); p != nil {
 			_ = tx.Rollback(ctx)
@@ -115,58 +215,3 @@ func (p *Pool) WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
 
 	return nil
 }
-
-// queryTracer implements pgx.QueryTracer for logging
-type queryTracer struct{}
-
-type queryContextKey string
-
-const (
-	queryStartKey queryContextKey = "query_start"
-	querySQLKey   queryContextKey = "query_sql"
-)
-
-func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
-	ctx = context.WithValue(ctx, queryStartKey, time.Now())
-	ctx = context.WithValue(ctx, querySQLKey, data.SQL)
-	return ctx
-}
-
-func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
-	start, ok := ctx.Value(queryStartKey).(time.Time)
-	if !ok {
-		return
-	}
-
-	sql, _ := ctx.Value(querySQLKey).(string)
-	duration := time.Since(start)
-
-	// Log slow queries (> 100ms)
-	if duration > 100*time.Millisecond {
-		logger.Warn("slow query",
-			zap.Duration("duration", duration),
-			zap.String("sql", sql),
-		)
-	}
-}
-
-// IsNotFound checks if error is "no rows" error
-func IsNotFound(err error) bool {
-	return err == pgx.ErrNoRows
-}
-
-// IsDuplicate checks if error is duplicate key error
-func IsDuplicate(err error) bool {
-	if pgErr, ok := err.(*pgconn.PgError); ok {
-		return pgErr.Code == "23505" // unique_violation
-	}
-	return false
-}
-
-// IsForeignKeyViolation checks if error is foreign key violation
-func IsForeignKeyViolation(err error) bool {
-	if pgErr, ok := err.(*pgconn.PgError); ok {
-		return pgErr.Code == "23503" // foreign_key_violation
-	}
-	return false
-}
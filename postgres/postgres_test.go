@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestConfig_DSN(t *testing.T) {
+	cfg := Config{
+		Host:             "db",
+		Port:             5432,
+		User:             "cg_user",
+		Password:         "secret",
+		Database:         "billing",
+		SSLMode:          "disable",
+		ApplicationName:  "billing-service",
+		SearchPath:       "billing,public",
+		StatementTimeout: 5 * time.Second,
+		ConnectTimeout:   1500 * time.Millisecond,
+		Options: map[string]string{
+			"lock_timeout": "1000",
+			"sslmode":      "require",
+		},
+	}
+
+	connCfg, err := pgx.ParseConfig(cfg.DSN())
+	if err != nil {
+		t.Fatalf("parse DSN: %v", err)
+	}
+
+	want := map[string]string{
+		"application_name":  "billing-service",
+		"search_path":       "billing,public",
+		"statement_timeout": "5000",
+		"lock_timeout":      "1000",
+	}
+	for key, value := range want {
+		if got := connCfg.RuntimeParams[key]; got != value {
+			t.Errorf("runtime param %s = %q, want %q", key, got, value)
+		}
+	}
+	if connCfg.TLSConfig != nil {
+		t.Error("expected explicit SSLMode to override options")
+	}
+	if connCfg.ConnectTimeout != 2*time.Second {
+		t.Errorf("connect timeout = %v, want 2s", connCfg.ConnectTimeout)
+	}
+}
+
+func TestConfig_DSNDefaultApplicationName(t *testing.T) {
+	cfg := Config{Host: "db", Port: 5432, Database: "billing", SSLMode: "disable"}
+
+	connCfg, err := pgx.ParseConfig(cfg.DSN())
+	if err != nil {
+		t.Fatalf("parse DSN: %v", err)
+	}
+	if connCfg.RuntimeParams["application_name"] == "" {
+		t.Error("expected application_name to default to binary name")
+	}
+}
@@ -0,0 +1,207 @@
+// Package postgrestest provides a throwaway PostgreSQL database for tests:
+// a testcontainers container (or POSTGRES_TEST_DSN), migrations and
+// per-test transactions rolled back on cleanup.
+//
+//	func TestMain(m *testing.M) {
+//		db, err := postgrestest.Start(context.Background(), postgrestest.Options{MigrationsPath: "../migrations"})
+//		...
+//	}
+//
+//	func TestCreateOrder(t *testing.T) {
+//		ctx, tx := db.Tx(t)
+//		repo := NewRepository(postgres.NewRunner(db.Pool))
+//		// repo queries run within tx and are rolled back after the test
+//	}
+package postgrestest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"gitlab.com/xakpro/cg-shared-libs/postgres"
+)
+
+// DSNEnv names variable with DSN of an existing database to use instead of a container
+const DSNEnv = "POSTGRES_TEST_DSN"
+
+// DefaultImage is PostgreSQL image started when Options.Image is empty
+const DefaultImage = "postgres:16-alpine"
+
+// startTimeout bounds container start and migrations
+const startTimeout = 2 * time.Minute
+
+// Options configures test database
+type Options struct {
+	// Image - container image, DefaultImage if empty
+	Image string
+	// MigrationsPath - migrations directory applied with postgres.RunMigrations
+	MigrationsPath string
+	// MigrationsFS, MigrationsDir - embedded migrations applied with postgres.RunMigrationsFS
+	MigrationsFS  fs.FS
+	MigrationsDir string
+}
+
+// DB is a migrated test database
+type DB struct {
+	Pool   *postgres.Pool
+	Config postgres.Config
+
+	container *tcpostgres.PostgresContainer
+}
+
+// Start connects to POSTGRES_TEST_DSN or starts a container and applies
+// migrations. Call Close when done, usually from TestMain.
+func Start(ctx context.Context, opts Options) (*DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	db := &DB{}
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		image := opts.Image
+		if image == "" {
+			image = DefaultImage
+		}
+
+		container, err := tcpostgres.Run(ctx, image,
+			tcpostgres.WithDatabase("test"),
+			tcpostgres.WithUsername("test"),
+			tcpostgres.WithPassword("test"),
+			tcpostgres.BasicWaitStrategies(),
+		)
+		db.container = container
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("start postgres container: %w", err)
+		}
+
+		if dsn, err = container.ConnectionString(ctx, "sslmode=disable"); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("container connection string: %w", err)
+		}
+	}
+
+	cfg, err := configFromDSN(dsn)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	db.Config = cfg
+
+	if err := db.migrate(ctx, opts); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if db.Pool, err = postgres.New(ctx, cfg); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	return db, nil
+}
+
+// New starts database for a single test and closes it on cleanup. The test
+// is skipped if neither POSTGRES_TEST_DSN nor Docker is available.
+func New(t *testing.T, opts Options) *DB {
+	t.Helper()
+
+	if os.Getenv(DSNEnv) == "" {
+		testcontainers.SkipIfProviderIsNotHealthy(t)
+	}
+
+	db, err := Start(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("start test database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// Tx begins transaction rolled back on test cleanup. The returned context
+// carries it, so postgres.Runner and Pool.WithNestedTx join the transaction.
+func (db *DB) Tx(t testing.TB) (context.Context, pgx.Tx) {
+	t.Helper()
+
+	ctx := context.Background()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin test transaction: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := tx.Rollback(context.Background()); err != nil {
+			t.Errorf("rollback test transaction: %v", err)
+		}
+	})
+	return postgres.WithTxContext(ctx, tx), tx
+}
+
+// Close closes the pool and terminates the container
+func (db *DB) Close() {
+	if db.Pool != nil {
+		db.Pool.Close()
+		db.Pool = nil
+	}
+	if db.container != nil {
+		_ = testcontainers.TerminateContainer(db.container)
+		db.container = nil
+	}
+}
+
+// migrate applies configured migrations
+func (db *DB) migrate(ctx context.Context, opts Options) error {
+	switch {
+	case opts.MigrationsFS != nil:
+		if err := postgres.RunMigrationsFS(ctx, db.Config, opts.MigrationsFS, opts.MigrationsDir); err != nil {
+			return fmt.Errorf("run migrations: %w", err)
+		}
+	case opts.MigrationsPath != "":
+		if err := postgres.RunMigrations(ctx, db.Config, opts.MigrationsPath); err != nil {
+			return fmt.Errorf("run migrations: %w", err)
+		}
+	}
+	return nil
+}
+
+// configFromDSN converts URL DSN to postgres.Config
+func configFromDSN(dsn string) (postgres.Config, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return postgres.Config{}, fmt.Errorf("parse %s: %w", DSNEnv, err)
+	}
+
+	port := 5432
+	if p := u.Port(); p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			return postgres.Config{}, fmt.Errorf("parse %s port: %w", DSNEnv, err)
+		}
+	}
+	password, _ := u.User.Password()
+	sslMode := u.Query().Get("sslmode")
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	return postgres.Config{
+		Host:            u.Hostname(),
+		Port:            port,
+		User:            u.User.Username(),
+		Password:        password,
+		Database:        strings.TrimPrefix(u.Path, "/"),
+		SSLMode:         sslMode,
+		MaxConns:        5,
+		MinConns:        1,
+		MaxConnLifetime: time.Hour,
+		MaxConnIdleTime: 30 * time.Minute,
+		ApplicationName: "postgrestest",
+	}, nil
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Runner is a Querier that runs queries within the transaction active in
+// ctx (see WithTxContext, WithNestedTx) and on db otherwise. Repositories
+// written against Querier transparently join the caller's transaction.
+type Runner struct {
+	db Querier
+}
+
+var _ Querier = (*Runner)(nil)
+
+// NewRunner creates runner falling back to db, usually *Pool
+func NewRunner(db Querier) *Runner {
+	return &Runner{db: db}
+}
+
+// Querier returns transaction active in ctx or db
+func (r *Runner) Querier(ctx context.Context) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// Exec executes sql within active transaction or on db
+func (r *Runner) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return r.Querier(ctx).Exec(ctx, sql, arguments...)
+}
+
+// Query executes query within active transaction or on db
+func (r *Runner) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.Querier(ctx).Query(ctx, sql, args...)
+}
+
+// QueryRow executes query within active transaction or on db
+func (r *Runner) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.Querier(ctx).QueryRow(ctx, sql, args...)
+}
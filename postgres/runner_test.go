@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunner_Querier(t *testing.T) {
+	pool := &Pool{}
+	r := NewRunner(pool)
+
+	if got := r.Querier(context.Background()); got != Querier(pool) {
+		t.Errorf("expected pool without transaction, got %T", got)
+	}
+
+	var log []string
+	tx := &fakeTx{log: &log}
+	ctx := WithTxContext(context.Background(), tx)
+	if got := r.Querier(ctx); got != Querier(tx) {
+		t.Errorf("expected transaction from context, got %T", got)
+	}
+}
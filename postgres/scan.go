@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Errors are returned unwrapped so IsNotFound, IsDuplicate etc. keep working.
+
+// QueryOne executes query and scans exactly one row into T by column name
+// (db struct tags, see pgx.RowToStructByName). Returns pgx.ErrNoRows if
+// there are no rows, check it with IsNotFound.
+func QueryOne[T any](ctx context.Context, q Querier, sql string, args ...any) (T, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[T])
+}
+
+// QueryAll executes query and scans all rows into T by column name,
+// returns empty slice if there are no rows
+func QueryAll[T any](ctx context.Context, q Querier, sql string, args ...any) ([]T, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[T])
+}
+
+// Exec executes statement and returns number of affected rows
+func Exec(ctx context.Context, q Querier, sql string, args ...any) (int64, error) {
+	tag, err := q.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
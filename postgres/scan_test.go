@@ -0,0 +1,102 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"gitlab.com/xakpro/cg-shared-libs/postgres"
+	"gitlab.com/xakpro/cg-shared-libs/postgres/postgrestest"
+)
+
+// Runs against a container or POSTGRES_TEST_DSN, skipped if neither is available
+
+func TestScan(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test")
+	}
+	db := postgrestest.New(t, postgrestest.Options{MigrationsFS: testMigrations, MigrationsDir: "migrations"})
+
+	// seed inserts users within the test transaction
+	seed := func(t *testing.T, emails ...string) (context.Context, pgx.Tx) {
+		t.Helper()
+		ctx, tx := db.Tx(t)
+		for _, email := range emails {
+			if _, err := tx.Exec(ctx, "INSERT INTO users (email) VALUES ($1)", email); err != nil {
+				t.Fatalf("insert %s: %v", email, err)
+			}
+		}
+		return ctx, tx
+	}
+
+	t.Run("struct fields by db tag", func(t *testing.T) {
+		ctx, tx := seed(t, "a@example.com")
+
+		// Column order differs from field order
+		got, err := postgres.QueryOne[user](ctx, tx, "SELECT email, id FROM users WHERE email = $1", "a@example.com")
+		if err != nil {
+			t.Fatalf("QueryOne() error = %v", err)
+		}
+		if got.ID == 0 || got.Email != "a@example.com" {
+			t.Errorf("QueryOne() = %+v", got)
+		}
+
+		users, err := postgres.QueryAll[user](ctx, tx, "SELECT id, email FROM users ORDER BY id")
+		if err != nil {
+			t.Fatalf("QueryAll() error = %v", err)
+		}
+		if len(users) != 1 || users[0] != got {
+			t.Errorf("QueryAll() = %+v, want [%+v]", users, got)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		ctx, tx := seed(t, "a@example.com")
+
+		if _, err := postgres.QueryOne[user](ctx, tx, "SELECT id, email, 1 AS extra FROM users"); err == nil {
+			t.Error("QueryOne() with a column missing in the struct must fail")
+		}
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		ctx, tx := seed(t)
+
+		_, err := postgres.QueryOne[user](ctx, tx, "SELECT id, email FROM users")
+		if !postgres.IsNotFound(err) {
+			t.Errorf("QueryOne() error = %v, want not found", err)
+		}
+
+		users, err := postgres.QueryAll[user](ctx, tx, "SELECT id, email FROM users")
+		if err != nil || users == nil || len(users) != 0 {
+			t.Errorf("QueryAll() = %v, %v; want empty slice", users, err)
+		}
+	})
+
+	t.Run("too many rows", func(t *testing.T) {
+		ctx, tx := seed(t, "a@example.com", "b@example.com")
+
+		_, err := postgres.QueryOne[user](ctx, tx, "SELECT id, email FROM users")
+		if !errors.Is(err, pgx.ErrTooManyRows) {
+			t.Errorf("QueryOne() error = %v, want pgx.ErrTooManyRows", err)
+		}
+	})
+
+	t.Run("exec affected rows", func(t *testing.T) {
+		ctx, tx := seed(t, "a@example.com", "b@example.com", "c@example.com")
+
+		n, err := postgres.Exec(ctx, tx, "DELETE FROM users WHERE email <> $1", "a@example.com")
+		if err != nil || n != 2 {
+			t.Errorf("Exec() = %d, %v; want 2", n, err)
+		}
+		n, err = postgres.Exec(ctx, tx, "DELETE FROM users WHERE email = $1", "missing@example.com")
+		if err != nil || n != 0 {
+			t.Errorf("Exec() = %d, %v; want 0", n, err)
+		}
+
+		if _, err := postgres.Exec(ctx, tx, "INSERT INTO users (email) VALUES ($1)", "a@example.com"); !postgres.IsDuplicate(err) {
+			t.Errorf("Exec() error = %v, want duplicate", err)
+		}
+	})
+}
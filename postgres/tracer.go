@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.uber.org/zap"
+)
+
+// maxLoggedArgLength truncates long string arguments in logs
+const maxLoggedArgLength = 64
+
+// queryTracer implements pgx.QueryTracer: logs slow and failed queries and
+// records per-query latency metrics
+type queryTracer struct {
+	slowThreshold time.Duration
+	logArgs       bool
+	metrics       *metrics.Metrics
+}
+
+func newQueryTracer(cfg Config) *queryTracer {
+	t := &queryTracer{
+		slowThreshold: cfg.SlowQueryThreshold,
+		logArgs:       cfg.LogQueryArgs,
+		metrics:       cfg.Metrics,
+	}
+
+	// Apply defaults if not set
+	if t.slowThreshold <= 0 {
+		t.slowThreshold = 100 * time.Millisecond
+	}
+	return t
+}
+
+type queryContextKey struct{}
+
+// queryTrace holds query data between TraceQueryStart and TraceQueryEnd
+type queryTrace struct {
+	start time.Time
+	sql   string
+	args  []any
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryContextKey{}, &queryTrace{start: time.Now(), sql: data.SQL, args: data.Args})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace, ok := ctx.Value(queryContextKey{}).(*queryTrace)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(trace.start)
+	name := queryName(trace.sql)
+	code := errorCode(data.Err)
+	if t.metrics != nil {
+		t.metrics.RecordPostgresQuery(name, duration, code)
+	}
+
+	if data.Err == nil && duration <= t.slowThreshold {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("query", name),
+		zap.Duration("duration", duration),
+		zap.String("sql", trace.sql),
+	}
+	if t.logArgs {
+		fields = append(fields, zap.Any("args", sanitizeArgs(trace.args)))
+	}
+
+	if data.Err != nil {
+		fields = append(fields, zap.String("code", code), zap.Error(data.Err))
+		logger.WithContext(ctx).Error("query failed", fields...)
+		return
+	}
+
+	fields = append(fields, zap.Int64("rows", data.CommandTag.RowsAffected()))
+	logger.WithContext(ctx).Warn("slow query", fields...)
+}
+
+// queryName returns sqlc-style name from "-- name: GetUser :one" comment,
+// or lowercased statement keyword (select, insert, ...)
+func queryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if rest, ok := strings.CutPrefix(sql, "-- name:"); ok {
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+
+	for strings.HasPrefix(sql, "--") {
+		_, sql, _ = strings.Cut(sql, "\n")
+		sql = strings.TrimSpace(sql)
+	}
+	keyword, _, _ := strings.Cut(sql, " ")
+	if keyword == "" {
+		return "unknown"
+	}
+	return strings.ToLower(strings.TrimRight(keyword, ";\n\t("))
+}
+
+// errorCode returns SQLSTATE of err, "unknown" for non-PostgreSQL errors
+// and "" for nil
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return "unknown"
+}
+
+// sanitizeArgs truncates long strings and hides binary values
+func sanitizeArgs(args []any) []any {
+	sanitized := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			if utf8.RuneCountInString(v) > maxLoggedArgLength {
+				v = string([]rune(v)[:maxLoggedArgLength]) + "..."
+			}
+			sanitized[i] = v
+		case []byte:
+			sanitized[i] = "<" + strconv.Itoa(len(v)) + " bytes>"
+		default:
+			sanitized[i] = v
+		}
+	}
+	return sanitized
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"-- name: GetUser :one\nSELECT * FROM users WHERE id = $1", "GetUser"},
+		{"SELECT 1", "select"},
+		{"  insert into users (id) values ($1)", "insert"},
+		{"-- fetch active\nUPDATE users SET active = true", "update"},
+		{"", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := queryName(tt.sql); got != tt.want {
+			t.Errorf("queryName(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if got := errorCode(nil); got != "" {
+		t.Errorf("errorCode(nil) = %q, want empty", got)
+	}
+	if got := errorCode(&pgconn.PgError{Code: "23505"}); got != "23505" {
+		t.Errorf("errorCode(PgError) = %q, want 23505", got)
+	}
+	if got := errorCode(errors.New("conn closed")); got != "unknown" {
+		t.Errorf("errorCode(other) = %q, want unknown", got)
+	}
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	got := sanitizeArgs([]any{long, []byte("secret"), 42})
+
+	if s := got[0].(string); len(s) != maxLoggedArgLength+3 {
+		t.Errorf("expected long string truncated, got %d chars", len(s))
+	}
+	if got[1] != "<6 bytes>" {
+		t.Errorf("expected bytes hidden, got %v", got[1])
+	}
+	if got[2] != 42 {
+		t.Errorf("expected int kept, got %v", got[2])
+	}
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// TxOptions configures transaction started by WithTxOptions
+type TxOptions struct {
+	// IsoLevel, AccessMode and DeferrableMode are passed to BEGIN
+	IsoLevel       pgx.TxIsoLevel
+	AccessMode     pgx.TxAccessMode
+	DeferrableMode pgx.TxDeferrableMode
+	// Retry - retry policy for serialization failures and deadlocks, zero disables retries
+	Retry TxRetryPolicy
+}
+
+// TxRetryPolicy retries transactions failed with serialization failure (40001)
+// or deadlock (40P01). The transaction function runs again from scratch, so
+// it must not have side effects outside the transaction.
+type TxRetryPolicy struct {
+	MaxRetries int
+	// InitialBackoff - upper bound of the first backoff
+	InitialBackoff time.Duration
+	// MaxBackoff - upper bound of any backoff
+	MaxBackoff time.Duration
+}
+
+// DefaultTxRetryPolicy suits short serializable transactions
+var DefaultTxRetryPolicy = TxRetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 20 * time.Millisecond,
+	MaxBackoff:     500 * time.Millisecond,
+}
+
+// backoff returns full-jitter delay before the given retry (1-based)
+func (p TxRetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.InitialBackoff
+	for i := 1; i < retry && ceiling < p.MaxBackoff; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, p.MaxBackoff)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)))
+}
+
+// Serializable returns options for serializable transaction retried with DefaultTxRetryPolicy
+func Serializable() TxOptions {
+	return TxOptions{IsoLevel: pgx.Serializable, Retry: DefaultTxRetryPolicy}
+}
+
+// WithTxOptions executes function within transaction started with opts,
+// retrying serialization failures and deadlocks according to opts.Retry
+func (p *Pool) WithTxOptions(ctx context.Context, opts TxOptions, fn func(tx pgx.Tx) error) error {
+	txOpts := pgx.TxOptions{
+		IsoLevel:       opts.IsoLevel,
+		AccessMode:     opts.AccessMode,
+		DeferrableMode: opts.DeferrableMode,
+	}
+
+	for retry := 0; ; retry++ {
+		err := p.withTx(ctx, txOpts, fn)
+		if err == nil || !IsRetryable(err) || retry >= opts.Retry.MaxRetries {
+			return err
+		}
+
+		waitDuration := opts.Retry.backoff(retry + 1)
+		logger.WithContext(ctx).Warn("retrying transaction",
+			zap.Int("attempt", retry+1),
+			zap.Duration("wait_time", waitDuration),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(waitDuration)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"wrapped on commit", fmt.Errorf("commit tx: %w", &pgconn.PgError{Code: "40001"}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTxRetryPolicy_BackoffIsCapped(t *testing.T) {
+	policy := TxRetryPolicy{MaxRetries: 100, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	for retry := 1; retry <= 100; retry++ {
+		if d := policy.backoff(retry); d < 0 || d >= policy.MaxBackoff {
+			t.Fatalf("backoff(%d) = %v, want [0, %v)", retry, d, policy.MaxBackoff)
+		}
+	}
+
+	if d := (TxRetryPolicy{}).backoff(1); d != 0 {
+		t.Errorf("zero policy backoff = %v, want 0", d)
+	}
+}
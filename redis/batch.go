@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MSetJSON sets values as JSON with expiration in a single pipeline
+func (c *Client) MSetJSON(ctx context.Context, values map[string]any, expiration time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range values {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("marshal value for %s: %w", key, err)
+			}
+			pipe.Set(ctx, key, data, expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+// MGetJSON gets keys in a single round trip and unmarshals found values.
+// Keys that do not exist are returned in missing.
+func MGetJSON[T any](ctx context.Context, c *Client, keys ...string) (found map[string]T, missing []string, err error) {
+	found = make(map[string]T, len(keys))
+	if len(keys) == 0 {
+		return found, nil, nil
+	}
+
+	values, err := c.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, raw := range values {
+		s, ok := raw.(string)
+		if !ok {
+			missing = append(missing, keys[i])
+			continue
+		}
+		var v T
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, nil, fmt.Errorf("unmarshal %s: %w", keys[i], err)
+		}
+		found[keys[i]] = v
+	}
+	return found, missing, nil
+}
+
+// CounterDelta is a single hash field increment for IncrCounters
+type CounterDelta struct {
+	Key   string
+	Field string
+	Delta int64
+}
+
+// IncrCounters increments hash fields in a single pipeline and returns
+// new values in the order of deltas
+func (c *Client) IncrCounters(ctx context.Context, deltas []CounterDelta) ([]int64, error) {
+	if len(deltas) == 0 {
+		return nil, nil
+	}
+
+	cmds := make([]*redis.IntCmd, len(deltas))
+	_, err := c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, d := range deltas {
+			cmds[i] = pipe.HIncrBy(ctx, d.Key, d.Field, d.Delta)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]int64, len(cmds))
+	for i, cmd := range cmds {
+		result[i] = cmd.Val()
+	}
+	return result, nil
+}
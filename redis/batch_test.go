@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type batchProfile struct {
+	Name string `json:"name"`
+}
+
+func TestMSetMGetJSON(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	err := client.MSetJSON(ctx, map[string]any{
+		"profile:1": batchProfile{Name: "alice"},
+		"profile:2": batchProfile{Name: "bob"},
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, missing, err := MGetJSON[batchProfile](ctx, client, "profile:1", "profile:3", "profile:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found["profile:1"].Name != "alice" || found["profile:2"].Name != "bob" {
+		t.Errorf("found = %+v", found)
+	}
+	if len(missing) != 1 || missing[0] != "profile:3" {
+		t.Errorf("missing = %v", missing)
+	}
+	if ttl := mr.TTL("profile:1"); ttl != time.Minute {
+		t.Errorf("ttl = %s", ttl)
+	}
+}
+
+func TestIncrCounters(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+
+	got, err := client.IncrCounters(context.Background(), []CounterDelta{
+		{Key: "counters:1", Field: "likes", Delta: 2},
+		{Key: "counters:2", Field: "likes", Delta: 1},
+		{Key: "counters:1", Field: "likes", Delta: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0] != 2 || got[1] != 1 || got[2] != 5 {
+		t.Errorf("got %v", got)
+	}
+}
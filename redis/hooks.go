@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "gitlab.com/xakpro/cg-shared-libs/redis"
+
+// metricsHook records command latency and errors
+type metricsHook struct {
+	metrics *metrics.Metrics
+}
+
+func (h metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		start := time.Now()
+		conn, err := next(ctx, network, addr)
+		h.metrics.RecordRedisCommand("dial", time.Since(start), err)
+		return conn, err
+	}
+}
+
+func (h metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.metrics.RecordRedisCommand(cmd.Name(), time.Since(start), commandError(err))
+		return err
+	}
+}
+
+func (h metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.metrics.RecordRedisCommand("pipeline", time.Since(start), commandError(err))
+		return err
+	}
+}
+
+// tracingHook creates a span per command or pipeline
+type tracingHook struct {
+	tracer trace.Tracer
+}
+
+func newTracingHook() tracingHook {
+	return tracingHook{tracer: otel.Tracer(tracerName)}
+}
+
+func (h tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		attrs := []attribute.KeyValue{
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", cmd.Name()),
+		}
+		if key, ok := commandKey(cmd); ok {
+			attrs = append(attrs, attribute.String("db.redis.key_hash", hashKey(key)))
+		}
+
+		ctx, span := h.tracer.Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordSpanError(span, err)
+		return err
+	}
+}
+
+func (h tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+
+		ctx, span := h.tracer.Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", strings.Join(names, " ")),
+				attribute.Int("db.redis.num_cmd", len(cmds)),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		recordSpanError(span, err)
+		return err
+	}
+}
+
+// commandError returns err unless it is redis.Nil, which is not a failure
+func commandError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err = commandError(err); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// commandKey returns the first key argument of cmd if any
+func commandKey(cmd redis.Cmder) (string, bool) {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return "", false
+	}
+	key, ok := args[1].(string)
+	return key, ok
+}
+
+// hashKey hashes key so span attributes don't leak identifiers
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
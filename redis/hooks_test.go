@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingHook(t *testing.T) {
+	mr := miniredis.RunT(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	rdb.AddHook(tracingHook{tracer: provider.Tracer(tracerName)})
+	client := &Client{Client: rdb}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	if err := client.Set(ctx, "user:42", "v", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get(ctx, "missing").Err(); !IsNil(err) {
+		t.Fatalf("get missing: %v", err)
+	}
+
+	// Connection handshake commands are traced too, keep only ours
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "redis.set" || span.Name() == "redis.get" {
+			spans = append(spans, span)
+		}
+	}
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	var keyHash string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "db.redis.key_hash" {
+			keyHash = attr.Value.AsString()
+		}
+	}
+	if keyHash != hashKey("user:42") {
+		t.Errorf("key hash = %q, want %q", keyHash, hashKey("user:42"))
+	}
+	// redis.Nil is a regular miss, not a span error
+	if status := spans[1].Status().Code; status == codes.Error {
+		t.Errorf("missing key span status = %v, want not error", status)
+	}
+}
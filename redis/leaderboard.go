@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Leaderboard operations for counter-service
+
+// ScoredMember is a sorted set member with its score and leaderboard rank
+type ScoredMember struct {
+	Member string
+	Score  float64
+	// Rank - 1-based position, highest score first
+	Rank int64
+}
+
+// IncrScore increments member score and returns the new score
+func (c *Client) IncrScore(ctx context.Context, key, member string, delta float64) (float64, error) {
+	return c.ZIncrBy(ctx, key, delta, member).Result()
+}
+
+// GetTopN returns n members with the highest scores
+func (c *Client) GetTopN(ctx context.Context, key string, n int64) ([]ScoredMember, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	zs, err := c.ZRevRangeWithScores(ctx, key, 0, n-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ScoredMember, len(zs))
+	for i, z := range zs {
+		member, _ := z.Member.(string)
+		members[i] = ScoredMember{Member: member, Score: z.Score, Rank: int64(i) + 1}
+	}
+	return members, nil
+}
+
+// GetRankAndScore returns member rank (1-based, highest score first) and
+// score in a single round trip. It returns redis.Nil if member is absent,
+// see IsNil.
+func (c *Client) GetRankAndScore(ctx context.Context, key, member string) (int64, float64, error) {
+	var (
+		rank  *redis.IntCmd
+		score *redis.FloatCmd
+	)
+	_, err := c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		rank = pipe.ZRevRank(ctx, key, member)
+		score = pipe.ZScore(ctx, key, member)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return rank.Val() + 1, score.Val(), nil
+}
+
+// Period is a leaderboard window length
+type Period string
+
+// Supported leaderboard periods
+const (
+	PeriodHourly  Period = "hourly"
+	PeriodDaily   Period = "daily"
+	PeriodWeekly  Period = "weekly"
+	PeriodMonthly Period = "monthly"
+)
+
+// WindowedLeaderboard keeps a separate sorted set per period, e.g.
+// "likes:daily:2024-05-17". Keys expire after retention periods.
+type WindowedLeaderboard struct {
+	client    *Client
+	name      string
+	period    Period
+	retention int
+}
+
+// NewWindowedLeaderboard creates leaderboard keeping retention past periods
+// (at least the current one)
+func NewWindowedLeaderboard(client *Client, name string, period Period, retention int) *WindowedLeaderboard {
+	return &WindowedLeaderboard{
+		client:    client,
+		name:      name,
+		period:    period,
+		retention: max(retention, 1),
+	}
+}
+
+// Key returns sorted set key of the period containing t (UTC)
+func (l *WindowedLeaderboard) Key(t time.Time) string {
+	t = t.UTC()
+	var suffix string
+	switch l.period {
+	case PeriodHourly:
+		suffix = t.Format("2006-01-02T15")
+	case PeriodWeekly:
+		year, week := t.ISOWeek()
+		suffix = fmt.Sprintf("%d-W%02d", year, week)
+	case PeriodMonthly:
+		suffix = t.Format("2006-01")
+	default:
+		suffix = t.Format("2006-01-02")
+	}
+	return l.name + ":" + string(l.period) + ":" + suffix
+}
+
+// Incr increments member score in the current period
+func (l *WindowedLeaderboard) Incr(ctx context.Context, member string, delta float64) (float64, error) {
+	now := time.Now()
+	key := l.Key(now)
+
+	var incr *redis.FloatCmd
+	_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.ZIncrBy(ctx, key, delta, member)
+		pipe.ExpireAt(ctx, key, l.expiresAt(now))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// TopN returns n members with the highest scores in the period containing t
+func (l *WindowedLeaderboard) TopN(ctx context.Context, t time.Time, n int64) ([]ScoredMember, error) {
+	return l.client.GetTopN(ctx, l.Key(t), n)
+}
+
+// RankAndScore returns member rank and score in the period containing t
+func (l *WindowedLeaderboard) RankAndScore(ctx context.Context, t time.Time, member string) (int64, float64, error) {
+	return l.client.GetRankAndScore(ctx, l.Key(t), member)
+}
+
+// expiresAt returns expiration of the period containing t: its end plus
+// retention-1 more periods
+func (l *WindowedLeaderboard) expiresAt(t time.Time) time.Time {
+	t = t.UTC()
+	var start time.Time
+	next := func(t time.Time, n int) time.Time { return t.AddDate(0, 0, n) }
+
+	switch l.period {
+	case PeriodHourly:
+		start = t.Truncate(time.Hour)
+		next = func(t time.Time, n int) time.Time { return t.Add(time.Duration(n) * time.Hour) }
+	case PeriodWeekly:
+		// ISO weeks start on Monday
+		start = time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+		next = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 7*n) }
+	case PeriodMonthly:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		next = func(t time.Time, n int) time.Time { return t.AddDate(0, n, 0) }
+	default:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return next(start, l.retention)
+}
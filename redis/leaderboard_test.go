@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLeaderboard(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	for member, score := range map[string]float64{"alice": 5, "bob": 9, "carol": 7} {
+		if _, err := client.IncrScore(ctx, "likes", member, score); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	top, err := client.GetTopN(ctx, "likes", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0] != (ScoredMember{"bob", 9, 1}) || top[1] != (ScoredMember{"carol", 7, 2}) {
+		t.Errorf("top = %+v", top)
+	}
+
+	rank, score, err := client.GetRankAndScore(ctx, "likes", "alice")
+	if err != nil || rank != 3 || score != 5 {
+		t.Errorf("GetRankAndScore = %d, %v, %v", rank, score, err)
+	}
+	if _, _, err := client.GetRankAndScore(ctx, "likes", "dave"); !IsNil(err) {
+		t.Errorf("absent member err = %v, want redis.Nil", err)
+	}
+}
+
+func TestWindowedLeaderboardKeys(t *testing.T) {
+	at := time.Date(2024, 5, 17, 13, 30, 0, 0, time.UTC) // Friday, ISO week 20
+
+	tests := []struct {
+		period  Period
+		key     string
+		expires time.Time
+	}{
+		{PeriodHourly, "likes:hourly:2024-05-17T13", time.Date(2024, 5, 17, 15, 0, 0, 0, time.UTC)},
+		{PeriodDaily, "likes:daily:2024-05-17", time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{PeriodWeekly, "likes:weekly:2024-W20", time.Date(2024, 5, 27, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonthly, "likes:monthly:2024-05", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		l := NewWindowedLeaderboard(nil, "likes", tt.period, 2)
+		if got := l.Key(at); got != tt.key {
+			t.Errorf("%s key = %q, want %q", tt.period, got, tt.key)
+		}
+		if got := l.expiresAt(at); !got.Equal(tt.expires) {
+			t.Errorf("%s expires = %s, want %s", tt.period, got, tt.expires)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrBloomUnsupported is returned by Bloom helpers when the server has no
+// Bloom module (RedisBloom or Redis Stack)
+var ErrBloomUnsupported = errors.New("redis: bloom filter commands not supported")
+
+const (
+	bloomUnknown int32 = iota
+	bloomSupported
+	bloomUnsupported
+)
+
+// bloomProbeKey is used to check Bloom module availability
+const bloomProbeKey = "bloom:probe"
+
+// AddUnique adds members to HyperLogLog key and reports whether the
+// estimated cardinality changed. Expiration is (re)set when ttl > 0.
+func (c *Client) AddUnique(ctx context.Context, key string, ttl time.Duration, members ...string) (bool, error) {
+	if len(members) == 0 {
+		return false, nil
+	}
+	args := make([]any, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+
+	var added *redis.IntCmd
+	_, err := c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.PFAdd(ctx, key, args...)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return added.Val() == 1, nil
+}
+
+// CountUnique returns estimated number of unique members in the union of
+// HyperLogLog keys. Standard error is 0.81%.
+func (c *Client) CountUnique(ctx context.Context, keys ...string) (int64, error) {
+	return c.PFCount(ctx, keys...).Result()
+}
+
+// DailyUniqueKey returns HyperLogLog key for name on the UTC day of t
+func DailyUniqueKey(name string, t time.Time) string {
+	return name + ":" + t.UTC().Format("2006-01-02")
+}
+
+// AddDailyUnique adds member to today's counter for name, e.g. unique
+// viewers of a stream. Counters are kept for retention days.
+func (c *Client) AddDailyUnique(ctx context.Context, name string, retention int, member string) (bool, error) {
+	return c.AddUnique(ctx, DailyUniqueKey(name, time.Now()), time.Duration(max(retention, 1))*24*time.Hour, member)
+}
+
+// CountDailyUnique returns estimated unique members for name across UTC
+// days from..to inclusive
+func (c *Client) CountDailyUnique(ctx context.Context, name string, from, to time.Time) (int64, error) {
+	var keys []string
+	day := from.UTC().Truncate(24 * time.Hour)
+	for last := to.UTC(); !day.After(last); day = day.AddDate(0, 0, 1) {
+		keys = append(keys, DailyUniqueKey(name, day))
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return c.CountUnique(ctx, keys...)
+}
+
+// BloomSupported reports whether the server supports Bloom filter commands.
+// The result is cached once determined.
+func (c *Client) BloomSupported(ctx context.Context) (bool, error) {
+	switch c.bloom.Load() {
+	case bloomSupported:
+		return true, nil
+	case bloomUnsupported:
+		return false, nil
+	}
+
+	err := c.bloomErr(c.BFExists(ctx, bloomProbeKey, "").Err())
+	switch {
+	case errors.Is(err, ErrBloomUnsupported):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// BloomReserve creates Bloom filter for expected capacity and false positive
+// rate. It is a no-op if the filter already exists.
+func (c *Client) BloomReserve(ctx context.Context, key string, errorRate float64, capacity int64) error {
+	if c.bloom.Load() == bloomUnsupported {
+		return ErrBloomUnsupported
+	}
+	err := c.bloomErr(c.BFReserve(ctx, key, errorRate, capacity).Err())
+	if err != nil && strings.Contains(err.Error(), "item exists") {
+		return nil
+	}
+	return err
+}
+
+// BloomAdd adds item to Bloom filter and reports whether it was new
+func (c *Client) BloomAdd(ctx context.Context, key, item string) (bool, error) {
+	if c.bloom.Load() == bloomUnsupported {
+		return false, ErrBloomUnsupported
+	}
+	added, err := c.BFAdd(ctx, key, item).Result()
+	return added, c.bloomErr(err)
+}
+
+// BloomExists reports whether item may be in Bloom filter. False positives
+// are possible, false negatives are not.
+func (c *Client) BloomExists(ctx context.Context, key, item string) (bool, error) {
+	if c.bloom.Load() == bloomUnsupported {
+		return false, ErrBloomUnsupported
+	}
+	exists, err := c.BFExists(ctx, key, item).Result()
+	return exists, c.bloomErr(err)
+}
+
+// bloomErr caches module availability and maps unknown command errors to
+// ErrBloomUnsupported
+func (c *Client) bloomErr(err error) error {
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		c.bloom.Store(bloomUnsupported)
+		return ErrBloomUnsupported
+	}
+	var redisErr redis.Error
+	if err == nil || errors.As(err, &redisErr) {
+		c.bloom.CompareAndSwap(bloomUnknown, bloomSupported)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCountDailyUnique(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+	if _, err := client.AddUnique(ctx, DailyUniqueKey("viewers", yesterday), time.Hour, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	// Disjoint days: miniredis sums multi-key PFCOUNT instead of merging
+	for _, member := range []string{"c", "d", "d"} {
+		if _, err := client.AddDailyUnique(ctx, "viewers", 7, member); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := client.CountDailyUnique(ctx, "viewers", today, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("today = %d, want 2", n)
+	}
+
+	n, err = client.CountDailyUnique(ctx, "viewers", yesterday, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("two days = %d, want 4", n)
+	}
+	if ttl := mr.TTL(DailyUniqueKey("viewers", today)); ttl != 7*24*time.Hour {
+		t.Errorf("ttl = %s, want 168h", ttl)
+	}
+}
+
+func TestBloomUnsupported(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	ok, err := client.BloomSupported(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("miniredis reported as supporting bloom filters")
+	}
+	if _, err := client.BloomAdd(ctx, "seen", "a"); !errors.Is(err, ErrBloomUnsupported) {
+		t.Errorf("BloomAdd err = %v, want ErrBloomUnsupported", err)
+	}
+}
@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"go.uber.org/zap"
+)
+
+// PublishJSON publishes value as JSON to channel
+func (c *Client) PublishJSON(ctx context.Context, channel string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	return c.Publish(ctx, channel, data).Err()
+}
+
+const unsubscribeTimeout = time.Second
+
+// SubscribeOption configures Subscribe
+type SubscribeOption func(*subscribeOptions)
+
+type subscribeOptions struct {
+	onResubscribe func()
+}
+
+// WithResubscribeHook sets hook called after the subscription is restored
+// following a reconnect. Messages published while disconnected are lost.
+func WithResubscribeHook(hook func()) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.onResubscribe = hook
+	}
+}
+
+// Subscription is an active channel subscription
+type Subscription struct {
+	pubsub    *redis.PubSub
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Subscribe subscribes to channel and calls handler for every message
+// decoded from JSON into T. Reconnects are handled by go-redis; handler
+// errors and panics are logged and do not stop the subscription. It stops
+// when ctx is cancelled or Close is called.
+func Subscribe[T any](ctx context.Context, c *Client, channel string, handler func(ctx context.Context, msg T) error, opts ...SubscribeOption) (*Subscription, error) {
+	var options subscribeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ps := c.Client.Subscribe(ctx, channel)
+	// Wait for confirmation so subscription errors are reported to the caller
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, fmt.Errorf("subscribe %s: %w", channel, err)
+	}
+
+	s := &Subscription{pubsub: ps, done: make(chan struct{})}
+	messages := ps.ChannelWithSubscriptions()
+
+	go func() {
+		defer close(s.done)
+		for {
+			select {
+			case <-ctx.Done():
+				_ = s.close()
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				switch msg := msg.(type) {
+				case *redis.Subscription:
+					if msg.Kind == "subscribe" && options.onResubscribe != nil {
+						options.onResubscribe()
+					}
+				case *redis.Message:
+					handleMessage(ctx, msg, handler)
+				}
+			}
+		}
+	}()
+
+	logger.Info("Redis subscription started", zap.String("channel", channel))
+	return s, nil
+}
+
+// Close unsubscribes and waits for the running handler to return
+func (s *Subscription) Close() error {
+	err := s.close()
+	<-s.done
+	return err
+}
+
+func (s *Subscription) close() error {
+	s.closeOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+		defer cancel()
+		// Best effort, closing the connection drops the subscription anyway
+		_ = s.pubsub.Unsubscribe(ctx)
+		s.closeErr = s.pubsub.Close()
+	})
+	return s.closeErr
+}
+
+// handleMessage decodes message and runs handler recovering from panics
+func handleMessage[T any](ctx context.Context, msg *redis.Message, handler func(ctx context.Context, msg T) error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.WithContext(ctx).Error("Redis subscription handler panicked",
+				zap.String("channel", msg.Channel),
+				zap.Any("panic", r),
+				zap.String("stack", string(debug.Stack())),
+			)
+		}
+	}()
+
+	var value T
+	if err := json.Unmarshal([]byte(msg.Payload), &value); err != nil {
+		logger.WithContext(ctx).Warn("Redis message decode failed",
+			zap.String("channel", msg.Channel),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := handler(ctx, value); err != nil {
+		logger.WithContext(ctx).Error("Redis message handler failed",
+			zap.String("channel", msg.Channel),
+			zap.Error(err),
+		)
+	}
+}
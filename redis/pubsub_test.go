@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type pubsubEvent struct {
+	ID int `json:"id"`
+}
+
+func TestSubscribeJSON(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	received := make(chan int, 2)
+	sub, err := Subscribe(ctx, client, "events", func(_ context.Context, e pubsubEvent) error {
+		if e.ID == 1 {
+			panic("boom")
+		}
+		received <- e.ID
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Panicking handler must not stop the subscription
+	for _, id := range []int{1, 2} {
+		if err := client.PublishJSON(ctx, "events", pubsubEvent{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case id := <-received:
+		if id != 2 {
+			t.Errorf("id = %d, want 2", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for mr.PubSubNumSub("events")["events"] != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("still subscribed after Close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
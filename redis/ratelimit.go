@@ -0,0 +1,195 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RateLimitResult describes rate limiter decision
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter - time until the limit is fully available again
+	ResetAfter time.Duration
+	// RetryAfter - time until the next request may be allowed, 0 if allowed
+	RetryAfter time.Duration
+}
+
+// RateLimiter allows up to limit requests per window for key.
+// Used by gRPC interceptors and HTTP middleware alike.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+var (
+	fixedWindowScript = DefaultScripts.Register("rate_limit_fixed_window", `
+		local current = redis.call("incr", KEYS[1])
+		if current == 1 then
+			redis.call("pexpire", KEYS[1], ARGV[1])
+		end
+		return {current, redis.call("pttl", KEYS[1])}
+	`)
+
+	slidingWindowScript = DefaultScripts.Register("rate_limit_sliding_window", `
+		local now = tonumber(ARGV[1])
+		local window = tonumber(ARGV[2])
+		local limit = tonumber(ARGV[3])
+		redis.call("zremrangebyscore", KEYS[1], "-inf", now - window)
+		local count = redis.call("zcard", KEYS[1])
+		local allowed = 0
+		if count < limit then
+			redis.call("zadd", KEYS[1], now, ARGV[4])
+			count = count + 1
+			allowed = 1
+		end
+		redis.call("pexpire", KEYS[1], window)
+		local oldest = redis.call("zrange", KEYS[1], 0, 0, "withscores")
+		local reset = 0
+		if oldest[2] then
+			reset = tonumber(oldest[2]) + window - now
+		end
+		return {allowed, count, reset}
+	`)
+
+	tokenBucketScript = DefaultScripts.Register("rate_limit_token_bucket", `
+		local capacity = tonumber(ARGV[1])
+		local rate = tonumber(ARGV[2])
+		local now = tonumber(ARGV[3])
+		local state = redis.call("hmget", KEYS[1], "tokens", "ts")
+		local tokens = tonumber(state[1])
+		local ts = tonumber(state[2])
+		if tokens == nil then
+			tokens = capacity
+			ts = now
+		end
+		tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+		local allowed = 0
+		if tokens >= 1 then
+			tokens = tokens - 1
+			allowed = 1
+		end
+		redis.call("hset", KEYS[1], "tokens", tostring(tokens), "ts", now)
+		redis.call("pexpire", KEYS[1], math.ceil(capacity / rate))
+		return {allowed, tostring(tokens)}
+	`)
+)
+
+// FixedWindowLimiter counts requests in fixed windows. Cheap, but allows
+// bursts of up to 2*limit around window boundaries.
+type FixedWindowLimiter struct {
+	client *Client
+	prefix string
+}
+
+// NewFixedWindowLimiter creates fixed window rate limiter
+func NewFixedWindowLimiter(client *Client, prefix string) *FixedWindowLimiter {
+	return &FixedWindowLimiter{client: client, prefix: prefix}
+}
+
+// Allow implements RateLimiter
+func (l *FixedWindowLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	res, err := fixedWindowScript.Run(ctx, l.client.Client, []string{l.prefix + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("fixed window limiter: %w", err)
+	}
+
+	count, reset := int(res[0]), time.Duration(res[1])*time.Millisecond
+	result := RateLimitResult{
+		Allowed:    count <= limit,
+		Limit:      limit,
+		Remaining:  max(0, limit-count),
+		ResetAfter: reset,
+	}
+	if !result.Allowed {
+		result.RetryAfter = reset
+	}
+	return result, nil
+}
+
+// SlidingWindowLimiter keeps a log of request timestamps, so exactly limit
+// requests are allowed within any window. Memory is O(limit) per key.
+type SlidingWindowLimiter struct {
+	client *Client
+	prefix string
+}
+
+// NewSlidingWindowLimiter creates sliding window log rate limiter
+func NewSlidingWindowLimiter(client *Client, prefix string) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{client: client, prefix: prefix}
+}
+
+// Allow implements RateLimiter
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	now := time.Now().UnixMilli()
+	member := strconv.FormatInt(now, 10) + "-" + hex.EncodeToString(id[:])
+
+	res, err := slidingWindowScript.Run(ctx, l.client.Client, []string{l.prefix + key},
+		now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("sliding window limiter: %w", err)
+	}
+
+	reset := time.Duration(res[2]) * time.Millisecond
+	result := RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      limit,
+		Remaining:  max(0, limit-int(res[1])),
+		ResetAfter: reset,
+	}
+	if !result.Allowed {
+		result.RetryAfter = reset
+	}
+	return result, nil
+}
+
+// TokenBucketLimiter refills limit tokens per window continuously and
+// allows bursts of up to limit requests
+type TokenBucketLimiter struct {
+	client *Client
+	prefix string
+}
+
+// NewTokenBucketLimiter creates token bucket rate limiter
+func NewTokenBucketLimiter(client *Client, prefix string) *TokenBucketLimiter {
+	return &TokenBucketLimiter{client: client, prefix: prefix}
+}
+
+// Allow implements RateLimiter
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	if limit <= 0 || window < time.Millisecond {
+		return RateLimitResult{}, fmt.Errorf("token bucket limiter: invalid limit %d per %s", limit, window)
+	}
+	rate := float64(limit) / float64(window.Milliseconds()) // tokens per millisecond
+
+	res, err := tokenBucketScript.Run(ctx, l.client.Client, []string{l.prefix + key},
+		limit, strconv.FormatFloat(rate, 'f', -1, 64), time.Now().UnixMilli()).Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("token bucket limiter: %w", err)
+	}
+
+	allowed, _ := res[0].(int64)
+	tokensStr, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("token bucket limiter: parse tokens %q: %w", tokensStr, err)
+	}
+
+	result := RateLimitResult{
+		Allowed:    allowed == 1,
+		Limit:      limit,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(limit) - tokens) / rate * float64(time.Millisecond)),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Millisecond))
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRateLimiters(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+
+	limiters := map[string]RateLimiter{
+		"fixed_window":   NewFixedWindowLimiter(client, "rl:fixed:"),
+		"sliding_window": NewSlidingWindowLimiter(client, "rl:sliding:"),
+		"token_bucket":   NewTokenBucketLimiter(client, "rl:bucket:"),
+	}
+	for name, limiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := range 3 {
+				res, err := limiter.Allow(ctx, "user:1", 3, time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !res.Allowed || res.Remaining != 2-i {
+					t.Fatalf("request %d: %+v", i, res)
+				}
+			}
+
+			res, err := limiter.Allow(ctx, "user:1", 3, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed || res.Remaining != 0 || res.RetryAfter <= 0 || res.RetryAfter > time.Minute {
+				t.Errorf("over limit: %+v", res)
+			}
+
+			if res, _ := limiter.Allow(ctx, "user:2", 3, time.Minute); !res.Allowed {
+				t.Errorf("other key limited: %+v", res)
+			}
+		})
+	}
+}
@@ -3,11 +3,14 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
-	"github.com/go-redis/redis/v8"
+	"github.com/redis/go-redis/v9"
 	"gitlab.com/xakpro/cg-shared-libs/logger"
+	"gitlab.com/xakpro/cg-shared-libs/metrics"
 	"go.uber.org/zap"
 )
 
@@ -22,6 +25,13 @@ type Config struct {
 	DialTimeout  time.Duration `yaml:"dial_timeout" env:"REDIS_DIAL_TIMEOUT" env-default:"5s"`
 	ReadTimeout  time.Duration `yaml:"read_timeout" env:"REDIS_READ_TIMEOUT" env-default:"3s"`
 	WriteTimeout time.Duration `yaml:"write_timeout" env:"REDIS_WRITE_TIMEOUT" env-default:"3s"`
+
+	TLS TLSConfig `yaml:"tls"`
+
+	// EnableTracing - create OpenTelemetry spans per command with hashed key
+	EnableTracing bool `yaml:"enable_tracing" env:"REDIS_ENABLE_TRACING" env-default:"false"`
+	// Metrics - optional metrics for command latency, errors and pool stats
+	Metrics *metrics.Metrics `yaml:"-"`
 }
 
 // Addr returns Redis address
@@ -32,10 +42,18 @@ func (c *Config) Addr() string {
 // Client wraps redis.Client with additional functionality
 type Client struct {
 	*redis.Client
+
+	unregisterStats func()
+	bloom           atomic.Int32 // Bloom module availability, see BloomSupported
 }
 
 // New creates a new Redis client
 func New(ctx context.Context, cfg Config) (*Client, error) {
+	tlsConfig, err := cfg.TLS.config(cfg.Host)
+	if err != nil {
+		return nil, fmt.Errorf("redis tls: %w", err)
+	}
+
 	client := redis.NewClient(&redis.Options{
 		Addr:         cfg.Addr(),
 		Password:     cfg.Password,
@@ -45,23 +63,50 @@ func New(ctx context.Context, cfg Config) (*Client, error) {
 		DialTimeout:  cfg.DialTimeout,
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
+		TLSConfig:    tlsConfig,
+		// Respect context deadlines instead of relying only on Read/WriteTimeout
+		ContextTimeoutEnabled: true,
 	})
 
+	if cfg.EnableTracing {
+		client.AddHook(newTracingHook())
+	}
+	if cfg.Metrics != nil {
+		client.AddHook(metricsHook{metrics: cfg.Metrics})
+	}
+
 	// Test connection
 	if err := client.Ping(ctx).Err(); err != nil {
 		return nil, fmt.Errorf("ping redis: %w", err)
 	}
 
+	// Preload helper scripts; EVALSHA falls back to EVAL if this fails
+	if err := DefaultScripts.Load(ctx, client); err != nil {
+		logger.Warn("Redis scripts preload failed", zap.Error(err))
+	}
+
 	logger.Info("Redis connected",
 		zap.String("addr", cfg.Addr()),
 		zap.Int("db", cfg.DB),
+		zap.Bool("tls", cfg.TLS.Enabled),
+		zap.Bool("tracing", cfg.EnableTracing),
 	)
 
-	return &Client{Client: client}, nil
+	c := &Client{Client: client}
+	if cfg.Metrics != nil {
+		if c.unregisterStats, err = cfg.Metrics.RegisterRedisPool(cfg.Addr(), client.PoolStats); err != nil {
+			logger.Warn("Redis pool metrics registration failed", zap.Error(err))
+		}
+	}
+	return c, nil
 }
 
 // Close closes the Redis connection
 func (c *Client) Close() error {
+	if c.unregisterStats != nil {
+		c.unregisterStats()
+		c.unregisterStats = nil
+	}
 	if c.Client != nil {
 		logger.Info("Redis connection closed")
 		return c.Client.Close()
@@ -105,15 +150,96 @@ func (c *Client) KeyExists(ctx context.Context, key string) (bool, error) {
 	return n > 0, nil
 }
 
-// DeletePattern deletes all keys matching pattern
-func (c *Client) DeletePattern(ctx context.Context, pattern string) error {
-	iter := c.Scan(ctx, 0, pattern, 100).Iterator()
+// ErrDeleteLimitReached is returned by DeletePattern when more keys match
+// than allowed by WithMaxKeys
+var ErrDeleteLimitReached = errors.New("redis: delete pattern limit reached")
+
+// DeletePatternOption configures DeletePattern
+type DeletePatternOption func(*deletePatternOptions)
+
+type deletePatternOptions struct {
+	scanCount int64
+	maxKeys   int64
+}
+
+// WithScanCount sets SCAN COUNT hint and UNLINK batch size (100 by default)
+func WithScanCount(count int64) DeletePatternOption {
+	return func(o *deletePatternOptions) {
+		o.scanCount = count
+	}
+}
+
+// WithMaxKeys stops DeletePattern after deleting max keys
+func WithMaxKeys(max int64) DeletePatternOption {
+	return func(o *deletePatternOptions) {
+		o.maxKeys = max
+	}
+}
+
+// DeletePattern deletes all keys matching pattern with pipelined batches of
+// UNLINK and returns number of deleted keys. With WithMaxKeys it returns
+// ErrDeleteLimitReached if more keys match.
+func (c *Client) DeletePattern(ctx context.Context, pattern string, opts ...DeletePatternOption) (int64, error) {
+	options := deletePatternOptions{scanCount: 100}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Flush every pipelineBatches UNLINK commands
+	const pipelineBatches = 10
+	var (
+		deleted int64
+		matched int64
+		batch   = make([]string, 0, options.scanCount)
+		pipe    = c.Pipeline()
+		cmds    []*redis.IntCmd
+	)
+
+	flush := func() error {
+		if len(batch) > 0 {
+			cmds = append(cmds, pipe.Unlink(ctx, batch...))
+			batch = make([]string, 0, options.scanCount)
+		}
+		if len(cmds) == 0 {
+			return nil
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("unlink keys: %w", err)
+		}
+		for _, cmd := range cmds {
+			deleted += cmd.Val()
+		}
+		cmds = cmds[:0]
+		return nil
+	}
+
+	iter := c.Scan(ctx, 0, pattern, options.scanCount).Iterator()
 	for iter.Next(ctx) {
-		if err := c.Del(ctx, iter.Val()).Err(); err != nil {
-			return err
+		if options.maxKeys > 0 && matched >= options.maxKeys {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+			return deleted, ErrDeleteLimitReached
+		}
+		matched++
+
+		batch = append(batch, iter.Val())
+		if int64(len(batch)) < options.scanCount {
+			continue
 		}
+		cmds = append(cmds, pipe.Unlink(ctx, batch...))
+		batch = make([]string, 0, options.scanCount)
+		if len(cmds) >= pipelineBatches {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
 	}
-	return iter.Err()
+
+	return deleted, flush()
 }
 
 // Counter operations for counter-service
@@ -166,20 +292,21 @@ func (c *Client) Lock(ctx context.Context, key string, value string, expiration
 	return c.SetNX(ctx, key, value, expiration).Result()
 }
 
+// unlockScript deletes lock key only if it still holds the owner value
+var unlockScript = DefaultScripts.Register("unlock", `
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("del", KEYS[1])
+	else
+		return 0
+	end
+`)
+
 // Unlock releases a distributed lock
 func (c *Client) Unlock(ctx context.Context, key string, value string) error {
-	script := redis.NewScript(`
-		if redis.call("get", KEYS[1]) == ARGV[1] then
-			return redis.call("del", KEYS[1])
-		else
-			return 0
-		end
-	`)
-	return script.Run(ctx, c.Client, []string{key}, value).Err()
+	return unlockScript.Run(ctx, c.Client, []string{key}, value).Err()
 }
 
 // IsNil checks if error is redis.Nil
 func IsNil(err error) bool {
-	return err == redis.Nil
+	return errors.Is(err, redis.Nil)
 }
-
@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestDeletePattern(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	for i := range 250 {
+		mr.Set(fmt.Sprintf("session:%d", i), "v")
+	}
+	mr.Set("profile:1", "v")
+
+	deleted, err := client.DeletePattern(ctx, "session:*", WithScanCount(7), WithMaxKeys(100))
+	if !errors.Is(err, ErrDeleteLimitReached) || deleted != 100 {
+		t.Fatalf("limited DeletePattern = %d, %v", deleted, err)
+	}
+
+	// miniredis SCAN cursors shift on deletes, unlike Redis, so use a single page
+	deleted, err = client.DeletePattern(ctx, "session:*", WithScanCount(1000))
+	if err != nil || deleted != 150 {
+		t.Fatalf("DeletePattern = %d, %v", deleted, err)
+	}
+	if keys := mr.Keys(); len(keys) != 1 || keys[0] != "profile:1" {
+		t.Errorf("remaining keys = %v", keys)
+	}
+}
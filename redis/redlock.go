@@ -0,0 +1,191 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mrand "math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrLockNotAcquired is returned when lock quorum could not be reached
+var ErrLockNotAcquired = errors.New("redis: lock not acquired")
+
+// clockDriftMin is added to drift compensation for small TTLs
+const clockDriftMin = 2 * time.Millisecond
+
+var redlockExtendScript = DefaultScripts.Register("lock_extend", `
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("pexpire", KEYS[1], ARGV[2])
+	else
+		return 0
+	end
+`)
+
+// RedlockConfig configures Redlock
+type RedlockConfig struct {
+	// RetryCount - number of additional attempts after a failed acquisition
+	RetryCount int `yaml:"retry_count" env:"REDLOCK_RETRY_COUNT" env-default:"3"`
+	// RetryDelay - max random delay between attempts
+	RetryDelay time.Duration `yaml:"retry_delay" env:"REDLOCK_RETRY_DELAY" env-default:"200ms"`
+	// DriftFactor - clock drift compensation as a fraction of TTL
+	DriftFactor float64 `yaml:"drift_factor" env:"REDLOCK_DRIFT_FACTOR" env-default:"0.01"`
+	// NodeTimeout bounds a single node operation, so a dead node does not
+	// eat the lock validity time
+	NodeTimeout time.Duration `yaml:"node_timeout" env:"REDLOCK_NODE_TIMEOUT" env-default:"50ms"`
+}
+
+// Redlock is a distributed lock over independent Redis nodes.
+// A lock is held when a majority of nodes accepted it.
+type Redlock struct {
+	clients []*Client
+	quorum  int
+	cfg     RedlockConfig
+}
+
+// NewRedlock creates Redlock over independent (not replicated) Redis nodes
+func NewRedlock(clients []*Client, cfg RedlockConfig) *Redlock {
+	// Apply defaults if not set
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 200 * time.Millisecond
+	}
+	if cfg.DriftFactor <= 0 {
+		cfg.DriftFactor = 0.01
+	}
+	if cfg.NodeTimeout <= 0 {
+		cfg.NodeTimeout = 50 * time.Millisecond
+	}
+
+	return &Redlock{
+		clients: clients,
+		quorum:  len(clients)/2 + 1,
+		cfg:     cfg,
+	}
+}
+
+// Lease is an acquired Redlock lock
+type Lease struct {
+	redlock *Redlock
+	key     string
+	value   string
+	until   time.Time
+}
+
+// Key returns locked key
+func (l *Lease) Key() string {
+	return l.key
+}
+
+// Until returns the time until which the lock is guaranteed to be held
+func (l *Lease) Until() time.Time {
+	return l.until
+}
+
+// Lock acquires lock on key for ttl. The lease is valid for ttl minus
+// acquisition time and clock drift, see Lease.Until.
+func (r *Redlock) Lock(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	value, err := lockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt <= r.cfg.RetryCount; attempt++ {
+		if attempt > 0 {
+			delay := mrand.N(r.cfg.RetryDelay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		start := time.Now()
+		acquired := r.forEach(ctx, func(ctx context.Context, c *Client) (bool, error) {
+			return c.SetNX(ctx, key, value, ttl).Result()
+		})
+
+		validity := ttl - time.Since(start) - r.drift(ttl)
+		if acquired >= r.quorum && validity > 0 {
+			return &Lease{redlock: r, key: key, value: value, until: start.Add(ttl - r.drift(ttl))}, nil
+		}
+
+		// Release partial acquisition before retrying
+		r.release(ctx, key, value)
+	}
+
+	return nil, ErrLockNotAcquired
+}
+
+// Extend resets lease TTL on a quorum of nodes
+func (l *Lease) Extend(ctx context.Context, ttl time.Duration) error {
+	r := l.redlock
+	start := time.Now()
+	extended := r.forEach(ctx, func(ctx context.Context, c *Client) (bool, error) {
+		n, err := redlockExtendScript.Run(ctx, c.Client, []string{l.key}, l.value, ttl.Milliseconds()).Int64()
+		return n == 1, err
+	})
+
+	if extended < r.quorum || ttl-time.Since(start)-r.drift(ttl) <= 0 {
+		return fmt.Errorf("extend lock %s: %w", l.key, ErrLockNotAcquired)
+	}
+	l.until = start.Add(ttl - r.drift(ttl))
+	return nil
+}
+
+// Unlock releases lease on all nodes
+func (l *Lease) Unlock(ctx context.Context) error {
+	released := l.redlock.release(ctx, l.key, l.value)
+	if released == 0 {
+		return fmt.Errorf("unlock %s: lock expired or held by another owner", l.key)
+	}
+	return nil
+}
+
+// release deletes key on all nodes where it holds value
+func (r *Redlock) release(ctx context.Context, key, value string) int {
+	return r.forEach(context.WithoutCancel(ctx), func(ctx context.Context, c *Client) (bool, error) {
+		n, err := unlockScript.Run(ctx, c.Client, []string{key}, value).Int64()
+		return n == 1, err
+	})
+}
+
+// forEach runs op on all nodes concurrently and returns number of successes
+func (r *Redlock) forEach(ctx context.Context, op func(ctx context.Context, c *Client) (bool, error)) int {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+		ok int
+	)
+	for _, c := range r.clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeCtx, cancel := context.WithTimeout(ctx, r.cfg.NodeTimeout)
+			defer cancel()
+			if success, err := op(nodeCtx, c); err == nil && success {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return ok
+}
+
+// drift returns clock drift compensation for ttl
+func (r *Redlock) drift(ttl time.Duration) time.Duration {
+	return time.Duration(float64(ttl)*r.cfg.DriftFactor) + clockDriftMin
+}
+
+// lockToken returns random lock owner token
+func lockToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedlockNodes(t *testing.T, n int) ([]*miniredis.Miniredis, []*Client) {
+	t.Helper()
+	servers := make([]*miniredis.Miniredis, n)
+	clients := make([]*Client, n)
+	for i := range n {
+		servers[i] = miniredis.RunT(t)
+		clients[i] = &Client{Client: redis.NewClient(&redis.Options{Addr: servers[i].Addr(), MaxRetries: -1})}
+		t.Cleanup(func() { _ = clients[i].Close() })
+	}
+	return servers, clients
+}
+
+func TestRedlockMutualExclusion(t *testing.T) {
+	ctx := context.Background()
+	_, clients := newRedlockNodes(t, 3)
+	rl := NewRedlock(clients, RedlockConfig{})
+
+	lease, err := rl.Lock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rl.Lock(ctx, "job", time.Second); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("second Lock err = %v, want ErrLockNotAcquired", err)
+	}
+
+	if err := lease.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rl.Lock(ctx, "job", time.Second); err != nil {
+		t.Fatalf("Lock after Unlock: %v", err)
+	}
+}
+
+func TestRedlockSurvivesMinorityFailure(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 3)
+	servers[0].Close()
+
+	rl := NewRedlock(clients, RedlockConfig{})
+	lease, err := rl.Lock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lease.Until().After(time.Now()) {
+		t.Error("lease already expired")
+	}
+	if err := lease.Extend(ctx, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	servers[1].Close()
+	if _, err := rl.Lock(ctx, "other", time.Second); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("Lock without quorum err = %v, want ErrLockNotAcquired", err)
+	}
+}
@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Scripts is a registry of named Lua scripts. Scripts run via EVALSHA and
+// fall back to EVAL on NOSCRIPT, e.g. after a Redis restart or failover.
+type Scripts struct {
+	mu      sync.RWMutex
+	scripts map[string]*redis.Script
+	names   []string
+}
+
+// DefaultScripts holds scripts of package helpers (locks, rate limiters,
+// tags). New loads them on connect.
+var DefaultScripts = NewScripts()
+
+// NewScripts creates an empty registry
+func NewScripts() *Scripts {
+	return &Scripts{scripts: make(map[string]*redis.Script)}
+}
+
+// Register adds script under name and returns it. Scripts are registered
+// once at init, so registering a name twice panics.
+func (s *Scripts) Register(name, src string) *redis.Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.scripts[name]; ok {
+		panic(fmt.Sprintf("redis: script %q already registered", name))
+	}
+
+	script := redis.NewScript(src)
+	s.scripts[name] = script
+	s.names = append(s.names, name)
+	return script
+}
+
+// Get returns script by name
+func (s *Scripts) Get(name string) (*redis.Script, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	script, ok := s.scripts[name]
+	return script, ok
+}
+
+// Run runs script by name via EVALSHA with NOSCRIPT fallback
+func (s *Scripts) Run(ctx context.Context, c redis.Scripter, name string, keys []string, args ...any) *redis.Cmd {
+	script, ok := s.Get(name)
+	if !ok {
+		cmd := redis.NewCmd(ctx)
+		cmd.SetErr(fmt.Errorf("redis: script %q not registered", name))
+		return cmd
+	}
+	return script.Run(ctx, c, keys, args...)
+}
+
+// Load loads all scripts with SCRIPT LOAD so the first EVALSHA succeeds
+func (s *Scripts) Load(ctx context.Context, c redis.Scripter) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, name := range s.names {
+		if err := s.scripts[name].Load(ctx, c).Err(); err != nil {
+			return fmt.Errorf("load script %s: %w", name, err)
+		}
+	}
+	return nil
+}
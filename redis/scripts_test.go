@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestScriptsRunFallsBackOnNoScript(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	scripts := NewScripts()
+	scripts.Register("double", `return tonumber(ARGV[1]) * 2`)
+	if err := scripts.Load(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+
+	// Script cache is lost, e.g. after failover
+	if err := client.ScriptFlush(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := scripts.Run(ctx, client, "double", nil, 21).Int()
+	if err != nil || n != 42 {
+		t.Errorf("Run = %d, %v", n, err)
+	}
+	if err := scripts.Run(ctx, client, "missing", nil).Err(); err == nil {
+		t.Error("unknown script should fail")
+	}
+}
@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// tagKeyPrefix prefixes sets holding keys of a tag
+const tagKeyPrefix = "tag:"
+
+var (
+	// setTaggedScript sets KEYS[1] and adds it to tag sets KEYS[2..],
+	// extending tag set TTL so it outlives all its keys
+	setTaggedScript = DefaultScripts.Register("tags_set", `
+		local ttl = tonumber(ARGV[2])
+		if ttl > 0 then
+			redis.call("set", KEYS[1], ARGV[1], "px", ttl)
+		else
+			redis.call("set", KEYS[1], ARGV[1])
+		end
+		for i = 2, #KEYS do
+			redis.call("sadd", KEYS[i], KEYS[1])
+			if ttl > 0 then
+				local current = redis.call("pttl", KEYS[i])
+				if current == -1 and redis.call("scard", KEYS[i]) == 1 or current >= 0 and current < ttl then
+					redis.call("pexpire", KEYS[i], ttl)
+				end
+			else
+				redis.call("persist", KEYS[i])
+			end
+		end
+		return 1
+	`)
+
+	// invalidateTagScript deletes all keys of tag KEYS[1] and the tag set
+	invalidateTagScript = DefaultScripts.Register("tags_invalidate", `
+		local keys = redis.call("smembers", KEYS[1])
+		local deleted = 0
+		for i = 1, #keys, 1000 do
+			deleted = deleted + redis.call("unlink", unpack(keys, i, math.min(i + 999, #keys)))
+		end
+		redis.call("del", KEYS[1])
+		return deleted
+	`)
+)
+
+// TagKey returns key of the set holding keys tagged with tag
+func TagKey(tag string) string {
+	return tagKeyPrefix + tag
+}
+
+// SetJSONTagged sets a value as JSON with expiration and records key in tag
+// sets, so it can be removed with InvalidateTag. Tagged keys must be on
+// the same node as their tags (no Redis Cluster support).
+func (c *Client) SetJSONTagged(ctx context.Context, key string, value any, expiration time.Duration, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+
+	keys := make([]string, 0, len(tags)+1)
+	keys = append(keys, key)
+	for _, tag := range tags {
+		keys = append(keys, TagKey(tag))
+	}
+	return setTaggedScript.Run(ctx, c.Client, keys, data, expiration.Milliseconds()).Err()
+}
+
+// InvalidateTag atomically deletes all keys tagged with tag and returns
+// the number of deleted keys
+func (c *Client) InvalidateTag(ctx context.Context, tag string) (int64, error) {
+	return invalidateTagScript.Run(ctx, c.Client, []string{TagKey(tag)}).Int64()
+}
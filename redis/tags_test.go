@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := &Client{Client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	for key, tags := range map[string][]string{
+		"profile:42":  {"user:42"},
+		"feed:42":     {"user:42", "feeds"},
+		"feed:7":      {"feeds"},
+		"settings:42": nil,
+	} {
+		if err := client.SetJSONTagged(ctx, key, "v", time.Minute, tags...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ttl := mr.TTL(TagKey("user:42")); ttl != time.Minute {
+		t.Errorf("tag ttl = %s, want 1m", ttl)
+	}
+
+	deleted, err := client.InvalidateTag(ctx, "user:42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deleted)
+	}
+	for key, want := range map[string]bool{"profile:42": false, "feed:42": false, "feed:7": true, "settings:42": true, TagKey("user:42"): false} {
+		if got := mr.Exists(key); got != want {
+			t.Errorf("%s exists = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig holds TLS configuration for managed Redis (ElastiCache, Upstash)
+type TLSConfig struct {
+	Enabled bool `yaml:"enabled" env:"REDIS_TLS_ENABLED" env-default:"false"`
+	// CAFile - PEM bundle to verify server, system roots are used if empty
+	CAFile string `yaml:"ca_file" env:"REDIS_TLS_CA_FILE"`
+	// CertFile/KeyFile - client certificate for mTLS
+	CertFile           string `yaml:"cert_file" env:"REDIS_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"REDIS_TLS_KEY_FILE"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"REDIS_TLS_INSECURE_SKIP_VERIFY" env-default:"false"`
+}
+
+// config returns TLS config or nil if TLS is disabled
+func (c *TLSConfig) config(host string) (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         host,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,243 @@
+// Package sessions implements user sessions stored in Redis
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+)
+
+// ErrNotFound is returned for missing or expired sessions
+var ErrNotFound = errors.New("sessions: session not found")
+
+// createSessionScript stores session KEYS[2] with ID ARGV[2] for device
+// ARGV[1] in user index KEYS[1], deleting the session the device had.
+// Running it as one script keeps concurrent creates for the same device
+// from leaving an orphaned session behind.
+var createSessionScript = redis.DefaultScripts.Register("sessions_create", `
+	local ttl = tonumber(ARGV[4])
+	local previous = redis.call("hget", KEYS[1], ARGV[1])
+	if previous then
+		redis.call("del", ARGV[5] .. previous)
+	end
+	redis.call("set", KEYS[2], ARGV[3], "px", ttl)
+	redis.call("hset", KEYS[1], ARGV[1], ARGV[2])
+	redis.call("pexpire", KEYS[1], ttl)
+	return 1
+`)
+
+// Config holds session store configuration
+type Config struct {
+	// Prefix is prepended to all keys
+	Prefix string `yaml:"prefix" env:"SESSIONS_PREFIX" env-default:"session:"`
+	// TTL - idle timeout, extended by every RefreshSession
+	TTL time.Duration `yaml:"ttl" env:"SESSIONS_TTL" env-default:"720h"`
+}
+
+// Session is a stored user session with typed payload
+type Session[T any] struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	DeviceID   string    `json:"device_id"`
+	Data       T         `json:"data"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Store keeps sessions with sliding TTL. Each user has a device index, so
+// there is at most one session per device and all user sessions can be
+// listed or revoked at once.
+type Store[T any] struct {
+	client *redis.Client
+	cfg    Config
+}
+
+// New creates a new session store
+func New[T any](client *redis.Client, cfg Config) *Store[T] {
+	// Apply defaults if not set
+	if cfg.Prefix == "" {
+		cfg.Prefix = "session:"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 720 * time.Hour
+	}
+
+	return &Store[T]{client: client, cfg: cfg}
+}
+
+// CreateSession creates session for user on device replacing the previous
+// session of that device
+func (s *Store[T]) CreateSession(ctx context.Context, userID, deviceID string, data T) (*Session[T], error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	session := &Session[T]{
+		ID:         id,
+		UserID:     userID,
+		DeviceID:   deviceID,
+		Data:       data,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.cfg.TTL),
+	}
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("marshal session: %w", err)
+	}
+
+	keys := []string{s.userKey(userID), s.sessionKey(id)}
+	err = createSessionScript.Run(ctx, s.client.Client, keys, deviceID, id, payload, s.cfg.TTL.Milliseconds(), s.cfg.Prefix).Err()
+	if err != nil {
+		return nil, fmt.Errorf("store session: %w", err)
+	}
+	return session, nil
+}
+
+// GetSession returns session by ID without extending it
+func (s *Store[T]) GetSession(ctx context.Context, id string) (*Session[T], error) {
+	data, err := s.client.Get(ctx, s.sessionKey(id)).Bytes()
+	if redis.IsNil(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+
+	var session Session[T]
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+	return &session, nil
+}
+
+// RefreshSession extends session TTL, call it on user activity
+func (s *Store[T]) RefreshSession(ctx context.Context, id string) (*Session[T], error) {
+	session, err := s.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(s.cfg.TTL)
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("marshal session: %w", err)
+	}
+
+	var updated *goredis.StatusCmd
+	_, err = s.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		// XX so a concurrently deleted session is not resurrected
+		updated = pipe.SetArgs(ctx, s.sessionKey(id), payload, goredis.SetArgs{Mode: "XX", TTL: s.cfg.TTL})
+		pipe.Expire(ctx, s.userKey(session.UserID), s.cfg.TTL)
+		return nil
+	})
+	if redis.IsNil(updated.Err()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("refresh session: %w", err)
+	}
+	return session, nil
+}
+
+// DeleteSession deletes session by ID
+func (s *Store[T]) DeleteSession(ctx context.Context, id string) error {
+	session, err := s.GetSession(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Del(ctx, s.sessionKey(id))
+		pipe.HDel(ctx, s.userKey(session.UserID), session.DeviceID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+// ListForUser returns active sessions of user
+func (s *Store[T]) ListForUser(ctx context.Context, userID string) ([]*Session[T], error) {
+	ids, err := s.client.HVals(ctx, s.userKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get user sessions: %w", err)
+	}
+
+	sessions := make([]*Session[T], 0, len(ids))
+	for _, id := range ids {
+		session, err := s.GetSession(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			// Expired, index entry is cleaned up on next create or delete
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// DeleteAllForUser deletes all sessions of user and returns number of
+// deleted sessions
+func (s *Store[T]) DeleteAllForUser(ctx context.Context, userID string) (int64, error) {
+	index := s.userKey(userID)
+	ids, err := s.client.HVals(ctx, index).Result()
+	if err != nil {
+		return 0, fmt.Errorf("get user sessions: %w", err)
+	}
+
+	var deleted *goredis.IntCmd
+	_, err = s.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		if len(ids) > 0 {
+			keys := make([]string, len(ids))
+			for i, id := range ids {
+				keys[i] = s.sessionKey(id)
+			}
+			deleted = pipe.Del(ctx, keys...)
+		}
+		pipe.Del(ctx, index)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("delete user sessions: %w", err)
+	}
+	if deleted == nil {
+		return 0, nil
+	}
+	return deleted.Val(), nil
+}
+
+func (s *Store[T]) sessionKey(id string) string {
+	return s.cfg.Prefix + id
+}
+
+func (s *Store[T]) userKey(userID string) string {
+	return s.cfg.Prefix + "user:" + userID
+}
+
+// newSessionID returns random URL-safe session ID
+func newSessionID() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generate session id: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,148 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"gitlab.com/xakpro/cg-shared-libs/redis"
+)
+
+type payload struct {
+	Role string `json:"role"`
+}
+
+func newTestStore(t *testing.T, cfg Config) (*Store[payload], *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := &redis.Client{Client: goredis.NewClient(&goredis.Options{Addr: mr.Addr()})}
+	t.Cleanup(func() { _ = client.Close() })
+	return New[payload](client, cfg), mr
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	store, mr := newTestStore(t, Config{TTL: time.Hour})
+	ctx := context.Background()
+
+	created, err := store.CreateSession(ctx, "42", "phone", payload{Role: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetSession(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UserID != "42" || got.Data.Role != "admin" {
+		t.Errorf("got %+v", got)
+	}
+
+	// Sliding TTL
+	mr.FastForward(50 * time.Minute)
+	if _, err := store.RefreshSession(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(50 * time.Minute)
+	if _, err := store.GetSession(ctx, created.ID); err != nil {
+		t.Fatalf("refreshed session expired: %v", err)
+	}
+
+	mr.FastForward(time.Hour)
+	if _, err := store.GetSession(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if _, err := store.RefreshSession(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("refresh err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSessionPerDevice(t *testing.T) {
+	store, _ := newTestStore(t, Config{})
+	ctx := context.Background()
+
+	first, err := store.CreateSession(ctx, "42", "phone", payload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateSession(ctx, "42", "phone", payload{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateSession(ctx, "42", "laptop", payload{}); err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.CreateSession(ctx, "7", "phone", payload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetSession(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("replaced session err = %v, want ErrNotFound", err)
+	}
+	sessions, err := store.ListForUser(ctx, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("sessions = %d, want 2", len(sessions))
+	}
+
+	deleted, err := store.DeleteAllForUser(ctx, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deleted)
+	}
+	if sessions, _ := store.ListForUser(ctx, "42"); len(sessions) != 0 {
+		t.Errorf("sessions after delete = %d, want 0", len(sessions))
+	}
+	if _, err := store.GetSession(ctx, other.ID); err != nil {
+		t.Errorf("other user session: %v", err)
+	}
+}
+
+func TestSessionConcurrentCreate(t *testing.T) {
+	store, mr := newTestStore(t, Config{})
+	ctx := context.Background()
+
+	const creates = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, creates)
+	for range creates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.CreateSession(ctx, "42", "phone", payload{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Exactly one session survives and it is the indexed one
+	var sessionKeys []string
+	for _, key := range mr.Keys() {
+		if !strings.HasPrefix(key, "session:user:") {
+			sessionKeys = append(sessionKeys, key)
+		}
+	}
+	if len(sessionKeys) != 1 {
+		t.Fatalf("session keys = %v, want one", sessionKeys)
+	}
+	sessions, err := store.ListForUser(ctx, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || "session:"+sessions[0].ID != sessionKeys[0] {
+		t.Errorf("indexed sessions = %v, want %s", sessions, sessionKeys[0])
+	}
+}
@@ -19,6 +19,9 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// DefaultOTLPEndpoint is the default OTLP gRPC collector endpoint
+const DefaultOTLPEndpoint = "localhost:4317"
+
 // Config holds OpenTelemetry configuration
 type Config struct {
 	ServiceName    string
@@ -54,7 +57,7 @@ func Init(ctx context.Context, cfg Config) (func(), error) {
 	// Create OTLP exporter
 	otlpEndpoint := cfg.OTLPEndpoint
 	if otlpEndpoint == "" {
-		otlpEndpoint = "localhost:4317" // default OTLP gRPC endpoint
+		otlpEndpoint = DefaultOTLPEndpoint
 	}
 
 	exporter, err := otlptracegrpc.New(ctx,